@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/internal/app/envelope"
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+	"github.com/AndreyAD1/test-signer/internal/configuration"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditFrom string
+	auditTo   string
+	auditCmd  = &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit trail.",
+	}
+	auditExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export audit events as JSON lines to stdout.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, err := parseOptionalTime(auditFrom)
+			if err != nil {
+				return fmt.Errorf("invalid 'from': %w", err)
+			}
+			to, err := parseOptionalTime(auditTo)
+			if err != nil {
+				return fmt.Errorf("invalid 'to': %w", err)
+			}
+			ctx := adminContext()
+			dbPool, err := openDatabase(ctx)
+			if err != nil {
+				return err
+			}
+			defer dbPool.Close()
+			auditSvc := services.NewAuditSvc(r.NewAuditCollection(dbPool), services.SystemClock{})
+			output := bufio.NewWriter(os.Stdout)
+			if err := auditSvc.ExportEvents(ctx, from, to, output); err != nil {
+				return err
+			}
+			return output.Flush()
+		},
+	}
+)
+
+var auditVerifyChainCmd = &cobra.Command{
+	Use:   "verify-chain",
+	Short: "Verify the hash chain over stored signatures.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config := configuration.MasterKeyConfig{}
+		if err := configuration.Load(&config, configFile, flagOverrides()); err != nil {
+			return fmt.Errorf("a configuration error: %w", err)
+		}
+		// answers are decrypted and compared with their stored hash
+		masterKeys, err := envelope.NewMasterKeys(config.MasterKey, config.RetiredMasterKeys)
+		if err != nil {
+			return err
+		}
+		ctx := adminContext()
+		dbPool, err := r.NewPool(ctx, config.DatabaseURL)
+		if err != nil {
+			return err
+		}
+		defer dbPool.Close()
+		auditSvc := services.NewAuditSvc(r.NewAuditCollection(dbPool), services.SystemClock{})
+		chainSvc := services.NewChainSvc(r.NewSignatureCollection(dbPool, masterKeys), auditSvc)
+		report, err := chainSvc.VerifyChain(ctx)
+		if err != nil {
+			return err
+		}
+		if report.Break != nil {
+			return fmt.Errorf(
+				"the chain is broken at record %d (signature %s) after %d valid records: %s",
+				report.Break.Seq,
+				report.Break.SignatureID,
+				report.Records,
+				report.Break.Reason,
+			)
+		}
+		fmt.Printf("the chain is intact: %d records\n", report.Records)
+		return nil
+	},
+}
+
+func init() {
+	auditExportCmd.Flags().StringVar(&auditFrom, "from", "", "an RFC 3339 start time, inclusive")
+	auditExportCmd.Flags().StringVar(&auditTo, "to", "", "an RFC 3339 end time, exclusive")
+	auditCmd.AddCommand(auditExportCmd, auditVerifyChainCmd)
+	RootCmd.AddCommand(auditCmd)
+}
+
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
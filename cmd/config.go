@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/AndreyAD1/test-signer/internal/app"
+	"github.com/AndreyAD1/test-signer/internal/configuration"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect a server configuration.",
+	}
+	configPrintCmd = &cobra.Command{
+		Use:   "print",
+		Short: "Print an effective configuration with masked secrets.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadServerConfig()
+			if err != nil {
+				return fmt.Errorf("a configuration error: %w", err)
+			}
+			writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for _, variable := range configuration.Variables(config) {
+				fmt.Fprintf(writer, "%s\t%s\n", variable.Name, variable.Value)
+			}
+			return writer.Flush()
+		},
+	}
+	configValidateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Check a configuration, its keys and certificates.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadServerConfig()
+			if err != nil {
+				return fmt.Errorf("a configuration error: %w", err)
+			}
+			if err := app.ValidateConfig(config); err != nil {
+				return fmt.Errorf("an invalid configuration: %w", err)
+			}
+			fmt.Println("the configuration is valid")
+			return nil
+		},
+	}
+)
+
+func init() {
+	configCmd.AddCommand(configPrintCmd, configValidateCmd)
+	RootCmd.AddCommand(configCmd)
+}
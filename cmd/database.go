@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/user"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+	"github.com/AndreyAD1/test-signer/internal/configuration"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func openDatabase(ctx context.Context) (*pgxpool.Pool, error) {
+	config := configuration.DatabaseConfig{}
+	if err := configuration.Load(&config, configFile, flagOverrides()); err != nil {
+		return nil, fmt.Errorf("a configuration error: %w", err)
+	}
+	return r.NewPool(ctx, config.DatabaseURL)
+}
+
+// adminContext identifies an operating system user as an audit actor
+// of administrative commands. An operator acts on behalf of all tenants.
+func adminContext() context.Context {
+	actorID := "cli:unknown"
+	if currentUser, err := user.Current(); err == nil {
+		actorID = "cli:" + currentUser.Username
+	}
+	return services.ContextWithActor(context.Background(), services.Actor{ID: actorID, AllTenants: true})
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+	"github.com/spf13/cobra"
+)
+
+var (
+	holdTenant           string
+	holdSignatureID      string
+	holdUserID           string
+	holdRequestIDPattern string
+	holdReason           string
+	holdIncludeLifted    bool
+	holdCmd              = &cobra.Command{
+		Use:   "hold",
+		Short: "Manage legal holds which freeze signatures.",
+	}
+	holdPlaceCmd = &cobra.Command{
+		Use:   "place",
+		Short: "Place a legal hold on a signature, a user or tests.",
+		Long: "Place a legal hold on a signature (--signature), all signatures of a user (--user) " +
+			"or signatures of tests whose request IDs match an SQL LIKE pattern " +
+			"(--request-id-pattern). Held signatures are neither erased nor purged.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withLegalHoldSvc(func(ctx context.Context, svc *services.LegalHoldSvc) error {
+				hold, err := svc.PlaceHold(ctx, services.LegalHold{
+					TenantID:         holdTenant,
+					SignatureID:      holdSignatureID,
+					UserID:           holdUserID,
+					RequestIDPattern: holdRequestIDPattern,
+					Reason:           holdReason,
+				})
+				if err != nil {
+					return err
+				}
+				fmt.Printf("legal hold %s is placed\n", hold.ID)
+				return nil
+			})
+		},
+	}
+	holdListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List active legal holds.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withLegalHoldSvc(func(ctx context.Context, svc *services.LegalHoldSvc) error {
+				holds, err := svc.ListHolds(ctx, holdIncludeLifted)
+				if err != nil {
+					return err
+				}
+				writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+				fmt.Fprintln(writer, "ID\tTENANT\tTARGET\tREASON\tPLACED\tLIFTED")
+				for _, hold := range holds {
+					tenantID := "-"
+					if hold.TenantID != "" {
+						tenantID = hold.TenantID
+					}
+					target := "request_id LIKE " + hold.RequestIDPattern
+					if hold.SignatureID != "" {
+						target = "signature " + hold.SignatureID
+					}
+					if hold.UserID != "" {
+						target = "user " + hold.UserID
+					}
+					lifted := "-"
+					if hold.LiftedAt != nil {
+						lifted = hold.LiftedAt.Format(time.RFC3339)
+					}
+					fmt.Fprintf(
+						writer,
+						"%s\t%s\t%s\t%s\t%s\t%s\n",
+						hold.ID,
+						tenantID,
+						target,
+						hold.Reason,
+						hold.PlacedAt.Format(time.RFC3339),
+						lifted,
+					)
+				}
+				return writer.Flush()
+			})
+		},
+	}
+	holdLiftCmd = &cobra.Command{
+		Use:   "lift <hold ID>",
+		Short: "Lift a legal hold.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withLegalHoldSvc(func(ctx context.Context, svc *services.LegalHoldSvc) error {
+				if _, err := svc.LiftHold(ctx, args[0]); err != nil {
+					return err
+				}
+				fmt.Printf("legal hold %s is lifted\n", args[0])
+				return nil
+			})
+		},
+	}
+)
+
+func init() {
+	holdPlaceCmd.Flags().StringVar(&holdTenant, "tenant", "", "a tenant ID of held signatures, a default tenant if empty")
+	holdPlaceCmd.Flags().StringVar(&holdSignatureID, "signature", "", "an ID of a held signature")
+	holdPlaceCmd.Flags().StringVar(&holdUserID, "user", "", "a user ID whose signatures are held")
+	holdPlaceCmd.Flags().StringVar(
+		&holdRequestIDPattern,
+		"request-id-pattern",
+		"",
+		"an SQL LIKE pattern of request IDs of held tests, e.g. 'exam-2024-%'",
+	)
+	holdPlaceCmd.Flags().StringVar(&holdReason, "reason", "", "a reason of the hold, e.g. a case number")
+	holdPlaceCmd.MarkFlagRequired("reason")
+	holdPlaceCmd.MarkFlagsMutuallyExclusive("signature", "user", "request-id-pattern")
+	holdPlaceCmd.MarkFlagsOneRequired("signature", "user", "request-id-pattern")
+	holdListCmd.Flags().BoolVar(&holdIncludeLifted, "all", false, "include lifted holds")
+	holdCmd.AddCommand(holdPlaceCmd, holdListCmd, holdLiftCmd)
+	RootCmd.AddCommand(holdCmd)
+}
+
+func withLegalHoldSvc(action func(context.Context, *services.LegalHoldSvc) error) error {
+	ctx := adminContext()
+	dbPool, err := openDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer dbPool.Close()
+	auditSvc := services.NewAuditSvc(r.NewAuditCollection(dbPool), services.SystemClock{})
+	return action(
+		ctx,
+		services.NewLegalHoldSvc(r.NewLegalHoldCollection(dbPool), auditSvc, services.SystemClock{}),
+	)
+}
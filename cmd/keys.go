@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AndreyAD1/test-signer/internal/app/envelope"
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+	"github.com/AndreyAD1/test-signer/internal/configuration"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keysCmd = &cobra.Command{
+		Use:   "keys",
+		Short: "Manage keys which encrypt stored answers.",
+	}
+	keysRewrapCmd = &cobra.Command{
+		Use:   "rewrap",
+		Short: "Wrap data keys of all signatures with the current MASTER_KEY.",
+		Long: "Wrap data keys of all signatures with the current MASTER_KEY. " +
+			"Data keys wrapped by RETIRED_MASTER_KEYS are unwrapped and wrapped again, " +
+			"answers are not re-encrypted. A retired master key can be removed afterwards.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := configuration.MasterKeyConfig{}
+			if err := configuration.Load(&config, configFile, flagOverrides()); err != nil {
+				return fmt.Errorf("a configuration error: %w", err)
+			}
+			masterKeys, err := envelope.NewMasterKeys(config.MasterKey, config.RetiredMasterKeys)
+			if err != nil {
+				return err
+			}
+			ctx := adminContext()
+			dbPool, err := r.NewPool(ctx, config.DatabaseURL)
+			if err != nil {
+				return err
+			}
+			defer dbPool.Close()
+			auditSvc := services.NewAuditSvc(r.NewAuditCollection(dbPool), services.SystemClock{})
+			masterKeySvc := services.NewMasterKeySvc(
+				r.NewSignatureCollection(dbPool, masterKeys),
+				auditSvc,
+			)
+			rewrapped, err := masterKeySvc.RewrapDataKeys(ctx)
+			if err != nil {
+				return fmt.Errorf("%d data keys are rewrapped before an error: %w", rewrapped, err)
+			}
+			fmt.Printf("%d data keys are rewrapped with the master key %s\n", rewrapped, masterKeys.CurrentID())
+			return nil
+		},
+	}
+)
+
+func init() {
+	keysCmd.AddCommand(keysRewrapCmd)
+	RootCmd.AddCommand(keysCmd)
+}
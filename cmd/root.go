@@ -3,32 +3,45 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
 	"github.com/AndreyAD1/test-signer/internal/app"
+	"github.com/AndreyAD1/test-signer/internal/app/logging"
 	"github.com/AndreyAD1/test-signer/internal/configuration"
-	"github.com/caarlos0/env/v9"
 	"github.com/spf13/cobra"
 )
 
 var (
 	apiSecret   string
 	databaseURL string
+	configFile  string
 	debug       bool
+	logLevel    *slog.LevelVar
 	RootCmd     = &cobra.Command{
 		Use:   "test-signer",
 		Short: "The 'Test Signer' service.",
 		Long: `The Test signer is a service that accepts a set of answers and 
 	questions and signs that the user has finished the " test " at this point in time. 
 	The signatures are stored and can later be verified by a different service.`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			level := slog.LevelInfo
+			if debug {
+				level = slog.LevelDebug
+			}
+			logLevel = logging.Setup(os.Stderr, level)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return run()
+			return serverSupervisor.run(context.Background(), run)
 		},
 	}
-	panicCounter   int
-	panicThreshold = 10
+	serverSupervisor = supervisor{
+		maxRestarts: 10,
+		window:      10 * time.Minute,
+		minBackoff:  time.Second,
+		maxBackoff:  time.Minute,
+	}
 )
 
 func Execute() error {
@@ -43,14 +56,22 @@ func init() {
 		"",
 		"a secret to manage a JWT token",
 	)
-	RootCmd.Flags().StringVarP(
+	RootCmd.PersistentFlags().StringVarP(
 		&databaseURL,
 		"dburl",
 		"u",
 		"",
 		"a database URL",
 	)
-	RootCmd.Flags().BoolP(
+	RootCmd.PersistentFlags().StringVarP(
+		&configFile,
+		"config",
+		"c",
+		"",
+		"a YAML or TOML configuration file, CONFIG_FILE by default",
+	)
+	RootCmd.PersistentFlags().BoolVarP(
+		&debug,
 		"debug",
 		"d",
 		false,
@@ -58,40 +79,41 @@ func init() {
 	)
 }
 
-func run() error {
-	defer func() {
-		p := recover()
-		if p == nil {
-			return
-		}
-		panicCounter++
-		if panicCounter >= panicThreshold {
-			log.Printf("too many panics: %v", panicCounter)
-			return
-		}
-		run()
-	}()
-
-	if apiSecret != "" {
-		os.Setenv("API_SECRET", apiSecret)
-	}
-	if databaseURL != "" {
-		os.Setenv("DATABASE_URL", databaseURL)
-	}
-	if debug {
-		os.Setenv("DEBUG", "true")
-	}
-	config := configuration.ServerConfig{}
-	err := env.Parse(&config)
+func run(ctx context.Context) error {
+	config, err := loadServerConfig()
 	if err != nil {
 		return fmt.Errorf("a configuration error: %w", err)
 	}
+	logLevel.Set(config.Level())
 
-	ctx := context.Background()
-	server, err := app.NewServer(ctx, config)
+	server, err := app.NewServer(ctx, config, loadServerConfig, logLevel)
 	if err != nil {
 		return fmt.Errorf("can not create a new server: %w", err)
 	}
-	defer server.Shutdown(10 * time.Second)
+	// release components if Run panics, it is a no-op after a normal stop
+	defer server.Close(ctx)
 	return server.Run(ctx)
 }
+
+// loadServerConfig is also called on SIGHUP to reload a configuration.
+func loadServerConfig() (configuration.ServerConfig, error) {
+	config := configuration.ServerConfig{}
+	err := configuration.Load(&config, configFile, flagOverrides())
+	return config, err
+}
+
+// flagOverrides takes precedence over environment variables
+// and a configuration file.
+func flagOverrides() map[string]string {
+	overrides := map[string]string{}
+	if apiSecret != "" {
+		overrides["API_SECRET"] = apiSecret
+	}
+	if databaseURL != "" {
+		overrides["DATABASE_URL"] = databaseURL
+	}
+	if debug {
+		overrides["DEBUG"] = "true"
+	}
+	return overrides
+}
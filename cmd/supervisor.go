@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	runtimeDebug "runtime/debug"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/internal/app"
+)
+
+// supervisor restarts a server after a panic with an exponential backoff.
+// It gives up after maxRestarts panics within a window. Errors are not
+// retried: a restart does not fix a configuration or a start-up problem.
+type supervisor struct {
+	maxRestarts int
+	window      time.Duration
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+}
+
+func (s supervisor) run(ctx context.Context, runServer func(context.Context) error) error {
+	var panics []time.Time
+	backoff := s.minBackoff
+	for {
+		err := runRecovered(ctx, runServer)
+		if !errors.Is(err, app.ErrPanic) {
+			return err
+		}
+		now := time.Now()
+		recentPanics := panics[:0]
+		for _, panicTime := range panics {
+			if now.Sub(panicTime) < s.window {
+				recentPanics = append(recentPanics, panicTime)
+			}
+		}
+		panics = append(recentPanics, now)
+		if len(panics) > s.maxRestarts {
+			return fmt.Errorf("%d panics within %v: %w", len(panics), s.window, err)
+		}
+		slog.Error("restart a server after a panic", "error", err, "backoff", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(2*backoff, s.maxBackoff)
+	}
+}
+
+func runRecovered(ctx context.Context, runServer func(context.Context) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			slog.Error("a server panic", "panic", fmt.Sprint(p), "stack", string(runtimeDebug.Stack()))
+			err = fmt.Errorf("%w: %v", app.ErrPanic, p)
+		}
+	}()
+	return runServer(ctx)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/internal/app/timestamping"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tsaAddress  string
+	tsaCertFile string
+	tsaKeyFile  string
+	tsaCmd      = &cobra.Command{
+		Use:   "tsa",
+		Short: "Run the built-in RFC 3161 timestamp authority.",
+	}
+	tsaServeCmd = &cobra.Command{
+		Use:   "serve",
+		Short: "Serve RFC 3161 timestamp queries over HTTP.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			authority, err := timestamping.NewLocalAuthority(tsaCertFile, tsaKeyFile)
+			if err != nil {
+				return err
+			}
+			server := http.Server{
+				Addr:              tsaAddress,
+				Handler:           authority,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			slog.Info("serve timestamp queries", "address", tsaAddress)
+			return server.ListenAndServe()
+		},
+	}
+)
+
+func init() {
+	tsaServeCmd.Flags().StringVar(&tsaAddress, "address", "localhost:3161", "a listen address")
+	tsaServeCmd.Flags().StringVar(&tsaCertFile, "cert", "", "a PEM TSA certificate with the timeStamping usage")
+	tsaServeCmd.Flags().StringVar(&tsaKeyFile, "key", "", "a PEM TSA private key")
+	tsaServeCmd.MarkFlagRequired("cert")
+	tsaServeCmd.MarkFlagRequired("key")
+	tsaCmd.AddCommand(tsaServeCmd)
+	RootCmd.AddCommand(tsaCmd)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+	"github.com/spf13/cobra"
+)
+
+var (
+	userTenant string
+	userIssuer string
+	userCmd    = &cobra.Command{
+		Use:   "user",
+		Short: "Manage data of users.",
+	}
+	userEraseCmd = &cobra.Command{
+		Use:   "erase <user ID>",
+		Short: "Erase answers of all signatures of a user.",
+		Long: "Erase answers of all signatures of a user on a request to erasure. " +
+			"Data keys and answers are destroyed, signatures stay as tombstones " +
+			"and verify as valid with erased content.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := adminContext()
+			actor := services.ActorFromContext(ctx)
+			actor.TenantID = userTenant
+			ctx = services.ContextWithActor(ctx, actor)
+			dbPool, err := openDatabase(ctx)
+			if err != nil {
+				return err
+			}
+			defer dbPool.Close()
+			auditSvc := services.NewAuditSvc(r.NewAuditCollection(dbPool), services.SystemClock{})
+			erasureSvc := services.NewErasureSvc(
+				r.NewSignatureCollection(dbPool, nil),
+				auditSvc,
+				services.SystemClock{},
+			)
+			owner := services.Owner{UserID: args[0], Issuer: userIssuer, TenantID: userTenant}
+			erasure, err := erasureSvc.EraseUser(ctx, owner)
+			if err != nil {
+				return err
+			}
+			for _, signatureID := range erasure.SignatureIDs {
+				fmt.Printf("signature %s is erased\n", signatureID)
+			}
+			fmt.Printf("%d signatures of the user %s are erased\n", len(erasure.SignatureIDs), args[0])
+			return nil
+		},
+	}
+)
+
+func init() {
+	userEraseCmd.Flags().StringVar(&userTenant, "tenant", "", "a tenant ID of the user, a default tenant if empty")
+	userEraseCmd.Flags().StringVar(&userIssuer, "issuer", "", "an identity provider of the user, any issuer if empty")
+	userCmd.AddCommand(userEraseCmd)
+	RootCmd.AddCommand(userCmd)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifierName   string
+	verifierScopes []string
+	verifierCert   string
+	verifierTenant string
+	verifierCmd    = &cobra.Command{
+		Use:   "verifier",
+		Short: "Manage verifier clients of the verify endpoint.",
+	}
+	verifierCreateCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Register a verifier and print its API key.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withVerifierSvc(func(ctx context.Context, svc *services.VerifierSvc) error {
+				verifier, apiKey, err := svc.CreateVerifier(
+					ctx,
+					verifierName,
+					verifierScopes,
+					verifierCert,
+					verifierTenant,
+				)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("verifier ID: %s\n", verifier.ID)
+				fmt.Printf("API key: %s\n", apiKey)
+				fmt.Println("Store the API key now: it can not be shown again.")
+				return nil
+			})
+		},
+	}
+	verifierListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List verifiers.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withVerifierSvc(func(ctx context.Context, svc *services.VerifierSvc) error {
+				verifiers, err := svc.ListVerifiers(ctx)
+				if err != nil {
+					return err
+				}
+				writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+				fmt.Fprintln(writer, "ID\tNAME\tTENANT\tSCOPES\tCERTIFICATE\tCREATED\tREVOKED")
+				for _, v := range verifiers {
+					revoked := "-"
+					if v.RevokedAt != nil {
+						revoked = v.RevokedAt.Format(time.RFC3339)
+					}
+					certIdentity := "-"
+					if v.CertIdentity != "" {
+						certIdentity = v.CertIdentity
+					}
+					tenantID := "-"
+					if v.TenantID != "" {
+						tenantID = v.TenantID
+					}
+					fmt.Fprintf(
+						writer,
+						"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+						v.ID,
+						v.Name,
+						tenantID,
+						strings.Join(v.Scopes, ","),
+						certIdentity,
+						v.CreatedAt.Format(time.RFC3339),
+						revoked,
+					)
+				}
+				return writer.Flush()
+			})
+		},
+	}
+	verifierRevokeCmd = &cobra.Command{
+		Use:   "revoke <verifier ID>",
+		Short: "Revoke an API key of a verifier.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withVerifierSvc(func(ctx context.Context, svc *services.VerifierSvc) error {
+				if err := svc.RevokeVerifier(ctx, args[0]); err != nil {
+					return err
+				}
+				fmt.Printf("verifier %s is revoked\n", args[0])
+				return nil
+			})
+		},
+	}
+)
+
+func init() {
+	verifierCreateCmd.Flags().StringVarP(
+		&verifierName,
+		"name",
+		"n",
+		"",
+		"a unique verifier name",
+	)
+	verifierCreateCmd.MarkFlagRequired("name")
+	verifierCreateCmd.Flags().StringSliceVar(
+		&verifierScopes,
+		"scope",
+		[]string{services.ScopeVerify},
+		fmt.Sprintf("verifier scopes: %s", strings.Join(services.KnownScopes, ", ")),
+	)
+	verifierCreateCmd.Flags().StringVar(
+		&verifierCert,
+		"cert-identity",
+		"",
+		"a client certificate identity for mutual TLS: a URI SAN, a DNS SAN or a subject CN",
+	)
+	verifierCreateCmd.Flags().StringVar(
+		&verifierTenant,
+		"tenant",
+		"",
+		"a tenant ID of signatures the verifier can verify, a default tenant if empty",
+	)
+	verifierCmd.AddCommand(verifierCreateCmd, verifierListCmd, verifierRevokeCmd)
+	RootCmd.AddCommand(verifierCmd)
+}
+
+func withVerifierSvc(action func(context.Context, *services.VerifierSvc) error) error {
+	ctx := adminContext()
+	dbPool, err := openDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer dbPool.Close()
+	auditSvc := services.NewAuditSvc(r.NewAuditCollection(dbPool), services.SystemClock{})
+	return action(ctx, services.NewVerifierSvc(r.NewVerifierCollection(dbPool), auditSvc, services.SystemClock{}))
+}
@@ -0,0 +1,82 @@
+// Package admin serves operational endpoints of the admin listener.
+// They disclose internals, so the listener must not be reachable publicly.
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"runtime/debug"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/internal/configuration"
+)
+
+// Key identifies a key in use by a fingerprint.
+type Key struct {
+	Name        string     `json:"name"`
+	Algorithm   string     `json:"algorithm"`
+	Fingerprint string     `json:"fingerprint"`
+	NotAfter    *time.Time `json:"not_after,omitempty"`
+}
+
+type BuildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	Settings  map[string]string `json:"settings"`
+}
+
+// RegisterProfiler serves runtime profiles under /debug/pprof/. A command
+// line is not served: flags like --secret and --dburl carry secrets.
+func RegisterProfiler(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// BuildInfoHandler reports a Go version, a module version and
+// VCS settings of a binary.
+func BuildInfoHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			http.Error(w, "no build information", http.StatusNotFound)
+			return
+		}
+		response := BuildInfo{
+			GoVersion: info.GoVersion,
+			Path:      info.Main.Path,
+			Version:   info.Main.Version,
+			Settings:  map[string]string{},
+		}
+		for _, setting := range info.Settings {
+			response.Settings[setting.Key] = setting.Value
+		}
+		writeJSON(w, response)
+	}
+}
+
+// ConfigHandler reports an effective configuration with masked secrets.
+func ConfigHandler(variables func() []configuration.Variable) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, variables())
+	}
+}
+
+// KeysHandler reports fingerprints of keys and certificates in use.
+func KeysHandler(keys func() []Key) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, keys())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("response composition error", "error", err)
+	}
+}
@@ -0,0 +1,28 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProfilerHidesCommandLine(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterProfiler(mux)
+	response := httptest.NewRecorder()
+	mux.ServeHTTP(response, httptest.NewRequest("GET", "/debug/pprof/cmdline", nil))
+	if response.Code != http.StatusNotFound {
+		t.Errorf("unexpected status of a command line: %d", response.Code)
+	}
+	if strings.Contains(response.Body.String(), os.Args[0]) {
+		t.Error("a command line is served")
+	}
+
+	response = httptest.NewRecorder()
+	mux.ServeHTTP(response, httptest.NewRequest("GET", "/debug/pprof/goroutine?debug=1", nil))
+	if response.Code != http.StatusOK {
+		t.Errorf("unexpected status of a goroutine profile: %d", response.Code)
+	}
+}
@@ -0,0 +1,331 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/internal/app/tracing"
+	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("github.com/AndreyAD1/test-signer/internal/app/auth")
+
+var (
+	hmacAlgorithms = map[string]bool{
+		jwt.SigningMethodHS256.Alg(): true,
+		jwt.SigningMethodHS384.Alg(): true,
+		jwt.SigningMethodHS512.Alg(): true,
+	}
+	asymmetricAlgorithms = map[string]bool{
+		jwt.SigningMethodRS256.Alg(): true,
+		jwt.SigningMethodRS384.Alg(): true,
+		jwt.SigningMethodRS512.Alg(): true,
+		jwt.SigningMethodPS256.Alg(): true,
+		jwt.SigningMethodPS384.Alg(): true,
+		jwt.SigningMethodPS512.Alg(): true,
+		jwt.SigningMethodES256.Alg(): true,
+		jwt.SigningMethodES384.Alg(): true,
+		jwt.SigningMethodES512.Alg(): true,
+	}
+)
+
+const defaultUserClaim = "user_id"
+
+type tokenVerifier struct {
+	parser *jwt.Parser
+	// clientParser accepts client-credential tokens of a client audience,
+	// it is nil if clients can not authenticate with tokens
+	clientParser   *jwt.Parser
+	clientAudience string
+	secret         []byte
+	keySet         KeySet
+	userClaim      string
+	tenantClaim    string
+	// tenant is fixed for all tokens if it is not empty
+	tenant string
+}
+
+// verifierSet is an immutable token configuration of an authenticator.
+type verifierSet struct {
+	local   *tokenVerifier
+	issuers map[string]*tokenVerifier
+}
+
+type Authenticator struct {
+	verifiers atomic.Pointer[verifierSet]
+	inspector *jwt.Parser
+}
+
+func NewAuthenticator(config Config) (*Authenticator, error) {
+	verifiers := verifierSet{issuers: map[string]*tokenVerifier{}}
+	if config.Secret != "" || config.KeySet != nil || len(config.TrustedIssuers) == 0 {
+		local, err := newTokenVerifier(
+			[]byte(config.Secret),
+			config.KeySet,
+			config.Issuer,
+			config.Audience,
+			config.ClientAudience,
+			defaultUserClaim,
+			config.TenantClaim,
+			"",
+			config.Algorithms,
+			config.Leeway,
+		)
+		if err != nil {
+			return nil, err
+		}
+		verifiers.local = local
+	}
+	for _, issuer := range config.TrustedIssuers {
+		if issuer.Issuer == "" || issuer.KeySet == nil {
+			return nil, errors.New("a trusted issuer requires a name and a JWKS")
+		}
+		if _, ok := verifiers.issuers[issuer.Issuer]; ok {
+			return nil, fmt.Errorf("a duplicated trusted issuer '%s'", issuer.Issuer)
+		}
+		userClaim := issuer.UserClaim
+		if userClaim == "" {
+			userClaim = defaultUserClaim
+		}
+		verifier, err := newTokenVerifier(
+			nil,
+			issuer.KeySet,
+			issuer.Issuer,
+			issuer.Audience,
+			issuer.ClientAudience,
+			userClaim,
+			config.TenantClaim,
+			issuer.Tenant,
+			issuer.Algorithms,
+			config.Leeway,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("an issuer '%s': %w", issuer.Issuer, err)
+		}
+		verifiers.issuers[issuer.Issuer] = verifier
+	}
+	authenticator := Authenticator{inspector: jwt.NewParser()}
+	authenticator.verifiers.Store(&verifiers)
+	return &authenticator, nil
+}
+
+// Replace switches to secrets, key sets and issuers of another
+// authenticator. Requests in progress finish with the previous ones.
+func (a *Authenticator) Replace(other *Authenticator) {
+	a.verifiers.Store(other.verifiers.Load())
+}
+
+func newTokenVerifier(
+	secret []byte,
+	keySet KeySet,
+	issuer string,
+	audience string,
+	clientAudience string,
+	userClaim string,
+	tenantClaim string,
+	tenant string,
+	algorithms []string,
+	leeway time.Duration,
+) (*tokenVerifier, error) {
+	if len(algorithms) == 0 {
+		return nil, fmt.Errorf("%w: no allowed algorithms", ErrUnsupportedAlg)
+	}
+	for _, alg := range algorithms {
+		switch {
+		case hmacAlgorithms[alg]:
+			if len(secret) == 0 {
+				return nil, fmt.Errorf("a JWT secret is required for '%s'", alg)
+			}
+		case asymmetricAlgorithms[alg]:
+			if keySet == nil {
+				return nil, fmt.Errorf("a JWKS is required for '%s'", alg)
+			}
+		default:
+			return nil, fmt.Errorf("%w: '%s'", ErrUnsupportedAlg, alg)
+		}
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if clientAudience != "" && clientAudience == audience {
+		return nil, errors.New("a client audience must differ from a user audience")
+	}
+	userOptions := options
+	if audience != "" {
+		userOptions = append(userOptions[:len(options):len(options)], jwt.WithAudience(audience))
+	}
+	verifier := tokenVerifier{
+		parser:         jwt.NewParser(userOptions...),
+		clientAudience: clientAudience,
+		secret:         secret,
+		keySet:         keySet,
+		userClaim:      userClaim,
+		tenantClaim:    tenantClaim,
+		tenant:         tenant,
+	}
+	if clientAudience != "" {
+		clientOptions := append(options[:len(options):len(options)], jwt.WithAudience(clientAudience))
+		verifier.clientParser = jwt.NewParser(clientOptions...)
+	}
+	return &verifier, nil
+}
+
+// Authenticate validates a bearer token of a request and returns its principal.
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	claims, verifier, err := a.verifyBearerToken(r, false)
+	if err != nil {
+		return Principal{}, err
+	}
+	if verifier.clientAudience != "" {
+		audience, _ := claims.GetAudience()
+		for _, value := range audience {
+			if value == verifier.clientAudience {
+				return Principal{}, fmt.Errorf("%w: a client token is not a user token", ErrInvalidToken)
+			}
+		}
+	}
+	userID, _ := claims[verifier.userClaim].(string)
+	if userID == "" {
+		return Principal{}, fmt.Errorf("%w: no user ID in '%s'", ErrInvalidToken, verifier.userClaim)
+	}
+	tenantID, err := verifier.tenantID(claims)
+	if err != nil {
+		return Principal{}, err
+	}
+	issuer, _ := claims.GetIssuer()
+	return Principal{UserID: userID, Issuer: issuer, TenantID: tenantID}, nil
+}
+
+// tenantID returns a fixed tenant of an issuer or a tenant claim. Without
+// a configured tenant claim all tokens belong to a default tenant, with it
+// a token without a tenant is rejected instead of falling into a default one.
+func (v *tokenVerifier) tenantID(claims jwt.MapClaims) (string, error) {
+	if v.tenant != "" || v.tenantClaim == "" {
+		return v.tenant, nil
+	}
+	tenantID, _ := claims[v.tenantClaim].(string)
+	if tenantID == "" {
+		return "", fmt.Errorf("%w: no tenant ID in '%s'", ErrInvalidToken, v.tenantClaim)
+	}
+	return tenantID, nil
+}
+
+// verifyBearerToken verifies a user token or a client-credential token
+// of a client audience.
+func (a *Authenticator) verifyBearerToken(
+	r *http.Request,
+	client bool,
+) (jwt.MapClaims, *tokenVerifier, error) {
+	tokens := r.Header.Values("Authorization")
+	if len(tokens) != 1 {
+		return nil, nil, ErrMissingToken
+	}
+	rawToken, ok := strings.CutPrefix(tokens[0], "Bearer ")
+	if !ok {
+		return nil, nil, ErrMissingToken
+	}
+	unverifiedClaims := jwt.MapClaims{}
+	if _, _, err := a.inspector.ParseUnverified(rawToken, unverifiedClaims); err != nil {
+		return nil, nil, errors.Join(ErrInvalidToken, err)
+	}
+	issuer, _ := unverifiedClaims.GetIssuer()
+	verifiers := a.verifiers.Load()
+	verifier, ok := verifiers.issuers[issuer]
+	if !ok {
+		verifier = verifiers.local
+	}
+	if verifier == nil {
+		return nil, nil, fmt.Errorf("%w: untrusted issuer '%s'", ErrInvalidToken, issuer)
+	}
+	parser := verifier.parser
+	if client {
+		if verifier.clientParser == nil {
+			return nil, nil, fmt.Errorf("%w: no client audience of an issuer '%s'", ErrInvalidToken, issuer)
+		}
+		parser = verifier.clientParser
+	}
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(rawToken, claims, verifier.keyFunc(r.Context()))
+	if err != nil {
+		return nil, nil, errors.Join(ErrInvalidToken, err)
+	}
+	return claims, verifier, nil
+}
+
+func (v *tokenVerifier) keyFunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		return verificationKey(ctx, token, v.secret, v.keySet)
+	}
+}
+
+// verificationKey selects a key matching a token algorithm. The algorithm
+// itself is already pinned by a parser.
+func verificationKey(
+	ctx context.Context,
+	token *jwt.Token,
+	secret []byte,
+	keySet KeySet,
+) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("%w: no HMAC secret", ErrUnsupportedAlg)
+		}
+		return secret, nil
+	}
+	if keySet == nil {
+		return nil, fmt.Errorf("%w: no JWKS", ErrUnsupportedAlg)
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, err := keySet.Key(ctx, kid, token.Method.Alg())
+	if err != nil {
+		return nil, err
+	}
+	switch token.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	case *jwt.SigningMethodECDSA:
+		if _, ok := key.(*ecdsa.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: key '%s' does not match '%s'", ErrInvalidToken, kid, token.Method.Alg())
+}
+
+// Middleware rejects unauthenticated requests and puts a principal into
+// a request context.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracer.Start(r.Context(), "Authenticator.Authenticate")
+		principal, err := a.Authenticate(r)
+		tracing.End(span, err)
+		if err != nil {
+			slog.InfoContext(
+				r.Context(),
+				"an authentication failure",
+				"remote_addr", r.RemoteAddr,
+				"error", err,
+			)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "the unexpected JWT token", http.StatusUnauthorized)
+			return
+		}
+		ctx := ContextWithPrincipal(r.Context(), principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
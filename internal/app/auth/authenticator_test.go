@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "a test secret of a local issuer"
+
+func signHMAC(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("can not sign a token: %v", err)
+	}
+	return token
+}
+
+func authenticate(t *testing.T, config Config, claims jwt.MapClaims) (Principal, error) {
+	t.Helper()
+	authenticator, err := NewAuthenticator(config)
+	if err != nil {
+		t.Fatalf("can not create an authenticator: %v", err)
+	}
+	request := httptest.NewRequest("POST", "/api/v1/sign", nil)
+	request.Header.Set("Authorization", "Bearer "+signHMAC(t, claims))
+	return authenticator.Authenticate(request)
+}
+
+func TestAuthenticateTenantClaim(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		name        string
+		tenantClaim string
+		claims      jwt.MapClaims
+		tenantID    string
+		err         error
+	}{
+		{
+			name:     "no tenant claim configured",
+			claims:   jwt.MapClaims{"user_id": "u1", "tenant_id": "acme", "exp": expiresAt},
+			tenantID: "",
+		},
+		{
+			name:        "tenant claim",
+			tenantClaim: "tenant_id",
+			claims:      jwt.MapClaims{"user_id": "u1", "tenant_id": "acme", "exp": expiresAt},
+			tenantID:    "acme",
+		},
+		{
+			name:        "missing tenant claim",
+			tenantClaim: "tenant_id",
+			claims:      jwt.MapClaims{"user_id": "u1", "exp": expiresAt},
+			err:         ErrInvalidToken,
+		},
+		{
+			name:        "empty tenant claim",
+			tenantClaim: "tenant_id",
+			claims:      jwt.MapClaims{"user_id": "u1", "tenant_id": "", "exp": expiresAt},
+			err:         ErrInvalidToken,
+		},
+		{
+			name:        "not a string tenant claim",
+			tenantClaim: "tenant_id",
+			claims:      jwt.MapClaims{"user_id": "u1", "tenant_id": 42, "exp": expiresAt},
+			err:         ErrInvalidToken,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Config{
+				Secret:      testSecret,
+				Algorithms:  []string{"HS256"},
+				TenantClaim: test.tenantClaim,
+			}
+			principal, err := authenticate(t, config, test.claims)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("unexpected error: %v, expected %v", err, test.err)
+			}
+			if err == nil && principal.TenantID != test.tenantID {
+				t.Errorf("unexpected tenant: '%s', expected '%s'", principal.TenantID, test.tenantID)
+			}
+		})
+	}
+}
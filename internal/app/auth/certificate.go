@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"crypto/x509"
+	"net/http"
+)
+
+// CertificateIdentity names a client certificate by its first URI SAN,
+// e.g. a SPIFFE ID, by its first DNS SAN or by its subject common name.
+func CertificateIdentity(certificate *x509.Certificate) string {
+	if len(certificate.URIs) > 0 {
+		return certificate.URIs[0].String()
+	}
+	if len(certificate.DNSNames) > 0 {
+		return certificate.DNSNames[0]
+	}
+	return certificate.Subject.CommonName
+}
+
+// verifiedClientCertificate returns a leaf certificate of a client
+// if a TLS handshake has verified it against trusted client CAs.
+func verifiedClientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
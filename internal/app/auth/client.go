@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// ClientAuthenticator authenticates verifier clients by API keys,
+// by client-credential JWTs or by mutual TLS certificates. A client JWT
+// must be issued for a client audience, which user tokens never have.
+type ClientAuthenticator struct {
+	tokens       *Authenticator
+	apiKeys      APIKeyFunc
+	certificates CertificateFunc
+}
+
+// NewClientAuthenticator creates an authenticator. A nil certificate
+// function disables authentication by client certificates.
+func NewClientAuthenticator(
+	tokens *Authenticator,
+	apiKeys APIKeyFunc,
+	certificates CertificateFunc,
+) *ClientAuthenticator {
+	return &ClientAuthenticator{tokens, apiKeys, certificates}
+}
+
+// Authenticate prefers explicit credentials: an API key, then a bearer
+// token, then a client certificate.
+func (a *ClientAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+		principal, err := a.apiKeys(r.Context(), apiKey)
+		if err != nil {
+			return Principal{}, errors.Join(ErrInvalidAPIKey, err)
+		}
+		return principal, nil
+	}
+	certificate := verifiedClientCertificate(r)
+	if r.Header.Get("Authorization") == "" && certificate != nil && a.certificates != nil {
+		principal, err := a.certificates(r.Context(), CertificateIdentity(certificate))
+		if err != nil {
+			return Principal{}, errors.Join(ErrUnknownCert, err)
+		}
+		return principal, nil
+	}
+	claims, verifier, err := a.tokens.verifyBearerToken(r, true)
+	if err != nil {
+		return Principal{}, err
+	}
+	// 'sub' is not a client ID: it identifies users in user tokens
+	clientID := firstStringClaim(claims, "client_id", "azp")
+	if clientID == "" {
+		return Principal{}, fmt.Errorf("%w: no client ID", ErrInvalidToken)
+	}
+	tenantID, err := verifier.tenantID(claims)
+	if err != nil {
+		return Principal{}, err
+	}
+	issuer, _ := claims.GetIssuer()
+	principal := Principal{
+		Issuer:   issuer,
+		ClientID: clientID,
+		Scopes:   scopeClaim(claims),
+		TenantID: tenantID,
+	}
+	return principal, nil
+}
+
+// Middleware rejects requests of unauthenticated clients and clients
+// without a required scope.
+func (a *ClientAuthenticator) Middleware(requiredScope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := a.Authenticate(r)
+			if err != nil {
+				slog.InfoContext(
+					r.Context(),
+					"a client authentication failure",
+					"remote_addr", r.RemoteAddr,
+					"error", err,
+				)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, "invalid client credentials", http.StatusUnauthorized)
+				return
+			}
+			if !principal.HasScope(requiredScope) {
+				slog.InfoContext(
+					r.Context(),
+					"a client has no scope",
+					"client_id", principal.ClientID,
+					"scope", requiredScope,
+				)
+				w.Header().Set(
+					"WWW-Authenticate",
+					fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, requiredScope),
+				)
+				http.Error(w, "insufficient scope", http.StatusForbidden)
+				return
+			}
+			ctx := ContextWithPrincipal(r.Context(), principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func firstStringClaim(claims jwt.MapClaims, names ...string) string {
+	for _, name := range names {
+		if value, ok := claims[name].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// scopeClaim reads an OAuth 2.0 'scope' claim or a list 'scp' claim.
+func scopeClaim(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	scopes := []string{}
+	if values, ok := claims["scp"].([]interface{}); ok {
+		for _, value := range values {
+			if scope, ok := value.(string); ok {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
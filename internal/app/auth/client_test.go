@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestClientAuthenticatorTokens(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	config := Config{
+		Secret:         testSecret,
+		Audience:       "test-signer",
+		ClientAudience: "test-signer-clients",
+		Algorithms:     []string{"HS256"},
+	}
+	tests := []struct {
+		name     string
+		config   Config
+		claims   jwt.MapClaims
+		clientID string
+		err      error
+	}{
+		{
+			name:   "client token",
+			config: config,
+			claims: jwt.MapClaims{
+				"aud":       "test-signer-clients",
+				"client_id": "portal",
+				"scope":     "signatures:verify",
+				"exp":       expiresAt,
+			},
+			clientID: "portal",
+		},
+		{
+			name:   "user token with a scope",
+			config: config,
+			claims: jwt.MapClaims{
+				"aud":       "test-signer",
+				"user_id":   "u1",
+				"client_id": "portal",
+				"scope":     "signatures:verify",
+				"exp":       expiresAt,
+			},
+			err: ErrInvalidToken,
+		},
+		{
+			name:   "subject is not a client ID",
+			config: config,
+			claims: jwt.MapClaims{
+				"aud":   "test-signer-clients",
+				"sub":   "portal",
+				"scope": "signatures:verify",
+				"exp":   expiresAt,
+			},
+			err: ErrInvalidToken,
+		},
+		{
+			name:   "no client audience",
+			config: Config{Secret: testSecret, Audience: "test-signer", Algorithms: []string{"HS256"}},
+			claims: jwt.MapClaims{
+				"aud":       "test-signer",
+				"client_id": "portal",
+				"scope":     "signatures:verify",
+				"exp":       expiresAt,
+			},
+			err: ErrInvalidToken,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, err := NewAuthenticator(test.config)
+			if err != nil {
+				t.Fatalf("can not create an authenticator: %v", err)
+			}
+			noAPIKeys := func(context.Context, string) (Principal, error) {
+				return Principal{}, errors.New("unexpected API key")
+			}
+			clients := NewClientAuthenticator(tokens, noAPIKeys, nil)
+			request := httptest.NewRequest("POST", "/api/v1/verify", nil)
+			request.Header.Set("Authorization", "Bearer "+signHMAC(t, test.claims))
+			principal, err := clients.Authenticate(request)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("unexpected error: %v, expected %v", err, test.err)
+			}
+			if err == nil && principal.ClientID != test.clientID {
+				t.Errorf("unexpected client: '%s', expected '%s'", principal.ClientID, test.clientID)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRejectsClientTokens(t *testing.T) {
+	config := Config{
+		Secret:         testSecret,
+		ClientAudience: "test-signer-clients",
+		Algorithms:     []string{"HS256"},
+	}
+	claims := jwt.MapClaims{
+		"aud":     "test-signer-clients",
+		"user_id": "u1",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}
+	if _, err := authenticate(t, config, claims); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("unexpected error: %v, expected %v", err, ErrInvalidToken)
+	}
+}
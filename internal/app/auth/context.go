@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/AndreyAD1/test-signer/internal/app/logging"
+)
+
+type principalKey struct{}
+
+// ContextWithPrincipal also adds principal identifiers to log records
+// of a context.
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	var attrs []slog.Attr
+	if principal.UserID != "" {
+		attrs = append(attrs, slog.String("user_id", principal.UserID))
+	}
+	if principal.ClientID != "" {
+		attrs = append(attrs, slog.String("client_id", principal.ClientID))
+	}
+	ctx = logging.WithAttrs(ctx, attrs...)
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
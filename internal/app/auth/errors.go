@@ -0,0 +1,11 @@
+package auth
+
+import "errors"
+
+var (
+	ErrMissingToken   = errors.New("a bearer token is missing")
+	ErrInvalidToken   = errors.New("a token is invalid")
+	ErrUnsupportedAlg = errors.New("an unsupported signing algorithm")
+	ErrInvalidAPIKey  = errors.New("an API key is invalid")
+	ErrUnknownCert    = errors.New("a client certificate is unknown")
+)
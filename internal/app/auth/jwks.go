@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxJWKSBytes          = 1 << 20
+	minJWKSRefreshBackoff = 10 * time.Second
+)
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// cachedKey is a public key and an algorithm which it is restricted to.
+// An empty algorithm allows any algorithm of a key type.
+type cachedKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// JWKS is a cached set of public keys loaded from a URL or a file.
+// Expired keys are served while a background refresh is running.
+type JWKS struct {
+	source          string
+	client          *http.Client
+	refreshInterval time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]cachedKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	refreshing  bool
+	closed      bool
+
+	stop      chan struct{}
+	stopOnce  sync.Once
+	refreshes sync.WaitGroup
+}
+
+// NewJWKS loads a key set and starts its background refresh.
+// A source is either an HTTP(S) URL or a file path.
+func NewJWKS(ctx context.Context, source string, refreshInterval time.Duration) (*JWKS, error) {
+	if refreshInterval <= 0 {
+		return nil, fmt.Errorf("invalid JWKS refresh interval: %v", refreshInterval)
+	}
+	keySet := JWKS{
+		source:          source,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		refreshInterval: refreshInterval,
+		keys:            map[string]cachedKey{},
+		stop:            make(chan struct{}),
+	}
+	if err := keySet.refresh(ctx); err != nil {
+		return nil, err
+	}
+	keySet.refreshes.Add(1)
+	go keySet.refreshLoop()
+	return &keySet, nil
+}
+
+// Key returns a public key by its ID. An unknown ID triggers a synchronous
+// refresh, because a key set may have been rotated. A key whose JWK
+// names another algorithm is rejected.
+func (s *JWKS) Key(ctx context.Context, kid string, alg string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	stale := time.Since(s.fetchedAt) > s.refreshInterval
+	s.mu.RUnlock()
+	if ok {
+		if stale {
+			s.refreshAsync()
+		}
+		return key.forAlgorithm(kid, alg)
+	}
+	if !s.startRefresh() {
+		return nil, fmt.Errorf("%w: unknown key ID '%s'", ErrInvalidToken, kid)
+	}
+	err := s.load(ctx)
+	s.finishRefresh()
+	if err != nil {
+		slog.WarnContext(ctx, "can not refresh a JWKS", "source", s.source, "error", err)
+	}
+	s.mu.RLock()
+	key, ok = s.keys[kid]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key ID '%s'", ErrInvalidToken, kid)
+	}
+	return key.forAlgorithm(kid, alg)
+}
+
+func (k cachedKey) forAlgorithm(kid string, alg string) (crypto.PublicKey, error) {
+	if k.alg != "" && k.alg != alg {
+		return nil, fmt.Errorf("%w: key '%s' is for '%s', not '%s'", ErrInvalidToken, kid, k.alg, alg)
+	}
+	return k.key, nil
+}
+
+// Close stops the background refresh and waits for a running one.
+// It can be called more than once.
+func (s *JWKS) Close() {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.stop)
+	})
+	s.refreshes.Wait()
+}
+
+func (s *JWKS) refreshLoop() {
+	defer s.refreshes.Done()
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.refreshAsync()
+		}
+	}
+}
+
+func (s *JWKS) refreshAsync() {
+	if !s.startRefresh() {
+		return
+	}
+	go func() {
+		defer s.finishRefresh()
+		ctx, cancel := context.WithTimeout(context.Background(), s.client.Timeout)
+		defer cancel()
+		if err := s.load(ctx); err != nil {
+			slog.Warn("can not refresh a JWKS, keep stale keys", "source", s.source, "error", err)
+		}
+	}()
+}
+
+// startRefresh reserves a refresh attempt. It prevents concurrent refreshes
+// and limits how often unknown key IDs can hit a key source. A closed key
+// set is not refreshed, Close waits for a reserved refresh.
+func (s *JWKS) startRefresh() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.refreshing || time.Since(s.attemptedAt) < minJWKSRefreshBackoff {
+		return false
+	}
+	s.refreshing = true
+	s.attemptedAt = time.Now()
+	s.refreshes.Add(1)
+	return true
+}
+
+func (s *JWKS) finishRefresh() {
+	s.mu.Lock()
+	s.refreshing = false
+	s.mu.Unlock()
+	s.refreshes.Done()
+}
+
+func (s *JWKS) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.attemptedAt = time.Now()
+	s.mu.Unlock()
+	return s.load(ctx)
+}
+
+func (s *JWKS) load(ctx context.Context) error {
+	rawKeySet, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(rawKeySet)
+	if err != nil {
+		return fmt.Errorf("invalid JWKS '%s': %w", s.source, err)
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *JWKS) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.source, "http://") && !strings.HasPrefix(s.source, "https://") {
+		return os.ReadFile(s.source)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected JWKS response status: %s", response.Status)
+	}
+	return io.ReadAll(io.LimitReader(response.Body, maxJWKSBytes))
+}
+
+func parseJWKS(rawKeySet []byte) (map[string]cachedKey, error) {
+	var keySet jsonWebKeySet
+	if err := json.Unmarshal(rawKeySet, &keySet); err != nil {
+		return nil, err
+	}
+	keys := map[string]cachedKey{}
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			slog.Warn("skip a JWK", "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = cachedKey{key, jwk.Alg}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("unsupported exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{
+			"P-256": elliptic.P256(),
+			"P-384": elliptic.P384(),
+			"P-521": elliptic.P521(),
+		}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("a point is not on a curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type '%s'", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) == 0 {
+		return nil, errors.New("an empty value")
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
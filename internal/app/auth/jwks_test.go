@@ -0,0 +1,343 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func rsaJWK(t *testing.T, kid string) (*rsa.PrivateKey, jsonWebKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("can not generate an RSA key: %v", err)
+	}
+	jwk := jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+	return key, jwk
+}
+
+func ecJWK(t *testing.T, kid string) (*ecdsa.PrivateKey, jsonWebKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("can not generate an EC key: %v", err)
+	}
+	jwk := jsonWebKey{
+		Kty: "EC",
+		Kid: kid,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+	return key, jwk
+}
+
+func marshalJWKS(t *testing.T, keys ...jsonWebKey) []byte {
+	t.Helper()
+	rawKeySet, err := json.Marshal(jsonWebKeySet{Keys: keys})
+	if err != nil {
+		t.Fatalf("can not marshal a JWKS: %v", err)
+	}
+	return rawKeySet
+}
+
+func newTestJWKS(t *testing.T, source string) *JWKS {
+	t.Helper()
+	keySet, err := NewJWKS(context.Background(), source, time.Hour)
+	if err != nil {
+		t.Fatalf("can not load a JWKS: %v", err)
+	}
+	t.Cleanup(keySet.Close)
+	return keySet
+}
+
+// allowRefresh lifts a refresh backoff, so a test does not wait for it.
+func allowRefresh(keySet *JWKS) {
+	keySet.mu.Lock()
+	keySet.attemptedAt = time.Time{}
+	keySet.mu.Unlock()
+}
+
+// jwksServer serves a key set which a test can replace or break.
+type jwksServer struct {
+	mu       sync.Mutex
+	keySet   []byte
+	failing  bool
+	requests int
+}
+
+func (s *jwksServer) set(keySet []byte, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keySet = keySet
+	s.failing = failing
+}
+
+func (s *jwksServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.failing {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write(s.keySet)
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"user_id": "u1",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = kid
+	rawToken, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("can not sign a token: %v", err)
+	}
+	return rawToken
+}
+
+func TestJWKSSelectsKeyByKid(t *testing.T) {
+	rsaKey, rsaKeyJWK := rsaJWK(t, "rsa-1")
+	ecKey, ecKeyJWK := ecJWK(t, "ec-1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, marshalJWKS(t, rsaKeyJWK, ecKeyJWK), 0o600); err != nil {
+		t.Fatalf("can not write a JWKS: %v", err)
+	}
+	keySet := newTestJWKS(t, path)
+
+	key, err := keySet.Key(context.Background(), "ec-1", "ES256")
+	if err != nil {
+		t.Fatalf("can not find a key: %v", err)
+	}
+	if !ecKey.PublicKey.Equal(key) {
+		t.Errorf("an unexpected key of 'ec-1'")
+	}
+	authenticator, err := NewAuthenticator(Config{KeySet: keySet, Algorithms: []string{"RS256", "ES256"}})
+	if err != nil {
+		t.Fatalf("can not create an authenticator: %v", err)
+	}
+	tests := map[string]string{
+		"RS256": signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey),
+		"ES256": signToken(t, jwt.SigningMethodES256, "ec-1", ecKey),
+	}
+	for name, rawToken := range tests {
+		request := httptest.NewRequest("POST", "/api/v1/sign", nil)
+		request.Header.Set("Authorization", "Bearer "+rawToken)
+		if _, err := authenticator.Authenticate(request); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	// a valid signature under a key of another kid is rejected
+	rawToken := signToken(t, jwt.SigningMethodRS256, "ec-1", rsaKey)
+	request := httptest.NewRequest("POST", "/api/v1/sign", nil)
+	request.Header.Set("Authorization", "Bearer "+rawToken)
+	if _, err := authenticator.Authenticate(request); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unexpected error of a mismatched kid: %v", err)
+	}
+}
+
+func TestJWKSRefetchesUnknownKid(t *testing.T) {
+	_, firstJWK := rsaJWK(t, "first")
+	secondKey, secondJWK := rsaJWK(t, "second")
+	source := &jwksServer{keySet: marshalJWKS(t, firstJWK)}
+	server := httptest.NewServer(source)
+	defer server.Close()
+	keySet := newTestJWKS(t, server.URL)
+
+	source.set(marshalJWKS(t, firstJWK, secondJWK), false)
+	if _, err := keySet.Key(context.Background(), "second", "RS256"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("a refresh within a backoff: unexpected error %v", err)
+	}
+	allowRefresh(keySet)
+	key, err := keySet.Key(context.Background(), "second", "RS256")
+	if err != nil {
+		t.Fatalf("can not find a rotated key: %v", err)
+	}
+	if !secondKey.PublicKey.Equal(key) {
+		t.Errorf("an unexpected key of 'second'")
+	}
+	if requests := source.requestCount(); requests != 2 {
+		t.Errorf("unexpected JWKS requests: %d, expected 2", requests)
+	}
+}
+
+func TestJWKSKeepsCachedKeysOnFetchFailure(t *testing.T) {
+	cachedKey, cachedJWK := rsaJWK(t, "cached")
+	source := &jwksServer{keySet: marshalJWKS(t, cachedJWK)}
+	server := httptest.NewServer(source)
+	defer server.Close()
+	keySet := newTestJWKS(t, server.URL)
+
+	source.set(nil, true)
+	allowRefresh(keySet)
+	if _, err := keySet.Key(context.Background(), "unknown", "RS256"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("unexpected error of an unknown key: %v", err)
+	}
+	key, err := keySet.Key(context.Background(), "cached", "RS256")
+	if err != nil {
+		t.Fatalf("a cached key is lost after a failed fetch: %v", err)
+	}
+	if !cachedKey.PublicKey.Equal(key) {
+		t.Errorf("an unexpected key of 'cached'")
+	}
+}
+
+func TestAuthenticateRejectsUnpinnedAlgorithms(t *testing.T) {
+	rsaKey, rsaKeyJWK := rsaJWK(t, "rsa-1")
+	ecKey, ecKeyJWK := ecJWK(t, "ec-1")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, marshalJWKS(t, rsaKeyJWK, ecKeyJWK), 0o600); err != nil {
+		t.Fatalf("can not write a JWKS: %v", err)
+	}
+	keySet := newTestJWKS(t, path)
+	authenticator, err := NewAuthenticator(Config{
+		Secret:     testSecret,
+		KeySet:     keySet,
+		Algorithms: []string{"RS256"},
+	})
+	if err != nil {
+		t.Fatalf("can not create an authenticator: %v", err)
+	}
+	publicKey, _ := json.Marshal(rsaKeyJWK)
+	tests := map[string]string{
+		"ES256 of a known key":  signToken(t, jwt.SigningMethodES256, "ec-1", ecKey),
+		"PS256 of a pinned key": signToken(t, jwt.SigningMethodPS256, "rsa-1", rsaKey),
+		"HS256 of a secret":     signToken(t, jwt.SigningMethodHS256, "", []byte(testSecret)),
+		"HS256 of a public key": signToken(t, jwt.SigningMethodHS256, "rsa-1", publicKey),
+	}
+	for name, rawToken := range tests {
+		request := httptest.NewRequest("POST", "/api/v1/sign", nil)
+		request.Header.Set("Authorization", "Bearer "+rawToken)
+		if _, err := authenticator.Authenticate(request); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
+
+func TestJWKSRejectsConflictingAlgorithm(t *testing.T) {
+	rsaKey, rsaKeyJWK := rsaJWK(t, "rsa-1")
+	rsaKeyJWK.Alg = "RS256"
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, marshalJWKS(t, rsaKeyJWK), 0o600); err != nil {
+		t.Fatalf("can not write a JWKS: %v", err)
+	}
+	keySet := newTestJWKS(t, path)
+	if _, err := keySet.Key(context.Background(), "rsa-1", "PS256"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("a key of RS256 is returned for PS256: %v", err)
+	}
+	authenticator, err := NewAuthenticator(Config{KeySet: keySet, Algorithms: []string{"RS256", "PS256"}})
+	if err != nil {
+		t.Fatalf("can not create an authenticator: %v", err)
+	}
+	tests := map[string]struct {
+		rawToken string
+		valid    bool
+	}{
+		"a pinned algorithm of a key": {signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey), true},
+		"another algorithm of a key":  {signToken(t, jwt.SigningMethodPS256, "rsa-1", rsaKey), false},
+	}
+	for name, test := range tests {
+		request := httptest.NewRequest("POST", "/api/v1/sign", nil)
+		request.Header.Set("Authorization", "Bearer "+test.rawToken)
+		_, err := authenticator.Authenticate(request)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: unexpected error %v", name, err)
+		}
+	}
+}
+
+// blockingJWKSServer holds refresh requests after a first one until
+// it is released.
+type blockingJWKSServer struct {
+	keySet   []byte
+	served   atomic.Int32
+	requests chan struct{}
+	release  chan struct{}
+}
+
+func (s *blockingJWKSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.served.Add(1) > 1 {
+		s.requests <- struct{}{}
+		<-s.release
+	}
+	w.Write(s.keySet)
+}
+
+func TestJWKSCloseWaitsForRefresh(t *testing.T) {
+	_, cachedJWK := rsaJWK(t, "cached")
+	source := &blockingJWKSServer{
+		keySet:   marshalJWKS(t, cachedJWK),
+		requests: make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+	server := httptest.NewServer(source)
+	defer server.Close()
+	keySet, err := NewJWKS(context.Background(), server.URL, time.Hour)
+	if err != nil {
+		t.Fatalf("can not load a JWKS: %v", err)
+	}
+
+	allowRefresh(keySet)
+	keySet.mu.Lock()
+	keySet.fetchedAt = time.Now().Add(-2 * time.Hour)
+	keySet.mu.Unlock()
+	if _, err := keySet.Key(context.Background(), "cached", "RS256"); err != nil {
+		t.Fatalf("a stale key is not served: %v", err)
+	}
+	<-source.requests
+
+	closed := make(chan struct{})
+	go func() {
+		keySet.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close returns before a refresh finishes")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(source.release)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close does not return after a refresh finishes")
+	}
+	keySet.Close()
+	allowRefresh(keySet)
+	if _, err := keySet.Key(context.Background(), "unknown", "RS256"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unexpected error of a closed key set: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+type discoveryDocument struct {
+	Issuer           string   `json:"issuer"`
+	JWKSURI          string   `json:"jwks_uri"`
+	SigningAlgValues []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// DiscoverIssuer reads an OpenID Connect discovery document of an issuer
+// and loads its JWKS.
+func DiscoverIssuer(
+	ctx context.Context,
+	issuer string,
+	audience string,
+	userClaim string,
+	refreshInterval time.Duration,
+) (TrustedIssuer, *JWKS, error) {
+	discoveryURL := strings.TrimSuffix(issuer, "/") + discoveryPath
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return TrustedIssuer{}, nil, err
+	}
+	client := http.Client{Timeout: 10 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return TrustedIssuer{}, nil, fmt.Errorf("a discovery request error: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return TrustedIssuer{}, nil, fmt.Errorf(
+			"unexpected discovery response status: %s",
+			response.Status,
+		)
+	}
+	var document discoveryDocument
+	body := io.LimitReader(response.Body, maxJWKSBytes)
+	if err := json.NewDecoder(body).Decode(&document); err != nil {
+		return TrustedIssuer{}, nil, fmt.Errorf("invalid discovery document: %w", err)
+	}
+	if document.Issuer != issuer {
+		return TrustedIssuer{}, nil, fmt.Errorf(
+			"a discovery document issuer mismatch: '%s'",
+			document.Issuer,
+		)
+	}
+	if document.JWKSURI == "" {
+		return TrustedIssuer{}, nil, fmt.Errorf("no 'jwks_uri' in a discovery document")
+	}
+	algorithms := []string{}
+	for _, alg := range document.SigningAlgValues {
+		if asymmetricAlgorithms[alg] {
+			algorithms = append(algorithms, alg)
+		}
+	}
+	if len(algorithms) == 0 {
+		algorithms = []string{"RS256"}
+	}
+	keySet, err := NewJWKS(ctx, document.JWKSURI, refreshInterval)
+	if err != nil {
+		return TrustedIssuer{}, nil, err
+	}
+	trustedIssuer := TrustedIssuer{
+		Issuer:     issuer,
+		Audience:   audience,
+		UserClaim:  userClaim,
+		KeySet:     keySet,
+		Algorithms: algorithms,
+	}
+	return trustedIssuer, keySet, nil
+}
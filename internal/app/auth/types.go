@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"time"
+)
+
+// KeySet finds a public key by its ID for a token algorithm.
+type KeySet interface {
+	Key(ctx context.Context, kid string, alg string) (crypto.PublicKey, error)
+}
+
+type Config struct {
+	Secret   string
+	KeySet   KeySet
+	Issuer   string
+	Audience string
+	// ClientAudience is an audience of client-credential tokens. Without
+	// it verifier clients can not authenticate with tokens.
+	ClientAudience string
+	Leeway         time.Duration
+	Algorithms     []string
+	// TenantClaim names a required claim with a tenant ID. Without it
+	// all users belong to a default tenant.
+	TenantClaim string
+	// TrustedIssuers are external identity providers. Their tokens are
+	// selected by the 'iss' claim and never fall back to a local secret.
+	TrustedIssuers []TrustedIssuer
+}
+
+type TrustedIssuer struct {
+	Issuer         string
+	Audience       string
+	ClientAudience string
+	UserClaim      string
+	KeySet         KeySet
+	Algorithms     []string
+	// Tenant pins all users of an issuer to a tenant, so a tenant claim
+	// of its tokens is ignored.
+	Tenant string
+}
+
+// Principal is an authenticated identity of a request. Users have a user ID,
+// verifier clients have a client ID and scopes. Both belong to a tenant,
+// an empty tenant ID is a default tenant.
+type Principal struct {
+	UserID   string
+	Issuer   string
+	ClientID string
+	Scopes   []string
+	TenantID string
+}
+
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyFunc resolves an API key into a principal of a client.
+type APIKeyFunc func(ctx context.Context, apiKey string) (Principal, error)
+
+// CertificateFunc resolves an identity of a verified client certificate
+// into a principal of a client.
+type CertificateFunc func(ctx context.Context, identity string) (Principal, error)
@@ -0,0 +1,212 @@
+// Package certificates serves TLS certificates which are reloaded from disk
+// without restarting listeners.
+package certificates
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrNoCertificates = errors.New("no certificates in a PEM file")
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseVersion converts a TLS version like "1.2" into a crypto/tls constant.
+func ParseVersion(version string) (uint16, error) {
+	tlsVersion, ok := tlsVersions[version]
+	if !ok {
+		return 0, fmt.Errorf("an unsupported TLS version '%s': use 1.2 or 1.3", version)
+	}
+	return tlsVersion, nil
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+type paths struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+}
+
+// Reloader keeps a server certificate and trusted client CAs. A new TLS
+// handshake uses the latest loaded files, established connections
+// keep their certificates.
+type Reloader struct {
+	mu          sync.RWMutex
+	paths       paths
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	files       map[string]fileState
+}
+
+// NewReloader loads a certificate and a key. An empty client CA file
+// disables client certificate verification.
+func NewReloader(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	reloader := Reloader{paths: paths{certFile, keyFile, clientCAFile}}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	return &reloader, nil
+}
+
+// Reload reads all files. The previous certificates stay in use
+// if any file is invalid.
+func (r *Reloader) Reload() error {
+	r.mu.RLock()
+	loadedPaths := r.paths
+	r.mu.RUnlock()
+	files, err := loadedPaths.stat()
+	if err != nil {
+		return err
+	}
+	certificate, err := tls.LoadX509KeyPair(loadedPaths.certFile, loadedPaths.keyFile)
+	if err != nil {
+		return fmt.Errorf("can not load a TLS certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if loadedPaths.clientCAFile != "" {
+		rawCAs, err := os.ReadFile(loadedPaths.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("can not read client CAs: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(rawCAs) {
+			return fmt.Errorf("%w: '%s'", ErrNoCertificates, loadedPaths.clientCAFile)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Replace has switched files while they were loading
+	if r.paths != loadedPaths {
+		return nil
+	}
+	r.certificate = &certificate
+	r.clientCAs = clientCAs
+	r.files = files
+	return nil
+}
+
+// ReloadIfChanged reloads files if their size or modification time differ
+// from the loaded ones.
+func (r *Reloader) ReloadIfChanged() error {
+	r.mu.RLock()
+	loadedPaths := r.paths
+	r.mu.RUnlock()
+	files, err := loadedPaths.stat()
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	changed := false
+	for name, state := range files {
+		if r.files[name] != state {
+			changed = true
+		}
+	}
+	r.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+	if err := r.Reload(); err != nil {
+		return err
+	}
+	slog.Info("TLS certificates are reloaded", "cert_file", loadedPaths.certFile)
+	return nil
+}
+
+// Replace switches to files and certificates of another reloader,
+// e.g. after a configuration has changed file paths.
+func (r *Reloader) Replace(other *Reloader) {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths = other.paths
+	r.certificate = other.certificate
+	r.clientCAs = other.clientCAs
+	r.files = other.files
+}
+
+// Watch polls files for changes until a context is done.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.ReloadIfChanged(); err != nil {
+				slog.Error("can not reload TLS certificates, keep the previous ones", "error", err)
+			}
+		}
+	}
+}
+
+// TLSConfig creates a server configuration which reads the latest
+// certificates on every handshake. Client certificates are optional,
+// so clients without them can still authenticate by other means.
+// A handshake clones this configuration, so it keeps ALPN protocols
+// including the ones added by http.Server, and session tickets are
+// encrypted with keys of the serving configuration.
+func (r *Reloader) TLSConfig(minVersion uint16) *tls.Config {
+	config := &tls.Config{
+		MinVersion:     minVersion,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: r.getCertificate,
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		if r.clientCAs == nil {
+			return nil, nil
+		}
+		clientConfig := config.Clone()
+		clientConfig.GetConfigForClient = nil
+		clientConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		clientConfig.ClientCAs = r.clientCAs
+		return clientConfig, nil
+	}
+	return config
+}
+
+// Leaf parses a server certificate which is currently served.
+func (r *Reloader) Leaf() (*x509.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return x509.ParseCertificate(r.certificate.Certificate[0])
+}
+
+func (r *Reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.certificate, nil
+}
+
+func (p paths) stat() (map[string]fileState, error) {
+	files := map[string]fileState{}
+	for _, name := range []string{p.certFile, p.keyFile, p.clientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, fmt.Errorf("can not read a TLS file: %w", err)
+		}
+		files[name] = fileState{info.ModTime(), info.Size()}
+	}
+	return files, nil
+}
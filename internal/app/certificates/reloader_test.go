@@ -0,0 +1,148 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+}
+
+func newTestCertificate(t *testing.T, name string, parent *testCertificate) testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.certificate, parent.key
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCertificate{certificate, key}
+}
+
+func (c testCertificate) write(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	rawKey, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(dir, c.certificate.Subject.CommonName+".crt")
+	keyFile := filepath.Join(dir, c.certificate.Subject.CommonName+".key")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.certificate.Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: rawKey})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func (c testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.certificate.Raw}, PrivateKey: c.key}
+}
+
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (tls.ConnectionState, tls.ConnectionState) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientConn.Close()
+	serverConn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverConn.Close()
+	server := tls.Server(serverConn, serverConfig)
+	client := tls.Client(clientConn, clientConfig)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Handshake()
+	}()
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("client handshake: %v", err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("server handshake: %v", err)
+	}
+	// TLS 1.3 tickets arrive after the handshake
+	_ = client.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, _ = client.Read(make([]byte, 1))
+	return server.ConnectionState(), client.ConnectionState()
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, "ca", nil)
+	serverCertificate := newTestCertificate(t, "localhost", &ca)
+	clientCertificate := newTestCertificate(t, "client", &ca)
+	certFile, keyFile := serverCertificate.write(t, dir)
+	caFile, _ := ca.write(t, dir)
+	reloader, err := NewReloader(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := reloader.TLSConfig(tls.VersionTLS12)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+	clientConfig := &tls.Config{
+		RootCAs:            roots,
+		ServerName:         "localhost",
+		NextProtos:         []string{"h2", "http/1.1"},
+		Certificates:       []tls.Certificate{clientCertificate.tlsCertificate()},
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+	}
+
+	serverState, clientState := handshake(t, serverConfig, clientConfig)
+	if clientState.NegotiatedProtocol != "h2" {
+		t.Errorf("protocol = %q, want h2", clientState.NegotiatedProtocol)
+	}
+	if len(serverState.VerifiedChains) == 0 || serverState.VerifiedChains[0][0].Subject.CommonName != "client" {
+		t.Errorf("a client certificate is not verified: %v", serverState.VerifiedChains)
+	}
+
+	_, clientState = handshake(t, serverConfig, clientConfig)
+	if !clientState.DidResume {
+		t.Error("a session is not resumed with a ticket of a previous handshake")
+	}
+}
@@ -0,0 +1,188 @@
+// Package envelope encrypts data with per-record data keys which are
+// wrapped by master keys. A master key rotates by re-wrapping data keys,
+// records themselves are not re-encrypted.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const keyLength = 32
+
+// commitmentKeyInfo separates a commitment key from a data key itself.
+const commitmentKeyInfo = "test-signer/answers-commitment/v1"
+
+var (
+	ErrInvalidMasterKey  = errors.New("a master key must be 32 bytes encoded in hex or base64")
+	ErrUnknownMasterKey  = errors.New("a data key is wrapped by an unknown master key")
+	ErrInvalidCiphertext = errors.New("a ciphertext can not be decrypted")
+)
+
+// MasterKeys wrap data keys with a current key and unwrap data keys
+// wrapped by the current or a retired key. A key is identified by
+// a fingerprint, so a configuration does not name keys.
+type MasterKeys struct {
+	currentID string
+	keys      map[string]cipher.AEAD
+	// retiredIDs keep an order of retired keys
+	retiredIDs []string
+}
+
+// NewMasterKeys creates AES-256-GCM ciphers of 32-byte keys encoded
+// in hex or base64, e.g. by 'openssl rand -hex 32'.
+func NewMasterKeys(current string, retired []string) (*MasterKeys, error) {
+	currentKey, err := decodeKey(current)
+	if err != nil {
+		return nil, err
+	}
+	currentCipher, err := newCipher(currentKey)
+	if err != nil {
+		return nil, err
+	}
+	masterKeys := MasterKeys{
+		currentID: fingerprint(currentKey),
+		keys:      map[string]cipher.AEAD{fingerprint(currentKey): currentCipher},
+	}
+	for i, encodedKey := range retired {
+		key, err := decodeKey(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("a retired master key %d: %w", i, err)
+		}
+		retiredCipher, err := newCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("a retired master key %d: %w", i, err)
+		}
+		keyID := fingerprint(key)
+		if _, ok := masterKeys.keys[keyID]; !ok {
+			masterKeys.keys[keyID] = retiredCipher
+			masterKeys.retiredIDs = append(masterKeys.retiredIDs, keyID)
+		}
+	}
+	return &masterKeys, nil
+}
+
+// CurrentID identifies a key which wraps new data keys.
+func (m *MasterKeys) CurrentID() string {
+	return m.currentID
+}
+
+// RetiredIDs identify keys which only unwrap data keys.
+func (m *MasterKeys) RetiredIDs() []string {
+	return m.retiredIDs
+}
+
+// decodeKey reads a key encoded in hex or in padded or raw standard base64.
+func decodeKey(encodedKey string) ([]byte, error) {
+	decoders := []func(string) ([]byte, error){
+		hex.DecodeString,
+		base64.StdEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+	}
+	for _, decode := range decoders {
+		if key, err := decode(encodedKey); err == nil && len(key) == keyLength {
+			return key, nil
+		}
+	}
+	return nil, ErrInvalidMasterKey
+}
+
+func fingerprint(key []byte) string {
+	hash := sha256.Sum256(append([]byte("test-signer master key\x00"), key...))
+	return hex.EncodeToString(hash[:8])
+}
+
+// NewDataKey generates a data key and wraps it with a current master key.
+// A record ID binds a wrapped key to its record.
+func (m *MasterKeys) NewDataKey(recordID []byte) (dataKey []byte, keyID string, wrapped []byte, err error) {
+	dataKey = make([]byte, keyLength)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, "", nil, fmt.Errorf("can not generate a data key: %w", err)
+	}
+	wrapped, err = Seal(m.keys[m.currentID], dataKey, recordID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return dataKey, m.currentID, wrapped, nil
+}
+
+// Unwrap decrypts a data key of a record.
+func (m *MasterKeys) Unwrap(keyID string, wrapped []byte, recordID []byte) ([]byte, error) {
+	masterKey, ok := m.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownMasterKey, keyID)
+	}
+	return Open(masterKey, wrapped, recordID)
+}
+
+// Rewrap wraps a data key of a record with a current master key.
+func (m *MasterKeys) Rewrap(keyID string, wrapped []byte, recordID []byte) (string, []byte, error) {
+	dataKey, err := m.Unwrap(keyID, wrapped, recordID)
+	if err != nil {
+		return "", nil, err
+	}
+	rewrapped, err := Seal(m.keys[m.currentID], dataKey, recordID)
+	if err != nil {
+		return "", nil, err
+	}
+	return m.currentID, rewrapped, nil
+}
+
+// DataCipher creates a cipher of a data key.
+func DataCipher(dataKey []byte) (cipher.AEAD, error) {
+	return newCipher(dataKey)
+}
+
+// CommitmentKey derives a key of keyed hashes of record data from a data
+// key. Once a data key is destroyed, data can not be guessed from the hashes.
+func CommitmentKey(dataKey []byte) []byte {
+	mac := hmac.New(sha256.New, dataKey)
+	mac.Write([]byte(commitmentKeyInfo))
+	return mac.Sum(nil)
+}
+
+func newCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != keyLength {
+		return nil, fmt.Errorf("a key of %d bytes, %d bytes required", len(key), keyLength)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating AES cipher: %w", err)
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating GCM: %w", err)
+	}
+	return aesgcm, nil
+}
+
+// Seal encrypts a plaintext and prepends a nonce. Additional data is
+// authenticated, so a ciphertext can not be moved to another record.
+func Seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("Error generating nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts a ciphertext created by Seal with the same additional data.
+func Open(aead cipher.AEAD, sealed []byte, additionalData []byte) ([]byte, error) {
+	nonceSize := aead.NonceSize()
+	if len(sealed) <= nonceSize {
+		return nil, ErrInvalidCiphertext
+	}
+	plaintext, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], additionalData)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidCiphertext, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,145 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var (
+	firstKey  = strings.Repeat("ab", 32)
+	secondKey = strings.Repeat("cd", 32)
+)
+
+func newTestMasterKeys(t *testing.T, current string, retired ...string) *MasterKeys {
+	t.Helper()
+	masterKeys, err := NewMasterKeys(current, retired)
+	if err != nil {
+		t.Fatalf("can not create master keys: %v", err)
+	}
+	return masterKeys
+}
+
+func TestNewMasterKeysDecodesKeys(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, keyLength)
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{"hex", hex.EncodeToString(key), true},
+		{"base64", base64.StdEncoding.EncodeToString(key), true},
+		{"raw base64", base64.RawStdEncoding.EncodeToString(key), true},
+		{"a passphrase", strings.Repeat("m", 32), false},
+		{"a short hex key", strings.Repeat("ab", 16), false},
+		{"a long hex key", strings.Repeat("ab", 33), false},
+		{"a short base64 key", base64.StdEncoding.EncodeToString(key[:16]), false},
+	}
+	expectedID := newTestMasterKeys(t, firstKey).CurrentID()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			masterKeys, err := NewMasterKeys(test.key, nil)
+			if !test.valid {
+				if !errors.Is(err, ErrInvalidMasterKey) {
+					t.Errorf("unexpected error of an invalid key: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("a valid key is refused: %v", err)
+			}
+			if masterKeys.CurrentID() != expectedID {
+				t.Errorf("a key ID depends on an encoding: %s", masterKeys.CurrentID())
+			}
+		})
+	}
+	if _, err := NewMasterKeys(firstKey, []string{"short"}); !errors.Is(err, ErrInvalidMasterKey) {
+		t.Errorf("an invalid retired key is accepted: %v", err)
+	}
+}
+
+func TestOpenBindsAdditionalData(t *testing.T) {
+	dataKey, _, _, err := newTestMasterKeys(t, firstKey).NewDataKey([]byte("record"))
+	if err != nil {
+		t.Fatalf("can not create a data key: %v", err)
+	}
+	dataCipher, err := DataCipher(dataKey)
+	if err != nil {
+		t.Fatalf("can not create a data cipher: %v", err)
+	}
+	recordID, otherRecordID := uuid.New(), uuid.New()
+	sealed, err := Seal(dataCipher, []byte("an answer"), append(recordID[:], "answer"...))
+	if err != nil {
+		t.Fatalf("can not seal: %v", err)
+	}
+	opened, err := Open(dataCipher, sealed, append(recordID[:], "answer"...))
+	if err != nil || string(opened) != "an answer" {
+		t.Fatalf("can not open: %q, %v", opened, err)
+	}
+	tests := map[string][]byte{
+		"another record": append(otherRecordID[:], "answer"...),
+		"another field":  append(recordID[:], "question"...),
+		"no data":        nil,
+	}
+	for name, additionalData := range tests {
+		if _, err := Open(dataCipher, sealed, additionalData); !errors.Is(err, ErrInvalidCiphertext) {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	if _, err := Open(dataCipher, sealed[:4], nil); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("a truncated ciphertext: unexpected error: %v", err)
+	}
+}
+
+func TestUnwrapUnknownMasterKey(t *testing.T) {
+	oldKeys := newTestMasterKeys(t, firstKey)
+	_, keyID, wrapped, err := oldKeys.NewDataKey([]byte("record"))
+	if err != nil {
+		t.Fatalf("can not create a data key: %v", err)
+	}
+	newKeys := newTestMasterKeys(t, secondKey)
+	if _, err := newKeys.Unwrap(keyID, wrapped, []byte("record")); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Errorf("unexpected error of an unknown key: %v", err)
+	}
+	if _, err := oldKeys.Unwrap(keyID, wrapped, []byte("another record")); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Errorf("a data key is unwrapped for another record: %v", err)
+	}
+}
+
+func TestRewrapRetiredMasterKey(t *testing.T) {
+	oldKeys := newTestMasterKeys(t, firstKey)
+	recordID := []byte("record")
+	dataKey, oldID, wrapped, err := oldKeys.NewDataKey(recordID)
+	if err != nil {
+		t.Fatalf("can not create a data key: %v", err)
+	}
+	rotatedKeys := newTestMasterKeys(t, secondKey, firstKey)
+	newID, rewrapped, err := rotatedKeys.Rewrap(oldID, wrapped, recordID)
+	if err != nil {
+		t.Fatalf("can not rewrap a data key: %v", err)
+	}
+	if newID != rotatedKeys.CurrentID() || newID == oldID {
+		t.Errorf("a data key is rewrapped by %s, expected %s", newID, rotatedKeys.CurrentID())
+	}
+	unwrapped, err := newTestMasterKeys(t, secondKey).Unwrap(newID, rewrapped, recordID)
+	if err != nil {
+		t.Fatalf("can not unwrap a rewrapped data key: %v", err)
+	}
+	if !bytes.Equal(unwrapped, dataKey) {
+		t.Error("a rewrapped data key differs")
+	}
+}
+
+func TestRetiredMasterKeysAreDeduplicated(t *testing.T) {
+	base64Key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xab}, keyLength))
+	masterKeys := newTestMasterKeys(t, secondKey, firstKey, firstKey, base64Key, secondKey)
+	retiredIDs := masterKeys.RetiredIDs()
+	if len(retiredIDs) != 1 || retiredIDs[0] != newTestMasterKeys(t, firstKey).CurrentID() {
+		t.Errorf("unexpected retired keys: %v", retiredIDs)
+	}
+}
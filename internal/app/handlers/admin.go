@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/internal/app/auth"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+)
+
+func (h HandlerContainer) AuditLogHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		ctx := withActor(r, principal)
+		query := r.URL.Query()
+		from, err := parseTimeParam(query.Get("from"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid 'from': %s", err), http.StatusBadRequest)
+			return
+		}
+		to, err := parseTimeParam(query.Get("to"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid 'to': %s", err), http.StatusBadRequest)
+			return
+		}
+
+		if query.Get("format") == "jsonl" {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			if err := h.AuditSvc.ExportEvents(ctx, from, to, w); err != nil {
+				slog.ErrorContext(ctx, "an audit export error", "error", err)
+				http.Error(w, "An internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		afterID, err := parseIntParam(query.Get("after_id"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid 'after_id': %s", err), http.StatusBadRequest)
+			return
+		}
+		limit, err := parseIntParam(query.Get("limit"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid 'limit': %s", err), http.StatusBadRequest)
+			return
+		}
+		events, err := h.AuditSvc.QueryEvents(ctx, from, to, afterID, int(limit))
+		if err != nil {
+			slog.ErrorContext(ctx, "an audit query error", "error", err)
+			http.Error(w, "An internal error", http.StatusInternalServerError)
+			return
+		}
+		response := AuditLogResponse{Events: events}
+		if len(events) > 0 {
+			response.NextAfterID = events[len(events)-1].ID
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			slog.ErrorContext(ctx, "response composition error", "error", err)
+		}
+	}
+}
+
+// EraseUserHandler erases answers of a user on a request to erasure.
+func (h HandlerContainer) EraseUserHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		ctx := withActor(r, principal)
+		requestBody, err := readBody(w, r)
+		if err != nil {
+			return
+		}
+		var requestInfo ErasureRequest
+		if err := json.Unmarshal(requestBody, &requestInfo); err != nil {
+			http.Error(
+				w,
+				fmt.Sprintf("unexpected request body: %s", err.Error()),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		if requestInfo.UserID == "" {
+			http.Error(w, "'user_id' is a required field", http.StatusBadRequest)
+			return
+		}
+		owner := services.Owner{
+			UserID:   requestInfo.UserID,
+			Issuer:   requestInfo.Issuer,
+			TenantID: principal.TenantID,
+		}
+		erasure, err := h.ErasureSvc.EraseUser(ctx, owner)
+		if errors.Is(err, services.ErrLegalHold) {
+			http.Error(w, "Signatures of the user are under a legal hold", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "an erasure error", "user_id", owner.UserID, "error", err)
+			http.Error(w, "An internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(erasure); err != nil {
+			slog.ErrorContext(ctx, "response composition error", "error", err)
+		}
+	}
+}
+
+// LegalHoldsHandler lists holds of a client tenant on GET and places
+// a hold on POST.
+func (h HandlerContainer) LegalHoldsHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		ctx := withActor(r, principal)
+		if r.Method == http.MethodGet {
+			includeLifted := r.URL.Query().Get("include_lifted") == "true"
+			holds, err := h.LegalHoldSvc.ListHolds(ctx, includeLifted)
+			if err != nil {
+				slog.ErrorContext(ctx, "a legal hold query error", "error", err)
+				http.Error(w, "An internal error", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(LegalHoldsResponse{Holds: holds}); err != nil {
+				slog.ErrorContext(ctx, "response composition error", "error", err)
+			}
+			return
+		}
+		requestBody, err := readBody(w, r)
+		if err != nil {
+			return
+		}
+		var requestInfo LegalHoldRequest
+		if err := json.Unmarshal(requestBody, &requestInfo); err != nil {
+			http.Error(
+				w,
+				fmt.Sprintf("unexpected request body: %s", err.Error()),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		hold := services.LegalHold{
+			TenantID:         principal.TenantID,
+			SignatureID:      requestInfo.SignatureID,
+			UserID:           requestInfo.UserID,
+			RequestIDPattern: requestInfo.RequestIDPattern,
+			Reason:           requestInfo.Reason,
+		}
+		hold, err = h.LegalHoldSvc.PlaceHold(ctx, hold)
+		if errors.Is(err, services.ErrInvalidLegalHold) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrSignatureNotFound) {
+			http.Error(w, "A signature does not exist", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "a legal hold error", "error", err)
+			http.Error(w, "An internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(hold); err != nil {
+			slog.ErrorContext(ctx, "response composition error", "error", err)
+		}
+	}
+}
+
+// LiftLegalHoldHandler lifts an active hold of a client tenant.
+func (h HandlerContainer) LiftLegalHoldHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		ctx := withActor(r, principal)
+		requestBody, err := readBody(w, r)
+		if err != nil {
+			return
+		}
+		var requestInfo LiftLegalHoldRequest
+		if err := json.Unmarshal(requestBody, &requestInfo); err != nil {
+			http.Error(
+				w,
+				fmt.Sprintf("unexpected request body: %s", err.Error()),
+				http.StatusBadRequest,
+			)
+			return
+		}
+		if requestInfo.ID == "" {
+			http.Error(w, "'id' is a required field", http.StatusBadRequest)
+			return
+		}
+		hold, err := h.LegalHoldSvc.LiftHold(ctx, requestInfo.ID)
+		if errors.Is(err, services.ErrLegalHoldNotFound) {
+			http.Error(w, "An active legal hold does not exist", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "a legal hold error", "hold_id", requestInfo.ID, "error", err)
+			http.Error(w, "An internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(hold); err != nil {
+			slog.ErrorContext(ctx, "response composition error", "error", err)
+		}
+	}
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func parseIntParam(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/internal/app/auth"
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+	"github.com/google/uuid"
+)
+
+// heldErasureRepo refuses to erase signatures under a legal hold.
+type heldErasureRepo struct{}
+
+func (heldErasureRepo) EraseSignatures(
+	ctx context.Context,
+	tenantID, userID, issuer string,
+	erasedAt time.Time,
+	record r.AuditRecord,
+) ([]uuid.UUID, error) {
+	return nil, r.ErrLegalHold
+}
+
+type discardAudit struct{}
+
+func (discardAudit) Record(context.Context, services.AuditEvent) error {
+	return nil
+}
+
+func TestEraseHeldUser(t *testing.T) {
+	container := HandlerContainer{
+		ErasureSvc: services.NewErasureSvc(heldErasureRepo{}, discardAudit{}, services.SystemClock{}),
+	}
+	request := httptest.NewRequest("POST", "/api/v1/admin/erasures", strings.NewReader(`{"user_id": "u1"}`))
+	principal := auth.Principal{ClientID: "legal", Scopes: []string{services.ScopeErase}, TenantID: "acme"}
+	request = request.WithContext(auth.ContextWithPrincipal(request.Context(), principal))
+	response := httptest.NewRecorder()
+	container.EraseUserHandler()(response, request)
+	if response.Code != http.StatusConflict {
+		t.Errorf("unexpected status of a held user: %d", response.Code)
+	}
+}
@@ -7,64 +7,33 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
-	"strings"
-	"time"
 
+	"github.com/AndreyAD1/test-signer/internal/app/auth"
+	"github.com/AndreyAD1/test-signer/internal/app/middleware"
 	"github.com/AndreyAD1/test-signer/internal/app/services"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/AndreyAD1/test-signer/internal/app/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+var tracer = otel.Tracer("github.com/AndreyAD1/test-signer/internal/app/handlers")
+
 func (h HandlerContainer) SignAnswersHandler() func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx, cancel := context.WithTimeout(context.Background(), h.Timeout*time.Second)
-		defer cancel()
-		if r.Method != http.MethodPost {
-			errMsg := fmt.Sprintf("Invalid method: '%s'. Expect 'POST'.", r.Method)
-			http.Error(w, errMsg, http.StatusMethodNotAllowed)
-			return
-		}
-		tokens := r.Header["Authorization"]
-		if len(tokens) != 1 {
-			log.Printf("unexpected tokens from %s", r.RemoteAddr)
-			http.Error(w, "", http.StatusUnauthorized)
-			return
-		}
-		rawToken, ok := strings.CutPrefix(tokens[0], "Bearer ")
+		ctx, span := tracer.Start(r.Context(), "SignAnswersHandler")
+		defer span.End()
+		principal, ok := auth.PrincipalFromContext(ctx)
 		if !ok {
-			log.Printf("unexpected tokens from %s", r.RemoteAddr)
+			slog.WarnContext(ctx, "no authenticated principal", "remote_addr", r.RemoteAddr)
 			http.Error(w, "", http.StatusUnauthorized)
 			return
 		}
-		token, err := jwt.ParseWithClaims(
-			rawToken,
-			&JWTClaims{},
-			func(token *jwt.Token) (interface{}, error) {
-				return []byte(h.ApiSecret), nil
-			},
-		)
-		if err != nil {
-			log.Printf("can not parse a JWT token: %s: %s", r.RemoteAddr, err)
-			http.Error(w, "the unexpected JWT token", http.StatusBadRequest)
-			return
-		}
 
-		claims, ok := token.Claims.(*JWTClaims)
-		if !ok {
-			log.Printf("unexpected claims: %s: %s", r.RemoteAddr, token.Claims)
-			http.Error(w, "the unexpected JWT token", http.StatusBadRequest)
-			return
-		}
-		if claims.UserID == "" {
-			log.Printf("no user ID in jwt key: %s", r.RemoteAddr)
-			http.Error(w, "the unexpected JWT token", http.StatusBadRequest)
-			return
-		}
-
-		requestBody, err := io.ReadAll(r.Body)
+		requestBody, err := readBody(w, r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 		var requestInfo SignAnswersRequest
@@ -84,6 +53,10 @@ func (h HandlerContainer) SignAnswersHandler() func(w http.ResponseWriter, r *ht
 			)
 			return
 		}
+		span.SetAttributes(
+			attribute.String("signature.request_id", requestInfo.ID),
+			tracing.AnswerCountKey.Int(len(requestInfo.TestAnswers)),
+		)
 
 		testInfo := []services.TestAnswer{}
 		for _, answer := range requestInfo.TestAnswers {
@@ -97,7 +70,11 @@ func (h HandlerContainer) SignAnswersHandler() func(w http.ResponseWriter, r *ht
 		testSignature, err := h.SignatureSvc.CreateSignature(
 			ctx,
 			requestInfo.ID,
-			claims.UserID,
+			services.Owner{
+				UserID:   principal.UserID,
+				Issuer:   principal.Issuer,
+				TenantID: principal.TenantID,
+			},
 			testInfo,
 		)
 		if errors.Is(err, services.ErrDuplicatedSignature) {
@@ -109,7 +86,8 @@ func (h HandlerContainer) SignAnswersHandler() func(w http.ResponseWriter, r *ht
 			return
 		}
 		if err != nil {
-			log.Printf("a DB signature error for %s: %s", claims.UserID, err)
+			tracing.RecordError(span, err)
+			slog.ErrorContext(ctx, "a DB signature error", "user_id", principal.UserID, "error", err)
 			http.Error(w, "An internal error occurred.", http.StatusInternalServerError)
 			return
 		}
@@ -119,7 +97,7 @@ func (h HandlerContainer) SignAnswersHandler() func(w http.ResponseWriter, r *ht
 		response := SignAnswersResponse{Signature: base64Signature}
 		err = json.NewEncoder(w).Encode(response)
 		if err != nil {
-			log.Printf("response composition error for %s: %s", claims.UserID, err)
+			slog.ErrorContext(ctx, "response composition error", "user_id", principal.UserID, "error", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -128,16 +106,18 @@ func (h HandlerContainer) SignAnswersHandler() func(w http.ResponseWriter, r *ht
 
 func (h HandlerContainer) VerifySignatureHandler() func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx, cancel := context.WithTimeout(context.Background(), h.Timeout*time.Second)
-		defer cancel()
-		if r.Method != http.MethodPost {
-			errMsg := fmt.Sprintf("Invalid method: '%s'. Expect 'POST'.", r.Method)
-			http.Error(w, errMsg, http.StatusMethodNotAllowed)
+		ctx, span := tracer.Start(r.Context(), "VerifySignatureHandler")
+		defer span.End()
+		r = r.WithContext(ctx)
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok || !principal.HasScope(services.ScopeVerify) {
+			slog.WarnContext(ctx, "no authenticated verifier", "remote_addr", r.RemoteAddr)
+			http.Error(w, "", http.StatusUnauthorized)
 			return
 		}
-		requestBody, err := io.ReadAll(r.Body)
+		ctx = withActor(r, principal)
+		requestBody, err := readBody(w, r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 		var requestInfo VerifyRequest
@@ -160,11 +140,17 @@ func (h HandlerContainer) VerifySignatureHandler() func(w http.ResponseWriter, r
 		decodedSignature, err := base64.StdEncoding.DecodeString(requestInfo.Signature)
 		if err != nil {
 			errMsg := fmt.Sprintf("a signature is invalid.")
-			log.Printf("a signature is invalid: %v", err)
+			slog.DebugContext(ctx, "a signature is invalid", "error", err)
 			http.Error(w, errMsg, http.StatusBadRequest)
 			return
 		}
-		signature, err := h.SignatureSvc.VerifySignature(ctx, requestInfo.UserID, decodedSignature)
+		// a verifier sees signatures of its own tenant only
+		owner := services.Owner{
+			UserID:   requestInfo.UserID,
+			Issuer:   requestInfo.Issuer,
+			TenantID: principal.TenantID,
+		}
+		signature, err := h.SignatureSvc.VerifySignature(ctx, owner, decodedSignature)
 		if errors.Is(err, services.ErrInvalidSignature) {
 			http.Error(w, "Unexpected signature", http.StatusBadRequest)
 			return
@@ -174,20 +160,83 @@ func (h HandlerContainer) VerifySignatureHandler() func(w http.ResponseWriter, r
 			return
 		}
 		if err != nil {
+			tracing.RecordError(span, err)
 			http.Error(w, "An internal error", http.StatusInternalServerError)
 			return
 		}
+		span.SetAttributes(tracing.SignatureIDKey.String(signature.ID))
+		response := VerifyResponse{
+			Valid:          true,
+			Timestamp:      signature.Timestamp,
+			Issuer:         signature.Issuer,
+			TimestampToken: signature.TimestampToken,
+			TimestampedAt:  signature.TimestampedAt,
+			ContentErased:  signature.ErasedAt != nil,
+			ErasedAt:       signature.ErasedAt,
+		}
+		if principal.HasScope(services.ScopeReadAnswers) {
+			response.Answers = signature.Answers
+		}
+		proof, err := h.TransparencySvc.InclusionProof(ctx, signature.ChainSeq, signature.RecordHash)
+		if err == nil {
+			response.Transparency = &proof
+		} else if !errors.Is(err, services.ErrNotLogged) && !errors.Is(err, services.ErrTreeHeadNotFound) {
+			slog.ErrorContext(
+				ctx,
+				"can not build an inclusion proof",
+				"signature_id", signature.ID,
+				"error", err,
+			)
+		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(signature)
+		err = json.NewEncoder(w).Encode(response)
 		if err != nil {
-			log.Printf(
-				"response composition error for %s: %s",
-				requestInfo.UserID, 
-				err,
+			slog.ErrorContext(
+				ctx,
+				"response composition error",
+				"user_id", requestInfo.UserID,
+				"error", err,
 			)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 	}
 }
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	requestBody, err := io.ReadAll(r.Body)
+	if err == nil {
+		return requestBody, nil
+	}
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		errMsg := fmt.Sprintf("a request body exceeds %d bytes", maxBytesError.Limit)
+		http.Error(w, errMsg, http.StatusRequestEntityTooLarge)
+		return nil, err
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+	return nil, err
+}
+
+// withActor puts an audit actor of an authenticated request into its context.
+func withActor(r *http.Request, principal auth.Principal) context.Context {
+	actorID := "user:" + principal.UserID
+	if principal.ClientID != "" {
+		actorID = "client:" + principal.ClientID
+	}
+	if principal.Issuer != "" {
+		actorID = actorID + "@" + principal.Issuer
+	}
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+	actor := services.Actor{
+		ID:        actorID,
+		ClientIP:  clientIP,
+		RequestID: middleware.RequestIDFromContext(r.Context()),
+		TenantID:  principal.TenantID,
+	}
+	return services.ContextWithActor(r.Context(), actor)
+}
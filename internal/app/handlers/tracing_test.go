@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AndreyAD1/test-signer/internal/app/auth"
+	"github.com/AndreyAD1/test-signer/internal/app/envelope"
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+	"github.com/AndreyAD1/test-signer/internal/app/tracing"
+	"github.com/AndreyAD1/test-signer/internal/app/tracing/tracingtest"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanByName(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no span '%s'", name)
+	return tracetest.SpanStub{}
+}
+
+// newUnreachableSignatureSvc stores signatures in a database which refuses
+// connections, so a repository span ends with an error.
+func newUnreachableSignatureSvc(t *testing.T) *services.SignatureSvc {
+	t.Helper()
+	dbPool, err := pgxpool.New(context.Background(), "postgres://signer@127.0.0.1:1/signer?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("can not create a pool: %v", err)
+	}
+	t.Cleanup(dbPool.Close)
+	masterKeys, err := envelope.NewMasterKeys(strings.Repeat("ab", 32), nil)
+	if err != nil {
+		t.Fatalf("can not create master keys: %v", err)
+	}
+	keyring, err := services.NewKeyring(strings.Repeat("k", 32), nil)
+	if err != nil {
+		t.Fatalf("can not create a keyring: %v", err)
+	}
+	service, err := services.NewSignatureSvc(
+		r.NewSignatureCollection(dbPool, masterKeys),
+		services.NewKeyrings(keyring, nil),
+		nil,
+		nil,
+		services.SystemClock{},
+		services.RandomIDGenerator{},
+	)
+	if err != nil {
+		t.Fatalf("can not create a service: %v", err)
+	}
+	return service
+}
+
+func TestSignAnswersSpans(t *testing.T) {
+	exporter := tracingtest.RecordSpans(t)
+	container := HandlerContainer{SignatureSvc: newUnreachableSignatureSvc(t)}
+	handler := tracing.Middleware("/api/v1/sign")(http.HandlerFunc(container.SignAnswersHandler()))
+	body := `{"id": "r1", "test": [{"question": "q1", "answer": "a1"}]}`
+	request := httptest.NewRequest("POST", "/api/v1/sign", strings.NewReader(body))
+	request = request.WithContext(auth.ContextWithPrincipal(request.Context(), auth.Principal{UserID: "u1"}))
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if response.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected status: %d", response.Code)
+	}
+
+	spans := exporter.GetSpans()
+	server := spanByName(t, spans, "POST /api/v1/sign")
+	handlerSpan := spanByName(t, spans, "SignAnswersHandler")
+	service := spanByName(t, spans, "SignatureSvc.CreateSignature")
+	seal := spanByName(t, spans, "SignatureSvc.seal")
+	repository := spanByName(t, spans, "SignatureCollection.Add")
+	parents := []struct {
+		child  tracetest.SpanStub
+		parent tracetest.SpanStub
+	}{
+		{handlerSpan, server},
+		{service, handlerSpan},
+		{seal, service},
+		{repository, service},
+	}
+	for _, link := range parents {
+		if link.child.Parent.SpanID() != link.parent.SpanContext.SpanID() {
+			t.Errorf("a parent of '%s' is not '%s'", link.child.Name, link.parent.Name)
+		}
+		if link.child.SpanContext.TraceID() != server.SpanContext.TraceID() {
+			t.Errorf("'%s' is not in a trace of a request", link.child.Name)
+		}
+	}
+	for _, span := range []tracetest.SpanStub{server, handlerSpan, service, repository} {
+		if span.Status.Code != codes.Error {
+			t.Errorf("'%s' has a status %s, expected an error", span.Name, span.Status.Code)
+		}
+	}
+	for _, span := range []tracetest.SpanStub{handlerSpan, service, repository} {
+		if len(span.Events) == 0 || span.Events[0].Name != "exception" {
+			t.Errorf("'%s' has no recorded error", span.Name)
+		}
+	}
+	if seal.Status.Code != codes.Unset {
+		t.Errorf("'%s' has a status %s, expected unset", seal.Name, seal.Status.Code)
+	}
+	for _, span := range spans {
+		for _, attr := range span.Attributes {
+			if attr.Value.Emit() == "u1" {
+				t.Errorf("'%s' carries a user ID in '%s'", span.Name, attr.Key)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+)
+
+func (h HandlerContainer) TreeHeadHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		size := int64(-1)
+		if rawSize := r.URL.Query().Get("size"); rawSize != "" {
+			parsedSize, err := strconv.ParseInt(rawSize, 10, 64)
+			if err != nil || parsedSize < 0 {
+				http.Error(w, "invalid 'size'", http.StatusBadRequest)
+				return
+			}
+			size = parsedSize
+		}
+		head, err := h.TransparencySvc.TreeHead(r.Context(), size)
+		if errors.Is(err, services.ErrTreeHeadNotFound) {
+			http.Error(w, "A tree head does not exist", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "can not read a tree head", "size", size, "error", err)
+			http.Error(w, "An internal error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, head)
+	}
+}
+
+func (h HandlerContainer) ConsistencyProofHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		first, firstErr := strconv.ParseInt(query.Get("first"), 10, 64)
+		second, secondErr := strconv.ParseInt(query.Get("second"), 10, 64)
+		if firstErr != nil || secondErr != nil {
+			http.Error(w, "'first' and 'second' tree sizes are required", http.StatusBadRequest)
+			return
+		}
+		proof, err := h.TransparencySvc.ConsistencyProof(r.Context(), first, second)
+		if errors.Is(err, services.ErrInvalidTreeSize) || errors.Is(err, services.ErrTreeHeadNotFound) {
+			http.Error(w, "Unexpected tree sizes", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(
+				r.Context(),
+				"can not build a consistency proof",
+				"first", first,
+				"second", second,
+				"error", err,
+			)
+			http.Error(w, "An internal error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, ConsistencyProofResponse{First: first, Second: second, Proof: proof})
+	}
+}
+
+func (h HandlerContainer) LogPublicKeyHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		publicKey := base64.StdEncoding.EncodeToString(h.TransparencySvc.PublicKey())
+		writeJSON(w, LogPublicKeyResponse{Algorithm: "Ed25519", PublicKey: publicKey})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("response composition error", "error", err)
+	}
+}
@@ -4,13 +4,14 @@ import (
 	"time"
 
 	"github.com/AndreyAD1/test-signer/internal/app/services"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type HandlerContainer struct {
-	ApiSecret    string
-	SignatureSvc services.SignatureService
-	Timeout      time.Duration
+	SignatureSvc    services.SignatureService
+	AuditSvc        services.AuditService
+	TransparencySvc services.TransparencyService
+	ErasureSvc      services.ErasureService
+	LegalHoldSvc    services.LegalHoldService
 }
 
 type SignAnswersRequest struct {
@@ -23,16 +24,67 @@ type answer struct {
 	Answer   string `json:"answer"`
 }
 
-type JWTClaims struct {
-	UserID string `json:"user_id"`
-	jwt.RegisteredClaims
-}
-
 type SignAnswersResponse struct {
 	Signature string `json:"signature"`
 }
 
 type VerifyRequest struct {
 	UserID    string `json:"user_id"`
+	Issuer    string `json:"issuer"` // optional, matches any issuer if empty
 	Signature string `json:"signature"`
 }
+
+// VerifyResponse contains answers only for clients allowed to read them.
+type VerifyResponse struct {
+	Valid     bool      `json:"valid"`
+	Timestamp time.Time `json:"timestamp"`
+	Issuer    string    `json:"issuer"`
+	Answers   []string  `json:"answers,omitempty"`
+	// TimestampToken is a DER RFC 3161 token over SHA-256 of a signature.
+	TimestampToken []byte     `json:"timestamp_token,omitempty"`
+	TimestampedAt  *time.Time `json:"timestamped_at,omitempty"`
+	// Transparency is absent until a signature is covered by a tree head.
+	Transparency *services.InclusionProof `json:"transparency,omitempty"`
+	// ContentErased signatures are valid, but their answers are erased.
+	ContentErased bool       `json:"content_erased,omitempty"`
+	ErasedAt      *time.Time `json:"erased_at,omitempty"`
+}
+
+// ErasureRequest erases signatures of a user in a tenant of a client.
+type ErasureRequest struct {
+	UserID string `json:"user_id"`
+	Issuer string `json:"issuer"` // optional, matches any issuer if empty
+}
+
+// LegalHoldRequest holds signatures in a tenant of a client by exactly one
+// of a signature ID, a user ID or a request ID pattern.
+type LegalHoldRequest struct {
+	SignatureID      string `json:"signature_id"`
+	UserID           string `json:"user_id"`
+	RequestIDPattern string `json:"request_id_pattern"`
+	Reason           string `json:"reason"`
+}
+
+type LiftLegalHoldRequest struct {
+	ID string `json:"id"`
+}
+
+type LegalHoldsResponse struct {
+	Holds []services.LegalHold `json:"holds"`
+}
+
+type AuditLogResponse struct {
+	Events      []services.AuditEvent `json:"events"`
+	NextAfterID int64                 `json:"next_after_id,omitempty"`
+}
+
+type ConsistencyProofResponse struct {
+	First  int64    `json:"first"`
+	Second int64    `json:"second"`
+	Proof  [][]byte `json:"proof"`
+}
+
+type LogPublicKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
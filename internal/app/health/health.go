@@ -0,0 +1,102 @@
+// Package health serves liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+var ErrDraining = errors.New("the server is shutting down")
+
+// Check returns an error if a dependency is not ready.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type Checker struct {
+	checks   []namedCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+// NewChecker creates a checker limiting every readiness check by a timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a readiness check. It is not safe to call after
+// the checker starts serving probes.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name, check})
+}
+
+// StartDraining makes readiness fail permanently.
+func (c *Checker) StartDraining() {
+	c.draining.Store(true)
+}
+
+type Response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// LivenessHandler reports that the process serves requests.
+func (c *Checker) LivenessHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, Response{Status: statusOK})
+	}
+}
+
+// ReadinessHandler runs all checks and fails if any of them fails
+// or the server is draining.
+func (c *Checker) ReadinessHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := Response{Status: statusOK, Checks: map[string]string{}}
+		if c.draining.Load() {
+			response.Status = statusFail
+			response.Checks["shutdown"] = ErrDraining.Error()
+			writeResponse(w, http.StatusServiceUnavailable, response)
+			return
+		}
+		for _, check := range c.checks {
+			ctx, cancel := context.WithTimeout(r.Context(), c.timeout)
+			err := check.check(ctx)
+			cancel()
+			if err != nil {
+				slog.WarnContext(r.Context(), "a readiness check failed", "check", check.name, "error", err)
+				response.Status = statusFail
+				// details stay in logs, probes may be reachable from outside
+				response.Checks[check.name] = statusFail
+				continue
+			}
+			response.Checks[check.name] = statusOK
+		}
+		status := http.StatusOK
+		if response.Status != statusOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeResponse(w, status, response)
+	}
+}
+
+func writeResponse(w http.ResponseWriter, status int, response Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("response composition error", "error", err)
+	}
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func probe(t *testing.T, handler func(http.ResponseWriter, *http.Request)) (int, Response, string) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var response Response
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("can not decode a response: %v", err)
+	}
+	return recorder.Code, response, recorder.Body.String()
+}
+
+func passing(context.Context) error {
+	return nil
+}
+
+func TestReadinessChecks(t *testing.T) {
+	schemaErr := errors.New("a schema version: 12 is applied, 16 is required")
+	keyErr := errors.New("a signing key is not loaded")
+	tests := []struct {
+		name   string
+		checks map[string]Check
+		status int
+		failed []string
+	}{
+		{
+			name:   "all checks pass",
+			checks: map[string]Check{"database": passing, "migrations": passing, "signing_keys": passing},
+			status: http.StatusOK,
+		},
+		{
+			name: "an old schema version",
+			checks: map[string]Check{
+				"database":     passing,
+				"migrations":   func(context.Context) error { return schemaErr },
+				"signing_keys": passing,
+			},
+			status: http.StatusServiceUnavailable,
+			failed: []string{"migrations"},
+		},
+		{
+			name: "an unloaded signing key",
+			checks: map[string]Check{
+				"database":     passing,
+				"migrations":   passing,
+				"signing_keys": func(context.Context) error { return keyErr },
+			},
+			status: http.StatusServiceUnavailable,
+			failed: []string{"signing_keys"},
+		},
+		{
+			name: "a check over a timeout",
+			checks: map[string]Check{
+				"database": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+				"migrations":   passing,
+				"signing_keys": passing,
+			},
+			status: http.StatusServiceUnavailable,
+			failed: []string{"database"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checker := NewChecker(10 * time.Millisecond)
+			for name, check := range test.checks {
+				checker.Add(name, check)
+			}
+			status, response, body := probe(t, checker.ReadinessHandler())
+			if status != test.status {
+				t.Errorf("unexpected status: %d", status)
+			}
+			for name := range test.checks {
+				expected := statusOK
+				for _, failed := range test.failed {
+					if name == failed {
+						expected = statusFail
+					}
+				}
+				if response.Checks[name] != expected {
+					t.Errorf("a check %s is %s, expected %s", name, response.Checks[name], expected)
+				}
+			}
+			if (response.Status == statusOK) != (len(test.failed) == 0) {
+				t.Errorf("unexpected readiness: %s", response.Status)
+			}
+			for _, err := range []error{schemaErr, keyErr} {
+				if strings.Contains(body, err.Error()) {
+					t.Errorf("a response discloses an error: %s", body)
+				}
+			}
+		})
+	}
+}
+
+func TestReadinessFailsWhileDraining(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checked := 0
+	checker.Add("database", func(context.Context) error {
+		checked++
+		return nil
+	})
+	if status, _, _ := probe(t, checker.ReadinessHandler()); status != http.StatusOK {
+		t.Fatalf("a checker is not ready: %d", status)
+	}
+	checker.StartDraining()
+	status, response, _ := probe(t, checker.ReadinessHandler())
+	if status != http.StatusServiceUnavailable || response.Status != statusFail {
+		t.Errorf("a draining checker is ready: %d %s", status, response.Status)
+	}
+	if response.Checks["shutdown"] != ErrDraining.Error() {
+		t.Errorf("unexpected checks of a draining checker: %v", response.Checks)
+	}
+	if checked != 1 {
+		t.Errorf("checks run %d times, a draining checker skips them", checked)
+	}
+	if status, response, _ := probe(t, checker.LivenessHandler()); status != http.StatusOK || response.Status != statusOK {
+		t.Errorf("a draining process is not alive: %d %s", status, response.Status)
+	}
+}
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE signatures DROP CONSTRAINT issuer_request_user_id;
+
+ALTER TABLE signatures ADD CONSTRAINT request_user_id UNIQUE (request_id, user_id);
+
+ALTER TABLE signatures DROP COLUMN issuer;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE signatures ADD COLUMN issuer varchar NOT NULL DEFAULT '';
+
+ALTER TABLE signatures DROP CONSTRAINT request_user_id;
+
+ALTER TABLE signatures ADD CONSTRAINT issuer_request_user_id
+UNIQUE (issuer, request_id, user_id);
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE TABLE verifiers(
+    id uuid PRIMARY KEY,
+    name varchar NOT NULL CHECK (name <> ''),
+    key_hash bytea NOT NULL,
+    scopes varchar[] NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    revoked_at timestamp with time zone,
+    CONSTRAINT verifier_name UNIQUE (name)
+);
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER audit_log_append_only ON audit_log;
+
+DROP FUNCTION audit_log_append_only;
+
+DROP TABLE audit_log;
+
+COMMIT;
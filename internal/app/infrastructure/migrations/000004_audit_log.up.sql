@@ -0,0 +1,28 @@
+BEGIN;
+
+CREATE TABLE audit_log(
+    id bigserial PRIMARY KEY,
+    occurred_at timestamp with time zone NOT NULL DEFAULT now(),
+    action varchar NOT NULL CHECK (action <> ''),
+    actor varchar NOT NULL,
+    signature_id uuid,
+    user_id varchar,
+    outcome varchar NOT NULL,
+    client_ip varchar,
+    request_id varchar,
+    details jsonb
+);
+
+CREATE INDEX audit_log_occurred_at ON audit_log (occurred_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+COMMIT;
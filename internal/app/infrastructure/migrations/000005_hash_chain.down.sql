@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX signatures_chain_seq;
+
+ALTER TABLE signatures DROP COLUMN record_hash;
+ALTER TABLE signatures DROP COLUMN answers_hash;
+ALTER TABLE signatures DROP COLUMN prev_hash;
+ALTER TABLE signatures DROP COLUMN chain_seq;
+
+COMMIT;
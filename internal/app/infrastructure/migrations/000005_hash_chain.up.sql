@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE signatures ADD COLUMN chain_seq bigint;
+ALTER TABLE signatures ADD COLUMN prev_hash bytea;
+ALTER TABLE signatures ADD COLUMN answers_hash bytea;
+ALTER TABLE signatures ADD COLUMN record_hash bytea;
+
+CREATE UNIQUE INDEX signatures_chain_seq ON signatures (chain_seq);
+
+COMMIT;
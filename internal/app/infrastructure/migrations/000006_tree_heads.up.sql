@@ -0,0 +1,10 @@
+BEGIN;
+
+CREATE TABLE tree_heads(
+    tree_size bigint PRIMARY KEY CHECK (tree_size >= 0),
+    root_hash bytea NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    signature bytea NOT NULL
+);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE signatures DROP COLUMN timestamped_at;
+ALTER TABLE signatures DROP COLUMN timestamp_token;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE signatures ADD COLUMN timestamp_token bytea;
+ALTER TABLE signatures ADD COLUMN timestamped_at timestamp with time zone;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE verifiers DROP COLUMN cert_identity;
+
+COMMIT;
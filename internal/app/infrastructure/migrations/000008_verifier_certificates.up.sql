@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE verifiers ADD COLUMN cert_identity varchar CHECK (cert_identity <> '');
+ALTER TABLE verifiers ADD CONSTRAINT verifier_cert_identity UNIQUE (cert_identity);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE rate_limit_buckets;
+
+COMMIT;
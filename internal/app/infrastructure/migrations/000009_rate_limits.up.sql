@@ -0,0 +1,10 @@
+BEGIN;
+
+CREATE TABLE rate_limit_buckets(
+    key varchar PRIMARY KEY,
+    tokens double precision,
+    updated_at timestamp with time zone
+);
+CREATE INDEX rate_limit_bucket_updated_at ON rate_limit_buckets (updated_at);
+
+COMMIT;
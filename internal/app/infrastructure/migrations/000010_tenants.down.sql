@@ -0,0 +1,16 @@
+BEGIN;
+
+DROP INDEX audit_log_tenant_occurred_at;
+
+ALTER TABLE audit_log DROP COLUMN tenant_id;
+
+ALTER TABLE verifiers DROP COLUMN tenant_id;
+
+ALTER TABLE signatures DROP CONSTRAINT tenant_issuer_request_user_id;
+
+ALTER TABLE signatures ADD CONSTRAINT issuer_request_user_id
+UNIQUE (issuer, request_id, user_id);
+
+ALTER TABLE signatures DROP COLUMN tenant_id;
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+ALTER TABLE signatures ADD COLUMN tenant_id varchar NOT NULL DEFAULT '';
+
+ALTER TABLE signatures DROP CONSTRAINT issuer_request_user_id;
+
+ALTER TABLE signatures ADD CONSTRAINT tenant_issuer_request_user_id
+UNIQUE (tenant_id, issuer, request_id, user_id);
+
+ALTER TABLE verifiers ADD COLUMN tenant_id varchar NOT NULL DEFAULT '';
+
+ALTER TABLE audit_log ADD COLUMN tenant_id varchar NOT NULL DEFAULT '';
+
+CREATE INDEX audit_log_tenant_occurred_at ON audit_log (tenant_id, occurred_at);
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX signatures_master_key_id;
+
+ALTER TABLE test_details DROP COLUMN sealed_answer;
+ALTER TABLE test_details DROP COLUMN sealed_question;
+
+ALTER TABLE signatures DROP COLUMN wrapped_data_key;
+ALTER TABLE signatures DROP COLUMN master_key_id;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE signatures ADD COLUMN master_key_id varchar;
+ALTER TABLE signatures ADD COLUMN wrapped_data_key bytea;
+
+ALTER TABLE test_details ADD COLUMN sealed_question bytea;
+ALTER TABLE test_details ADD COLUMN sealed_answer bytea;
+
+CREATE INDEX signatures_master_key_id ON signatures (master_key_id);
+
+COMMIT;
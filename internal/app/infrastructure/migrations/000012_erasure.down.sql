@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX signatures_tenant_user_id;
+
+ALTER TABLE signatures DROP COLUMN erased_at;
+
+COMMIT;
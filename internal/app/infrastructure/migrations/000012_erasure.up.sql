@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE signatures ADD COLUMN erased_at timestamp with time zone;
+
+CREATE INDEX signatures_tenant_user_id ON signatures (tenant_id, user_id);
+
+COMMIT;
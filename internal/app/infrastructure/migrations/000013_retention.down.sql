@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE archived_test_details;
+DROP TABLE archived_signatures;
+
+DROP INDEX signatures_created_at;
+
+COMMIT;
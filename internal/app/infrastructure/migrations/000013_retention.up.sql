@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE INDEX signatures_created_at ON signatures (created_at) WHERE erased_at IS NULL;
+
+CREATE TABLE archived_signatures(
+    id uuid PRIMARY KEY REFERENCES signatures (id),
+    tenant_id varchar NOT NULL,
+    user_id varchar NOT NULL,
+    master_key_id varchar,
+    wrapped_data_key bytea,
+    archived_at timestamp with time zone NOT NULL
+);
+CREATE INDEX archived_signatures_tenant_user_id ON archived_signatures (tenant_id, user_id);
+CREATE INDEX archived_signatures_master_key_id ON archived_signatures (master_key_id);
+
+CREATE TABLE archived_test_details(
+    id integer PRIMARY KEY,
+    signature_id uuid NOT NULL REFERENCES archived_signatures (id) ON DELETE CASCADE,
+    question varchar,
+    answer varchar,
+    sealed_question bytea,
+    sealed_answer bytea
+);
+CREATE INDEX archived_test_details_signature_id ON archived_test_details (signature_id);
+
+COMMIT;
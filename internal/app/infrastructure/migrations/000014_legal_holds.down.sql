@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP VIEW held_signatures;
+DROP TABLE legal_holds;
+
+COMMIT;
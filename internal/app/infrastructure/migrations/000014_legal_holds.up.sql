@@ -0,0 +1,28 @@
+BEGIN;
+
+CREATE TABLE legal_holds(
+    id uuid PRIMARY KEY,
+    tenant_id varchar NOT NULL DEFAULT '',
+    signature_id uuid REFERENCES signatures (id),
+    user_id varchar CHECK (user_id <> ''),
+    request_id_pattern varchar CHECK (request_id_pattern <> ''),
+    reason varchar NOT NULL CHECK (reason <> ''),
+    placed_by varchar NOT NULL,
+    placed_at timestamp with time zone NOT NULL DEFAULT now(),
+    lifted_by varchar,
+    lifted_at timestamp with time zone,
+    CONSTRAINT legal_hold_target CHECK (num_nonnulls(signature_id, user_id, request_id_pattern) = 1)
+);
+CREATE INDEX legal_holds_active ON legal_holds (tenant_id) WHERE lifted_at IS NULL;
+
+-- held_signatures lists signatures under an active hold
+CREATE VIEW held_signatures AS
+SELECT DISTINCT signatures.id FROM signatures JOIN legal_holds
+ON legal_holds.lifted_at IS NULL AND legal_holds.tenant_id = signatures.tenant_id
+AND (
+    legal_holds.signature_id = signatures.id
+    OR legal_holds.user_id = signatures.user_id
+    OR signatures.request_id LIKE legal_holds.request_id_pattern
+);
+
+COMMIT;
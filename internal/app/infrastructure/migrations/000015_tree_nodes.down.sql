@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE tree_nodes;
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- hashes of perfect subtrees of the transparency log: a node of a level
+-- covers leaves [node_index * 2^level, (node_index + 1) * 2^level)
+CREATE TABLE tree_nodes(
+    level smallint NOT NULL CHECK (level >= 0 AND level < 64),
+    node_index bigint NOT NULL CHECK (node_index >= 0),
+    hash bytea NOT NULL,
+    PRIMARY KEY (level, node_index)
+);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE signatures DROP COLUMN answers_keyed;
+
+COMMIT;
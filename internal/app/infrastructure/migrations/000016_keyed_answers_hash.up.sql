@@ -0,0 +1,7 @@
+BEGIN;
+
+-- answers_hash of a keyed record is an HMAC with a key derived from its
+-- data key; older records keep a plain SHA-256 covered by their record hash
+ALTER TABLE signatures ADD COLUMN answers_keyed boolean NOT NULL DEFAULT false;
+
+COMMIT;
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditCollection is append-only: the audit_log table rejects updates
+// and deletes.
+type AuditCollection struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewAuditCollection(dbPool *pgxpool.Pool) *AuditCollection {
+	return &AuditCollection{dbPool}
+}
+
+func (r *AuditCollection) Add(ctx context.Context, record AuditRecord) error {
+	return addAuditRecord(ctx, r.dbPool, record)
+}
+
+// execer runs a statement with a pool or within a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// addAuditRecord inserts a record, a transaction of a change commits
+// the change and its record together.
+func addAuditRecord(ctx context.Context, db execer, record AuditRecord) error {
+	insertQuery := `INSERT INTO audit_log (occurred_at, action, actor,
+	signature_id, user_id, outcome, client_ip, request_id, details, tenant_id)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''), NULLIF($8, ''), $9, $10);`
+	_, err := db.Exec(
+		ctx,
+		insertQuery,
+		record.OccurredAt,
+		record.Action,
+		record.Actor,
+		record.SignatureID,
+		record.UserID,
+		record.Outcome,
+		record.ClientIP,
+		record.RequestID,
+		record.Details,
+		record.TenantID,
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not add an audit record", "action", record.Action, "error", err)
+		return errors.Join(ErrInsertFailed, err)
+	}
+	return nil
+}
+
+func (r *AuditCollection) Query(ctx context.Context, spec Specification) ([]AuditRecord, error) {
+	query, queryArgs := spec.ToSQL()
+	rows, err := r.dbPool.Query(ctx, query, pgx.NamedArgs(queryArgs))
+	if err != nil {
+		slog.ErrorContext(ctx, "a query error", "query", query, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	records := []AuditRecord{}
+	for rows.Next() {
+		var record AuditRecord
+		var userID, clientIP, requestID *string
+		if err := rows.Scan(
+			&record.ID,
+			&record.OccurredAt,
+			&record.Action,
+			&record.Actor,
+			&record.SignatureID,
+			&userID,
+			&record.Outcome,
+			&clientIP,
+			&requestID,
+			&record.Details,
+			&record.TenantID,
+		); err != nil {
+			slog.ErrorContext(ctx, "can not scan an audit record", "query", query, "error", err)
+			return nil, err
+		}
+		record.UserID = valueOrEmpty(userID)
+		record.ClientIP = valueOrEmpty(clientIP)
+		record.RequestID = valueOrEmpty(requestID)
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+)
+
+const (
+	chainHashDomain = "test-signer/signature-chain/v1"
+	// tenantChainHashDomain also covers a tenant of a record
+	tenantChainHashDomain = "test-signer/signature-chain/v2"
+)
+
+// GenesisHash is a previous hash of the first chain record.
+var GenesisHash = make([]byte, sha256.Size)
+
+// AnswersHash commits to test answers in their insertion order. It is
+// an HMAC with a commitment key of a signature, so short answers can not be
+// guessed from a hash, and not at all once a data key is destroyed.
+// Records created before keyed commitments have a nil key.
+func AnswersHash(commitmentKey []byte, answers []TestDetails) []byte {
+	h := sha256.New()
+	if commitmentKey != nil {
+		h = hmac.New(sha256.New, commitmentKey)
+	}
+	for _, answer := range answers {
+		writeHashField(h, []byte(answer.Question))
+		writeHashField(h, []byte(answer.Answer))
+	}
+	return h.Sum(nil)
+}
+
+// RecordHash links a signature record to a previous one. It covers answers
+// through AnswersHash, so a chain stays verifiable without answer content.
+// Records of a default tenant keep the first hash version.
+func RecordHash(signature Signature) []byte {
+	h := sha256.New()
+	if signature.TenantID == "" {
+		writeHashField(h, []byte(chainHashDomain))
+	} else {
+		writeHashField(h, []byte(tenantChainHashDomain))
+		writeHashField(h, []byte(signature.TenantID))
+	}
+	binary.Write(h, binary.BigEndian, signature.ChainSeq)
+	writeHashField(h, signature.PrevHash)
+	writeHashField(h, signature.ID[:])
+	writeHashField(h, []byte(signature.RequestID))
+	writeHashField(h, []byte(signature.UserID))
+	writeHashField(h, []byte(signature.Issuer))
+	binary.Write(h, binary.BigEndian, signature.CreatedAt.UnixMicro())
+	writeHashField(h, signature.AnswersHash)
+	return h.Sum(nil)
+}
+
+func writeHashField(h hash.Hash, field []byte) {
+	binary.Write(h, binary.BigEndian, uint32(len(field)))
+	h.Write(field)
+}
@@ -10,4 +10,8 @@ var (
 	ErrUpdateFailed   = errors.New("update failed")
 	ErrDeleteFailed   = errors.New("delete failed")
 	ErrNotImplemented = errors.New("not implemented")
+	ErrSchemaVersion  = errors.New("an unexpected schema version")
+	ErrNoMasterKey    = errors.New("no master key to encrypt answers")
+	ErrLocked         = errors.New("a lock is held by another process")
+	ErrLegalHold      = errors.New("records are under a legal hold")
 )
@@ -1,12 +1,96 @@
 package repositories
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type SignatureRepository interface {
 	Add(context.Context, Signature) (*Signature, error)
 	Query(context.Context, Specification) ([]Signature, error)
 }
 
+type DataKeyRepository interface {
+	// RewrapDataKeys wraps a batch of data keys with a current master key
+	// and returns a number of rewrapped keys.
+	RewrapDataKeys(context.Context, int) (int64, error)
+}
+
+type ErasureRepository interface {
+	// EraseSignatures destroys data keys and answers of signatures of a user
+	// in a tenant and returns IDs of erased signatures. An empty issuer
+	// matches any issuer. It returns ErrLegalHold if any of them is held.
+	// An audit record listing erased signatures is committed with them.
+	EraseSignatures(
+		ctx context.Context,
+		tenantID, userID, issuer string,
+		erasedAt time.Time,
+		record AuditRecord,
+	) ([]uuid.UUID, error)
+}
+
+type RetentionRepository interface {
+	// PurgeSignatures purges a batch of expired signatures selected
+	// by a specification and returns their IDs. It returns ErrLegalHold
+	// if any of them is held.
+	PurgeSignatures(context.Context, Specification, PurgeOptions) ([]uuid.UUID, error)
+}
+
+// LegalHoldRepository commits a change of a hold and its audit record
+// in one transaction.
+type LegalHoldRepository interface {
+	Add(context.Context, LegalHold, AuditRecord) (*LegalHold, error)
+	Query(context.Context, Specification) ([]LegalHold, error)
+	// Lift lifts an active hold of a tenant, a nil tenant matches any tenant.
+	Lift(
+		ctx context.Context,
+		id uuid.UUID,
+		tenantID *string,
+		liftedBy string,
+		liftedAt time.Time,
+		record AuditRecord,
+	) (*LegalHold, error)
+}
+
+// VerifierRepository commits a change of a verifier and its audit record
+// in one transaction.
+type VerifierRepository interface {
+	Add(context.Context, Verifier, AuditRecord) (*Verifier, error)
+	Query(context.Context, Specification) ([]Verifier, error)
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time, record AuditRecord) error
+}
+
+type AuditRepository interface {
+	Add(context.Context, AuditRecord) error
+	Query(context.Context, Specification) ([]AuditRecord, error)
+}
+
+type TransparencyLogRepository interface {
+	LeafCount(context.Context) (int64, error)
+	// LeafData returns record hashes of chain records after a chain
+	// sequence up to a size.
+	LeafData(ctx context.Context, afterSeq, size int64) ([][]byte, error)
+	// HashedLeafCount returns a number of leaves whose subtree hashes are stored.
+	HashedLeafCount(context.Context) (int64, error)
+	// AddTreeNodes stores subtree hashes, already stored ones are kept.
+	AddTreeNodes(context.Context, []TreeNode) error
+	// TreeNodes returns stored nodes with a level and an index of given nodes.
+	TreeNodes(context.Context, []TreeNode) ([]TreeNode, error)
+	AddTreeHead(context.Context, TreeHead) error
+	// TreeHead returns a head of a given size or the latest head for size -1.
+	TreeHead(context.Context, int64) (*TreeHead, error)
+}
+
 type Specification interface {
 	ToSQL() (string, map[string]any)
 }
+
+type RateLimitRepository interface {
+	// UpdateBucket saves a bucket returned by an update function.
+	// Concurrent updates of a bucket are serialized.
+	UpdateBucket(context.Context, string, func(RateLimitBucket) RateLimitBucket) error
+	// DeleteBuckets deletes buckets which are not updated since a time.
+	DeleteBuckets(context.Context, time.Time) (int64, error)
+}
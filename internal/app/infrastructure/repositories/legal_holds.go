@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LegalHoldCollection stores holds. Lifted holds are kept as a history.
+type LegalHoldCollection struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewLegalHoldCollection(dbPool *pgxpool.Pool) *LegalHoldCollection {
+	return &LegalHoldCollection{dbPool}
+}
+
+// Add places a hold and stores its audit record in one transaction.
+// A held signature must belong to a tenant of a hold, otherwise it
+// returns ErrNoDependency.
+func (r *LegalHoldCollection) Add(ctx context.Context, hold LegalHold, record AuditRecord) (*LegalHold, error) {
+	transaction, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not begin a transaction", "error", err)
+		return nil, err
+	}
+	defer func() {
+		err := transaction.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "can not finish a transaction", "hold_id", hold.ID, "error", err)
+		}
+	}()
+	insertQuery := `INSERT INTO legal_holds (id, tenant_id, signature_id, user_id,
+	request_id_pattern, reason, placed_by, placed_at)
+	SELECT @id::uuid, @tenant_id::varchar, @signature_id::uuid, @user_id::varchar,
+	@request_id_pattern::varchar, @reason::varchar, @placed_by::varchar,
+	@placed_at::timestamptz
+	WHERE @signature_id::uuid IS NULL OR EXISTS (
+		SELECT 1 FROM signatures WHERE id = @signature_id AND tenant_id = @tenant_id
+	)
+	RETURNING ` + legalHoldColumns + `;`
+	rows, err := transaction.Query(ctx, insertQuery, pgx.NamedArgs{
+		"id":                 hold.ID,
+		"tenant_id":          hold.TenantID,
+		"signature_id":       hold.SignatureID,
+		"user_id":            hold.UserID,
+		"request_id_pattern": hold.RequestIDPattern,
+		"reason":             hold.Reason,
+		"placed_by":          hold.PlacedBy,
+		"placed_at":          hold.PlacedAt,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "can not place a legal hold", "error", err)
+		return nil, errors.Join(ErrInsertFailed, err)
+	}
+	savedHold, err := pgx.CollectOneRow(rows, scanLegalHold)
+	if errors.Is(err, pgx.ErrNoRows) {
+		slog.InfoContext(ctx, "a held signature does not exist", "signature_id", hold.SignatureID)
+		return nil, ErrNoDependency
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "can not place a legal hold", "error", err)
+		return nil, errors.Join(ErrInsertFailed, err)
+	}
+	if err := addAuditRecord(ctx, transaction, record); err != nil {
+		return nil, err
+	}
+	if err := transaction.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "can not commit a transaction", "hold_id", hold.ID, "error", err)
+		return nil, err
+	}
+	return &savedHold, nil
+}
+
+func (r *LegalHoldCollection) Query(ctx context.Context, spec Specification) ([]LegalHold, error) {
+	query, queryArgs := spec.ToSQL()
+	rows, err := r.dbPool.Query(ctx, query, pgx.NamedArgs(queryArgs))
+	if err != nil {
+		slog.ErrorContext(ctx, "a query error", "query", query, "error", err)
+		return nil, err
+	}
+	holds, err := pgx.CollectRows(rows, scanLegalHold)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not scan a legal hold", "query", query, "error", err)
+		return nil, err
+	}
+	return holds, nil
+}
+
+// Lift lifts a hold and stores its audit record in one transaction.
+// The record is completed with a target of the lifted hold.
+func (r *LegalHoldCollection) Lift(
+	ctx context.Context,
+	id uuid.UUID,
+	tenantID *string,
+	liftedBy string,
+	liftedAt time.Time,
+	record AuditRecord,
+) (*LegalHold, error) {
+	transaction, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not begin a transaction", "error", err)
+		return nil, err
+	}
+	defer func() {
+		err := transaction.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "can not finish a transaction", "hold_id", id, "error", err)
+		}
+	}()
+	query := `UPDATE legal_holds SET lifted_by = @lifted_by, lifted_at = @lifted_at
+	WHERE id = @id AND lifted_at IS NULL
+	AND (@tenant_id::varchar IS NULL OR tenant_id = @tenant_id)
+	RETURNING ` + legalHoldColumns + `;`
+	rows, err := transaction.Query(ctx, query, pgx.NamedArgs{
+		"id":        id,
+		"tenant_id": tenantID,
+		"lifted_by": liftedBy,
+		"lifted_at": liftedAt,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "can not lift a legal hold", "hold_id", id, "error", err)
+		return nil, errors.Join(ErrUpdateFailed, err)
+	}
+	hold, err := pgx.CollectOneRow(rows, scanLegalHold)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "can not lift a legal hold", "hold_id", id, "error", err)
+		return nil, errors.Join(ErrUpdateFailed, err)
+	}
+	if err := addAuditRecord(ctx, transaction, hold.auditRecord(record)); err != nil {
+		return nil, err
+	}
+	if err := transaction.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "can not commit a transaction", "hold_id", id, "error", err)
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// auditRecord names a target of a hold in a record of its change.
+func (h LegalHold) auditRecord(record AuditRecord) AuditRecord {
+	record.SignatureID = h.SignatureID
+	record.UserID = derefString(h.UserID)
+	details := map[string]any{
+		"tenant_id":          h.TenantID,
+		"request_id_pattern": derefString(h.RequestIDPattern),
+	}
+	for key, value := range record.Details {
+		details[key] = value
+	}
+	record.Details = details
+	return record
+}
+
+const legalHoldColumns = `id, tenant_id, signature_id, user_id, request_id_pattern,
+	reason, placed_by, placed_at, lifted_by, lifted_at`
+
+func scanLegalHold(row pgx.CollectableRow) (LegalHold, error) {
+	var hold LegalHold
+	err := row.Scan(
+		&hold.ID,
+		&hold.TenantID,
+		&hold.SignatureID,
+		&hold.UserID,
+		&hold.RequestIDPattern,
+		&hold.Reason,
+		&hold.PlacedBy,
+		&hold.PlacedAt,
+		&hold.LiftedBy,
+		&hold.LiftedAt,
+	)
+	return hold, err
+}
+
+// lockLegalHolds blocks placing and lifting holds until a transaction ends,
+// so holds checked by refuseHeld stay in force until a change is committed.
+// A destructive operation locks holds before rows of signatures.
+func lockLegalHolds(ctx context.Context, transaction pgx.Tx) error {
+	if _, err := transaction.Exec(ctx, "LOCK TABLE legal_holds IN SHARE MODE;"); err != nil {
+		slog.ErrorContext(ctx, "can not lock legal holds", "error", err)
+		return err
+	}
+	return nil
+}
+
+// refuseHeld returns ErrLegalHold if any of signatures is under an active hold.
+func refuseHeld(ctx context.Context, transaction pgx.Tx, signatureIDs []uuid.UUID) error {
+	query := `SELECT id FROM held_signatures WHERE id = ANY($1);`
+	rows, err := transaction.Query(ctx, query, signatureIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not check legal holds", "error", err)
+		return err
+	}
+	heldIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		slog.ErrorContext(ctx, "can not check legal holds", "error", err)
+		return err
+	}
+	if len(heldIDs) > 0 {
+		slog.WarnContext(ctx, "held signatures are not changed", "signature_ids", heldIDs)
+		return fmt.Errorf("%w: %d signatures", ErrLegalHold, len(heldIDs))
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func placeTestHold(t *testing.T, holds *LegalHoldCollection, hold LegalHold) LegalHold {
+	t.Helper()
+	hold.ID = uuid.New()
+	hold.Reason = "litigation"
+	hold.PlacedBy = "test"
+	hold.PlacedAt = time.Now()
+	savedHold, err := holds.Add(context.Background(), hold, testAuditRecord("legal_hold.place"))
+	if err != nil {
+		t.Fatalf("can not place a hold: %v", err)
+	}
+	return *savedHold
+}
+
+func heldSignatures(t *testing.T, dbPool *pgxpool.Pool) map[uuid.UUID]bool {
+	t.Helper()
+	rows, err := dbPool.Query(context.Background(), "SELECT id FROM held_signatures;")
+	if err != nil {
+		t.Fatalf("can not query held signatures: %v", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		t.Fatalf("can not scan held signatures: %v", err)
+	}
+	held := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		held[id] = true
+	}
+	return held
+}
+
+func TestHeldSignatures(t *testing.T) {
+	ctx := context.Background()
+	dbPool := newTestPool(t)
+	signatures := newTestSignatureCollection(t, dbPool)
+	holds := NewLegalHoldCollection(dbPool)
+	now := time.Now()
+	bySignature := addTestSignature(t, signatures, "acme", "u1", "quiz-1", now)
+	byUser := addTestSignature(t, signatures, "acme", "u2", "quiz-2", now)
+	byPattern := addTestSignature(t, signatures, "acme", "u3", "exam-2020-1", now)
+	free := addTestSignature(t, signatures, "acme", "u3", "quiz-3", now)
+	otherUser := addTestSignature(t, signatures, "other", "u2", "quiz-4", now)
+	otherPattern := addTestSignature(t, signatures, "other", "u4", "exam-2020-2", now)
+
+	userID, pattern := "u2", "exam-2020-%"
+	placeTestHold(t, holds, LegalHold{TenantID: "acme", SignatureID: &bySignature})
+	userHold := placeTestHold(t, holds, LegalHold{TenantID: "acme", UserID: &userID})
+	placeTestHold(t, holds, LegalHold{TenantID: "acme", RequestIDPattern: &pattern})
+	if count := countAuditRecords(t, dbPool, "legal_hold.place"); count != 3 {
+		t.Errorf("holds are placed with %d audit records", count)
+	}
+
+	held := heldSignatures(t, dbPool)
+	expected := map[string]struct {
+		id   uuid.UUID
+		held bool
+	}{
+		"by a signature ID":           {bySignature, true},
+		"by a user ID":                {byUser, true},
+		"by a request ID pattern":     {byPattern, true},
+		"not matched":                 {free, false},
+		"a user of another tenant":    {otherUser, false},
+		"a pattern of another tenant": {otherPattern, false},
+	}
+	for name, signature := range expected {
+		if held[signature.id] != signature.held {
+			t.Errorf("%s: held is %v, expected %v", name, held[signature.id], signature.held)
+		}
+	}
+
+	otherTenant := "other"
+	_, err := holds.Lift(ctx, userHold.ID, &otherTenant, "test", now, testAuditRecord("legal_hold.lift"))
+	if !errors.Is(err, ErrNotExist) {
+		t.Errorf("a hold is lifted by another tenant: %v", err)
+	}
+	liftedHold, err := holds.Lift(ctx, userHold.ID, nil, "test", now, testAuditRecord("legal_hold.lift"))
+	if err != nil {
+		t.Fatalf("can not lift a hold: %v", err)
+	}
+	if liftedHold.LiftedAt == nil {
+		t.Error("a lifted hold has no lifting time")
+	}
+	if heldSignatures(t, dbPool)[byUser] {
+		t.Error("a lifted hold still holds a signature")
+	}
+	if count := countAuditRecords(t, dbPool, "legal_hold.lift"); count != 1 {
+		t.Errorf("a hold is lifted with %d audit records", count)
+	}
+}
+
+func TestPlaceHoldOfAnotherTenant(t *testing.T) {
+	dbPool := newTestPool(t)
+	signatures := newTestSignatureCollection(t, dbPool)
+	holds := NewLegalHoldCollection(dbPool)
+	signatureID := addTestSignature(t, signatures, "other", "u1", "quiz-1", time.Now())
+	hold := LegalHold{
+		ID:          uuid.New(),
+		TenantID:    "acme",
+		SignatureID: &signatureID,
+		Reason:      "litigation",
+		PlacedBy:    "test",
+		PlacedAt:    time.Now(),
+	}
+	_, err := holds.Add(context.Background(), hold, testAuditRecord("legal_hold.place"))
+	if !errors.Is(err, ErrNoDependency) {
+		t.Errorf("a hold of a signature of another tenant: %v", err)
+	}
+	if count := countAuditRecords(t, dbPool, "legal_hold.place"); count != 0 {
+		t.Errorf("a failed hold is committed with %d audit records", count)
+	}
+}
+
+func TestEraseHeldSignatures(t *testing.T) {
+	ctx := context.Background()
+	dbPool := newTestPool(t)
+	signatures := newTestSignatureCollection(t, dbPool)
+	holds := NewLegalHoldCollection(dbPool)
+	signatureID := addTestSignature(t, signatures, "acme", "u1", "quiz-1", time.Now())
+	addTestSignature(t, signatures, "acme", "u1", "quiz-2", time.Now())
+	hold := placeTestHold(t, holds, LegalHold{TenantID: "acme", SignatureID: &signatureID})
+
+	_, err := signatures.EraseSignatures(ctx, "acme", "u1", "", time.Now(), testAuditRecord("user.erase"))
+	if !errors.Is(err, ErrLegalHold) {
+		t.Fatalf("a held user is erased: %v", err)
+	}
+	var erased int
+	query := "SELECT count(*) FROM signatures WHERE user_id = 'u1' AND erased_at IS NOT NULL;"
+	if err := dbPool.QueryRow(ctx, query).Scan(&erased); err != nil {
+		t.Fatalf("can not count erased signatures: %v", err)
+	}
+	if erased != 0 {
+		t.Errorf("%d signatures of a held user are erased", erased)
+	}
+
+	if _, err := holds.Lift(ctx, hold.ID, nil, "test", time.Now(), testAuditRecord("legal_hold.lift")); err != nil {
+		t.Fatalf("can not lift a hold: %v", err)
+	}
+	erasedIDs, err := signatures.EraseSignatures(ctx, "acme", "u1", "", time.Now(), testAuditRecord("user.erase"))
+	if err != nil {
+		t.Fatalf("can not erase a user after a hold is lifted: %v", err)
+	}
+	if len(erasedIDs) != 2 {
+		t.Errorf("unexpected erased signatures: %v", erasedIDs)
+	}
+}
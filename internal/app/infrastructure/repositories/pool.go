@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/AndreyAD1/test-signer/internal/app/logging"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func NewPool(ctx context.Context, dbURL string) (*pgxpool.Pool, error) {
+	dbPool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"unable to create a connection pool: DB URL '%s': %w",
+			logging.RedactURL(dbURL),
+			err,
+		)
+	}
+	if err := dbPool.Ping(ctx); err != nil {
+		slog.ErrorContext(
+			ctx,
+			"unable to connect to the DB",
+			"url", logging.RedactURL(dbURL),
+			"error", err,
+		)
+		dbPool.Close()
+		return nil, err
+	}
+	return dbPool, nil
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/internal/app/envelope"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestPool connects to TEST_DATABASE_URL and migrates a fresh schema
+// which is dropped after a test. Tests are skipped without a database.
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("can not connect to a database: %v", err)
+	}
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("can not create a schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("can not drop a schema: %v", err)
+		}
+		conn.Close(ctx)
+	})
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		t.Fatalf("can not parse TEST_DATABASE_URL: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	dbPool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("can not create a pool: %v", err)
+	}
+	t.Cleanup(dbPool.Close)
+	migrations, err := filepath.Glob("../migrations/*.up.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("no migrations: %v", err)
+	}
+	sort.Strings(migrations)
+	for _, migration := range migrations {
+		statements, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatalf("can not read a migration: %v", err)
+		}
+		if _, err := dbPool.Exec(ctx, string(statements)); err != nil {
+			t.Fatalf("can not apply %s: %v", filepath.Base(migration), err)
+		}
+	}
+	return dbPool
+}
+
+func newTestSignatureCollection(t *testing.T, dbPool *pgxpool.Pool) *SignatureCollection {
+	t.Helper()
+	masterKeys, err := envelope.NewMasterKeys(strings.Repeat("ab", 32), nil)
+	if err != nil {
+		t.Fatalf("can not create master keys: %v", err)
+	}
+	return NewSignatureCollection(dbPool, masterKeys)
+}
+
+func addTestSignature(
+	t *testing.T,
+	collection *SignatureCollection,
+	tenantID string,
+	userID string,
+	requestID string,
+	createdAt time.Time,
+) uuid.UUID {
+	t.Helper()
+	signature := Signature{
+		ID:        uuid.New(),
+		RequestID: requestID,
+		UserID:    userID,
+		Issuer:    "test",
+		CreatedAt: createdAt,
+		Answers:   []TestDetails{{Question: "q1", Answer: "a1"}},
+		TenantID:  tenantID,
+	}
+	if _, err := collection.Add(context.Background(), signature); err != nil {
+		t.Fatalf("can not add a signature: %v", err)
+	}
+	return signature.ID
+}
+
+func testAuditRecord(action string) AuditRecord {
+	return AuditRecord{
+		OccurredAt: time.Now(),
+		Action:     action,
+		Actor:      "test",
+		Outcome:    "success",
+		Details:    map[string]any{},
+	}
+}
+
+func countAuditRecords(t *testing.T, dbPool *pgxpool.Pool, action string) int {
+	t.Helper()
+	var count int
+	query := "SELECT count(*) FROM audit_log WHERE action = $1;"
+	if err := dbPool.QueryRow(context.Background(), query, action).Scan(&count); err != nil {
+		t.Fatalf("can not count audit records: %v", err)
+	}
+	return count
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RateLimitCollection struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewRateLimitCollection(dbPool *pgxpool.Pool) *RateLimitCollection {
+	return &RateLimitCollection{dbPool}
+}
+
+// UpdateBucket locks a bucket row, so replicas update a bucket one by one.
+// An empty row is created first, because a missing row can not be locked.
+func (r *RateLimitCollection) UpdateBucket(
+	ctx context.Context,
+	key string,
+	update func(RateLimitBucket) RateLimitBucket,
+) error {
+	transaction, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not begin a transaction", "error", err)
+		return err
+	}
+	defer func() {
+		err := transaction.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "can not finish a transaction", "key", key, "error", err)
+		}
+	}()
+	insertQuery := `INSERT INTO rate_limit_buckets (key) VALUES ($1)
+	ON CONFLICT (key) DO NOTHING;`
+	if _, err := transaction.Exec(ctx, insertQuery, key); err != nil {
+		slog.ErrorContext(ctx, "can not create a rate limit bucket", "key", key, "error", err)
+		return errors.Join(ErrInsertFailed, err)
+	}
+	var tokens *float64
+	var updatedAt *time.Time
+	selectQuery := `SELECT tokens, updated_at FROM rate_limit_buckets
+	WHERE key = $1 FOR UPDATE;`
+	if err := transaction.QueryRow(ctx, selectQuery, key).Scan(&tokens, &updatedAt); err != nil {
+		slog.ErrorContext(ctx, "can not lock a rate limit bucket", "key", key, "error", err)
+		return err
+	}
+	bucket := RateLimitBucket{Key: key}
+	if tokens != nil && updatedAt != nil {
+		bucket.Tokens, bucket.UpdatedAt = *tokens, *updatedAt
+	}
+	bucket = update(bucket)
+	updateQuery := `UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3
+	WHERE key = $1;`
+	if _, err := transaction.Exec(ctx, updateQuery, key, bucket.Tokens, bucket.UpdatedAt); err != nil {
+		slog.ErrorContext(ctx, "can not update a rate limit bucket", "key", key, "error", err)
+		return errors.Join(ErrUpdateFailed, err)
+	}
+	if err := transaction.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "can not commit a transaction", "key", key, "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *RateLimitCollection) DeleteBuckets(ctx context.Context, updatedBefore time.Time) (int64, error) {
+	query := `DELETE FROM rate_limit_buckets WHERE updated_at < $1;`
+	tag, err := r.dbPool.Exec(ctx, query, updatedBefore)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not delete rate limit buckets", "error", err)
+		return 0, errors.Join(ErrDeleteFailed, err)
+	}
+	return tag.RowsAffected(), nil
+}
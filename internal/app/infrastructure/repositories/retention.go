@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/AndreyAD1/test-signer/internal/app/tracing"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// retentionLockKey is an advisory lock which lets one replica
+// purge a batch at a time.
+const retentionLockKey = 0x72657461
+
+// RetentionCollection removes content of expired signatures. Signature
+// rows stay as tombstones, so a hash chain and a transparency log stay intact.
+type RetentionCollection struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewRetentionCollection(dbPool *pgxpool.Pool) *RetentionCollection {
+	return &RetentionCollection{dbPool}
+}
+
+// PurgeSignatures purges a batch of signatures selected by a specification
+// and returns their IDs. A dry run changes nothing. It returns ErrLocked
+// if another replica purges a batch and ErrLegalHold if a specification
+// selects held signatures.
+func (r *RetentionCollection) PurgeSignatures(
+	ctx context.Context,
+	spec Specification,
+	options PurgeOptions,
+) (_ []uuid.UUID, err error) {
+	ctx, span := startSpan(ctx, "RetentionCollection.PurgeSignatures", "UPDATE")
+	defer func() { tracing.End(span, err) }()
+	transaction, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not begin a transaction", "error", err)
+		return nil, err
+	}
+	defer func() {
+		err := transaction.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "can not finish a transaction", "error", err)
+		}
+	}()
+	var locked bool
+	lockQuery := "SELECT pg_try_advisory_xact_lock($1);"
+	if err := transaction.QueryRow(ctx, lockQuery, retentionLockKey).Scan(&locked); err != nil {
+		slog.ErrorContext(ctx, "can not lock retention", "error", err)
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrLocked
+	}
+	if err := lockLegalHolds(ctx, transaction); err != nil {
+		return nil, err
+	}
+	query, queryArgs := spec.ToSQL()
+	rows, err := transaction.Query(ctx, query, pgx.NamedArgs(queryArgs))
+	if err != nil {
+		slog.ErrorContext(ctx, "a query error", "query", query, "error", err)
+		return nil, err
+	}
+	signatureIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		slog.ErrorContext(ctx, "can not scan expired signatures", "query", query, "error", err)
+		return nil, err
+	}
+	if err := refuseHeld(ctx, transaction, signatureIDs); err != nil {
+		return nil, err
+	}
+	if options.DryRun || len(signatureIDs) == 0 {
+		return signatureIDs, nil
+	}
+	if options.Archive {
+		if err := archiveSignatures(ctx, transaction, signatureIDs, options); err != nil {
+			return nil, err
+		}
+	}
+	deleteQuery := `DELETE FROM test_details WHERE signature_id = ANY($1);`
+	if _, err := transaction.Exec(ctx, deleteQuery, signatureIDs); err != nil {
+		slog.ErrorContext(ctx, "can not delete expired answers", "error", err)
+		return nil, errors.Join(ErrDeleteFailed, err)
+	}
+	purgeQuery := `UPDATE signatures
+	SET erased_at = $2, master_key_id = NULL, wrapped_data_key = NULL
+	WHERE id = ANY($1);`
+	if _, err := transaction.Exec(ctx, purgeQuery, signatureIDs, options.PurgedAt); err != nil {
+		slog.ErrorContext(ctx, "can not purge expired signatures", "error", err)
+		return nil, errors.Join(ErrUpdateFailed, err)
+	}
+	if err := transaction.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "can not commit a transaction", "error", err)
+		return nil, err
+	}
+	return signatureIDs, nil
+}
+
+// archiveSignatures copies encrypted answers and wrapped data keys,
+// so archived answers are readable with a master key.
+func archiveSignatures(
+	ctx context.Context,
+	transaction pgx.Tx,
+	signatureIDs []uuid.UUID,
+	options PurgeOptions,
+) error {
+	signaturesQuery := `INSERT INTO archived_signatures
+	(id, tenant_id, user_id, master_key_id, wrapped_data_key, archived_at)
+	SELECT id, tenant_id, user_id, master_key_id, wrapped_data_key, $2
+	FROM signatures WHERE id = ANY($1);`
+	if _, err := transaction.Exec(ctx, signaturesQuery, signatureIDs, options.PurgedAt); err != nil {
+		slog.ErrorContext(ctx, "can not archive signatures", "error", err)
+		return errors.Join(ErrInsertFailed, err)
+	}
+	detailsQuery := `INSERT INTO archived_test_details
+	(id, signature_id, question, answer, sealed_question, sealed_answer)
+	SELECT id, signature_id, question, answer, sealed_question, sealed_answer
+	FROM test_details WHERE signature_id = ANY($1);`
+	if _, err := transaction.Exec(ctx, detailsQuery, signatureIDs); err != nil {
+		slog.ErrorContext(ctx, "can not archive answers", "error", err)
+		return errors.Join(ErrInsertFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
+	"github.com/google/uuid"
+)
+
+func expiredSignatures(
+	t *testing.T,
+	retention *RetentionCollection,
+	rules []specs.ExpiryRule,
+	afterID uuid.UUID,
+	limit int,
+) []uuid.UUID {
+	t.Helper()
+	spec := specs.NewExpiredSignatureSpecification(rules, afterID.String(), limit)
+	signatureIDs, err := retention.PurgeSignatures(context.Background(), spec, PurgeOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("can not select expired signatures: %v", err)
+	}
+	return signatureIDs
+}
+
+func TestExpiredSignaturesMatchFirstRule(t *testing.T) {
+	dbPool := newTestPool(t)
+	signatures := newTestSignatureCollection(t, dbPool)
+	retention := NewRetentionCollection(dbPool)
+	now := time.Now()
+	createdAt := now.AddDate(0, 0, -10)
+	tenantID := "acme"
+	fiveDaysAgo, twentyDaysAgo := now.AddDate(0, 0, -5), now.AddDate(0, 0, -20)
+	rules := []specs.ExpiryRule{
+		{RequestIDPattern: "exam-%"},
+		{TenantID: &tenantID, Before: &fiveDaysAgo},
+		{Before: &twentyDaysAgo},
+	}
+	tests := map[string]struct {
+		id      uuid.UUID
+		expired bool
+	}{
+		"a test rule over a tenant rule": {addTestSignature(t, signatures, "acme", "u1", "exam-1", createdAt), false},
+		"a tenant rule over a default":   {addTestSignature(t, signatures, "acme", "u1", "quiz-1", createdAt), true},
+		"a default":                      {addTestSignature(t, signatures, "other", "u1", "quiz-2", createdAt), false},
+		"a test rule of another tenant":  {addTestSignature(t, signatures, "other", "u1", "exam-2", createdAt), false},
+	}
+	expired := map[uuid.UUID]bool{}
+	for _, id := range expiredSignatures(t, retention, rules, uuid.Nil, 10) {
+		expired[id] = true
+	}
+	for name, test := range tests {
+		if expired[test.id] != test.expired {
+			t.Errorf("%s: expired is %v, expected %v", name, expired[test.id], test.expired)
+		}
+	}
+}
+
+func TestExpiredSignaturesAfterID(t *testing.T) {
+	dbPool := newTestPool(t)
+	signatures := newTestSignatureCollection(t, dbPool)
+	retention := NewRetentionCollection(dbPool)
+	now := time.Now()
+	added := map[uuid.UUID]bool{}
+	for _, requestID := range []string{"quiz-1", "quiz-2", "quiz-3", "quiz-4", "quiz-5"} {
+		added[addTestSignature(t, signatures, "acme", "u1", requestID, now.Add(-time.Hour))] = true
+	}
+	rules := []specs.ExpiryRule{{Before: &now}}
+	selected := map[uuid.UUID]bool{}
+	afterID, batches := uuid.Nil, 0
+	for {
+		batch := expiredSignatures(t, retention, rules, afterID, 2)
+		batches++
+		for _, id := range batch {
+			if selected[id] || id.String() <= afterID.String() {
+				t.Fatalf("a signature %s is selected again after %s", id, afterID)
+			}
+			selected[id] = true
+		}
+		if len(batch) < 2 {
+			break
+		}
+		afterID = batch[len(batch)-1]
+	}
+	if batches != 3 || len(selected) != len(added) {
+		t.Errorf("%d signatures are selected in %d batches", len(selected), batches)
+	}
+}
+
+func TestExpiredSignaturesSkipHeld(t *testing.T) {
+	dbPool := newTestPool(t)
+	signatures := newTestSignatureCollection(t, dbPool)
+	retention := NewRetentionCollection(dbPool)
+	holds := NewLegalHoldCollection(dbPool)
+	now := time.Now()
+	heldID := addTestSignature(t, signatures, "acme", "u1", "quiz-1", now.Add(-time.Hour))
+	freeID := addTestSignature(t, signatures, "acme", "u2", "quiz-1", now.Add(-time.Hour))
+	placeTestHold(t, holds, LegalHold{TenantID: "acme", SignatureID: &heldID})
+
+	spec := specs.NewExpiredSignatureSpecification([]specs.ExpiryRule{{Before: &now}}, uuid.Nil.String(), 10)
+	purgedIDs, err := retention.PurgeSignatures(context.Background(), spec, PurgeOptions{PurgedAt: now})
+	if err != nil {
+		t.Fatalf("can not purge expired signatures: %v", err)
+	}
+	if len(purgedIDs) != 1 || purgedIDs[0] != freeID {
+		t.Errorf("unexpected purged signatures: %v", purgedIDs)
+	}
+}
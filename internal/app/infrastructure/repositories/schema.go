@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SchemaVersion is the latest migration the code depends on.
+const SchemaVersion = 16
+
+// CheckSchemaVersion confirms golang-migrate has applied SchemaVersion
+// or a later migration and left the schema clean.
+func CheckSchemaVersion(ctx context.Context, dbPool *pgxpool.Pool) error {
+	var version int64
+	var dirty bool
+	err := dbPool.QueryRow(
+		ctx,
+		"SELECT version, dirty FROM schema_migrations LIMIT 1;",
+	).Scan(&version, &dirty)
+	if err != nil {
+		return fmt.Errorf("can not read a schema version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("%w: a migration %d is dirty", ErrSchemaVersion, version)
+	}
+	if version < SchemaVersion {
+		return fmt.Errorf(
+			"%w: %d is applied, %d is required",
+			ErrSchemaVersion,
+			version,
+			SchemaVersion,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheckSchemaVersion(t *testing.T) {
+	ctx := context.Background()
+	dbPool := newTestPool(t)
+	if err := CheckSchemaVersion(ctx, dbPool); err == nil {
+		t.Error("a schema without migrations is ready")
+	}
+	query := "CREATE TABLE schema_migrations (version bigint NOT NULL, dirty boolean NOT NULL);"
+	if _, err := dbPool.Exec(ctx, query); err != nil {
+		t.Fatalf("can not create schema_migrations: %v", err)
+	}
+	tests := []struct {
+		name    string
+		version int64
+		dirty   bool
+		ready   bool
+	}{
+		{"a current version", SchemaVersion, false, true},
+		{"a later version", SchemaVersion + 1, false, true},
+		{"an old version", SchemaVersion - 1, false, false},
+		{"a dirty migration", SchemaVersion, true, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := dbPool.Exec(ctx, "DELETE FROM schema_migrations;"); err != nil {
+				t.Fatalf("can not clear schema_migrations: %v", err)
+			}
+			query := "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2);"
+			if _, err := dbPool.Exec(ctx, query, test.version, test.dirty); err != nil {
+				t.Fatalf("can not set a schema version: %v", err)
+			}
+			err := CheckSchemaVersion(ctx, dbPool)
+			if test.ready && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !test.ready && !errors.Is(err, ErrSchemaVersion) {
+				t.Errorf("unexpected error of a schema which is not ready: %v", err)
+			}
+		})
+	}
+}
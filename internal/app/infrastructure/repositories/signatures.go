@@ -2,53 +2,100 @@ package repositories
 
 import (
 	"context"
+	"crypto/cipher"
 	"errors"
-	"fmt"
-	"log"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
+	"github.com/AndreyAD1/test-signer/internal/app/envelope"
+	"github.com/AndreyAD1/test-signer/internal/app/tracing"
+	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories")
+
+// chainLockKey is an advisory lock serializing hash chain appends.
+const chainLockKey = 0x7369676e
+
+// SignatureCollection encrypts answers with a data key of a signature.
+// A data key is stored wrapped by a master key.
 type SignatureCollection struct {
-	dbPool *pgxpool.Pool
+	dbPool     *pgxpool.Pool
+	masterKeys atomic.Pointer[envelope.MasterKeys]
+}
+
+// NewSignatureCollection creates a collection. A collection without
+// master keys can not add signatures and reads encrypted answers as sealed.
+func NewSignatureCollection(dbPool *pgxpool.Pool, masterKeys *envelope.MasterKeys) *SignatureCollection {
+	collection := SignatureCollection{dbPool: dbPool}
+	collection.masterKeys.Store(masterKeys)
+	return &collection
+}
+
+// MasterKeys returns current master keys.
+func (r *SignatureCollection) MasterKeys() *envelope.MasterKeys {
+	return r.masterKeys.Load()
+}
+
+// SetMasterKeys replaces master keys. A data key wrapped by a removed
+// master key can not be unwrapped, so data keys should be rewrapped first.
+func (r *SignatureCollection) SetMasterKeys(masterKeys *envelope.MasterKeys) {
+	r.masterKeys.Store(masterKeys)
 }
 
-func NewSignatureCollection(ctx context.Context, dbURL string) (*SignatureCollection, error) {
-	dbPool, err := pgxpool.New(ctx, dbURL)
+func (r *SignatureCollection) Add(ctx context.Context, signature Signature) (_ *Signature, err error) {
+	ctx, span := startSpan(ctx, "SignatureCollection.Add", "INSERT")
+	defer func() { tracing.End(span, err) }()
+	masterKeys := r.masterKeys.Load()
+	if masterKeys == nil {
+		return nil, ErrNoMasterKey
+	}
+	dataKey, masterKeyID, wrappedDataKey, err := masterKeys.NewDataKey(signature.ID[:])
 	if err != nil {
-		return nil, fmt.Errorf(
-			"unable to create a connection pool: DB URL '%s': %w",
-			dbURL,
-			err,
-		)
+		return nil, err
 	}
-	if err := dbPool.Ping(ctx); err != nil {
-		log.Printf("unable to connect to the DB '%v'", dbURL)
+	dataCipher, err := envelope.DataCipher(dataKey)
+	if err != nil {
 		return nil, err
 	}
-	return &SignatureCollection{dbPool}, nil
-}
-
-func (r *SignatureCollection) Add(ctx context.Context, signature Signature) (*Signature, error) {
+	signature.CommitmentKey = envelope.CommitmentKey(dataKey)
 	transaction, err := r.dbPool.Begin(ctx)
 	if err != nil {
-		log.Println("can not begin a transaction")
+		slog.ErrorContext(ctx, "can not begin a transaction", "error", err)
 		return nil, err
 	}
 	defer func() {
 		err := transaction.Rollback(ctx)
 		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Printf(
-				"can not finish a transaction for a signature '%s'",
-				signature.RequestID,
+			slog.ErrorContext(
+				ctx,
+				"can not finish a transaction",
+				"request_id", signature.RequestID,
+				"error", err,
 			)
 		}
 	}()
-	insertQuery := `INSERT INTO signatures (id, request_id, user_id, created_at)
-	VALUES ($1, $2, $3, $4) RETURNING id, request_id, user_id, created_at;`
+	chainCtx, chainSpan := tracer.Start(ctx, "SignatureCollection.linkToChain")
+	err = r.linkToChain(chainCtx, transaction, &signature)
+	tracing.End(chainSpan, err)
+	if err != nil {
+		return nil, err
+	}
+	insertQuery := `INSERT INTO signatures (id, request_id, user_id, issuer, created_at,
+	chain_seq, prev_hash, answers_hash, record_hash, timestamp_token, timestamped_at,
+	tenant_id, master_key_id, wrapped_data_key, answers_keyed)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, true)
+	RETURNING id, request_id, user_id, issuer, created_at,
+	chain_seq, prev_hash, answers_hash, record_hash, timestamp_token, timestamped_at,
+	tenant_id;`
 	var savedSignature Signature
 	err = transaction.QueryRow(
 		ctx,
@@ -56,114 +103,491 @@ func (r *SignatureCollection) Add(ctx context.Context, signature Signature) (*Si
 		signature.ID,
 		signature.RequestID,
 		signature.UserID,
+		signature.Issuer,
 		signature.CreatedAt,
+		signature.ChainSeq,
+		signature.PrevHash,
+		signature.AnswersHash,
+		signature.RecordHash,
+		signature.TimestampToken,
+		signature.TimestampedAt,
+		signature.TenantID,
+		masterKeyID,
+		wrappedDataKey,
 	).Scan(
 		&savedSignature.ID,
 		&savedSignature.RequestID,
 		&savedSignature.UserID,
+		&savedSignature.Issuer,
 		&savedSignature.CreatedAt,
+		&savedSignature.ChainSeq,
+		&savedSignature.PrevHash,
+		&savedSignature.AnswersHash,
+		&savedSignature.RecordHash,
+		&savedSignature.TimestampToken,
+		&savedSignature.TimestampedAt,
+		&savedSignature.TenantID,
 	)
 	if err != nil {
 		var pgxError *pgconn.PgError
 		if !errors.As(err, &pgxError) {
-			log.Printf("unexpected DB error: %v", err)
+			slog.ErrorContext(ctx, "unexpected DB error", "error", err)
 			return nil, err
 		}
 		if pgxError.Code == pgerrcode.UniqueViolation {
-			log.Printf("the signature already exists: %v", signature.RequestID)
+			slog.InfoContext(ctx, "the signature already exists", "request_id", signature.RequestID)
 			return nil, ErrDuplicate
 		}
 		return nil, err
 	}
-	insertAnswerQuery := `INSERT INTO test_details (signature_id, question, answer)
-	VALUES ($1, $2, $3) RETURNING id, question, answer;`
+	insertAnswerQuery := `INSERT INTO test_details (signature_id, sealed_question, sealed_answer)
+	VALUES ($1, $2, $3) RETURNING id;`
+	answersCtx, answersSpan := tracer.Start(
+		ctx,
+		"SignatureCollection.insertAnswers",
+		trace.WithAttributes(semconv.DBSQLTable("test_details")),
+	)
 	savedAnswers := []TestDetails{}
 	for _, answer := range signature.Answers {
-		var savedTestDetails TestDetails
-		err := transaction.QueryRow(
-			ctx, 
-			insertAnswerQuery, 
-			savedSignature.ID, 
-			answer.Question, 
-			answer.Answer,
-		).Scan(
-			&savedTestDetails.ID,
-			&savedTestDetails.Question,
-			&savedTestDetails.Answer,
-		)
+		sealedQuestion, sealedAnswer, err := sealAnswer(dataCipher, savedSignature.ID, answer)
 		if err != nil {
-			log.Printf("unexpected DB error: %v", err)
+			tracing.End(answersSpan, err)
+			return nil, err
+		}
+		savedTestDetails := answer
+		err = transaction.QueryRow(
+			answersCtx,
+			insertAnswerQuery,
+			savedSignature.ID,
+			sealedQuestion,
+			sealedAnswer,
+		).Scan(&savedTestDetails.ID)
+		if err != nil {
+			tracing.End(answersSpan, err)
+			slog.ErrorContext(ctx, "unexpected DB error", "error", err)
 			return nil, err
 		}
 		savedAnswers = append(savedAnswers, savedTestDetails)
 	}
+	answersSpan.End()
 	savedSignature.Answers = savedAnswers
 	if err := transaction.Commit(ctx); err != nil {
-		log.Printf(
-			"can not close a transaction for the signature %v - %v: %v",
-			signature.RequestID,
-			signature.UserID,
-			err,
+		slog.ErrorContext(
+			ctx,
+			"can not commit a transaction",
+			"request_id", signature.RequestID,
+			"user_id", signature.UserID,
+			"error", err,
 		)
 		return &savedSignature, err
 	}
 	return &savedSignature, err
 }
 
-func (r *SignatureCollection) Query(ctx context.Context, spec Specification) ([]Signature, error) {
+// linkToChain computes chain fields of a new record. It holds a transaction
+// advisory lock, so concurrent appends can not link to the same record.
+func (r *SignatureCollection) linkToChain(ctx context.Context, transaction pgx.Tx, signature *Signature) error {
+	if _, err := transaction.Exec(ctx, "SELECT pg_advisory_xact_lock($1);", chainLockKey); err != nil {
+		slog.ErrorContext(ctx, "can not lock a signature chain", "error", err)
+		return err
+	}
+	lastQuery := `SELECT chain_seq, record_hash FROM signatures
+	WHERE chain_seq IS NOT NULL ORDER BY chain_seq DESC LIMIT 1;`
+	var lastSeq int64
+	var lastHash []byte
+	err := transaction.QueryRow(ctx, lastQuery).Scan(&lastSeq, &lastHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		slog.ErrorContext(ctx, "can not read the last chain record", "error", err)
+		return err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		lastHash = GenesisHash
+	}
+	// the DB keeps microseconds, a hash must cover a stored value
+	signature.CreatedAt = signature.CreatedAt.Truncate(time.Microsecond)
+	signature.ChainSeq = lastSeq + 1
+	signature.PrevHash = lastHash
+	signature.AnswersHash = AnswersHash(signature.CommitmentKey, signature.Answers)
+	signature.RecordHash = RecordHash(*signature)
+	return nil
+}
+
+func (r *SignatureCollection) Query(ctx context.Context, spec Specification) (_ []Signature, err error) {
+	ctx, span := startSpan(ctx, "SignatureCollection.Query", "SELECT")
+	defer func() { tracing.End(span, err) }()
 	query, queryArgs := spec.ToSQL()
 	rows, err := r.dbPool.Query(ctx, query, pgx.NamedArgs(queryArgs))
 	if err != nil {
-		log.Printf("a query error: '%v'", query)
+		slog.ErrorContext(ctx, "a query error", "query", query, "error", err)
 		return nil, err
 	}
 	defer rows.Close()
 	var signatures []Signature
+	var dataKeys []wrappedDataKey
 	for rows.Next() {
 		var signature Signature
+		var dataKey wrappedDataKey
 		if err := rows.Scan(
 			&signature.ID,
 			&signature.RequestID,
 			&signature.UserID,
+			&signature.Issuer,
 			&signature.CreatedAt,
+			&signature.ChainSeq,
+			&signature.PrevHash,
+			&signature.AnswersHash,
+			&signature.RecordHash,
+			&signature.TimestampToken,
+			&signature.TimestampedAt,
+			&signature.TenantID,
+			&dataKey.masterKeyID,
+			&dataKey.wrapped,
+			&signature.ErasedAt,
+			&dataKey.keyed,
 		); err != nil {
-			log.Printf(
-				"can not scan a signature from a query result: %v: %v",
-				query,
-				queryArgs,
+			slog.ErrorContext(
+				ctx,
+				"can not scan a signature",
+				"query", query,
+				"error", err,
 			)
 			return nil, err
 		}
 		signatures = append(signatures, signature)
+		dataKeys = append(dataKeys, dataKey)
 	}
-	detailsQuery := `SELECT id, question, answer FROM test_details 
-	WHERE signature_id = $1;`
+	detailsQuery := `SELECT id, question, answer, sealed_question, sealed_answer
+	FROM test_details WHERE signature_id = $1 ORDER BY id;`
 
+	masterKeys := r.masterKeys.Load()
 	for i, signature := range signatures {
+		var dataCipher cipher.AEAD
+		if dataKeys[i].masterKeyID != nil && masterKeys != nil {
+			dataKey, err := masterKeys.Unwrap(*dataKeys[i].masterKeyID, dataKeys[i].wrapped, signature.ID[:])
+			if err == nil {
+				dataCipher, err = envelope.DataCipher(dataKey)
+			}
+			if err != nil {
+				slog.ErrorContext(
+					ctx,
+					"can not unwrap a data key",
+					"signature_id", signature.ID,
+					"error", err,
+				)
+				return nil, err
+			}
+			if dataKeys[i].keyed {
+				signatures[i].CommitmentKey = envelope.CommitmentKey(dataKey)
+			}
+		}
 		rows, err := r.dbPool.Query(ctx, detailsQuery, signature.ID)
 		if err != nil {
-			log.Printf("a query error: '%v'", query)
+			slog.ErrorContext(ctx, "a query error", "query", detailsQuery, "error", err)
 			return nil, err
 		}
 		defer rows.Close()
 		allTestDetails := []TestDetails{}
 		for rows.Next() {
 			var details TestDetails
+			var question, answer *string
+			var sealedQuestion, sealedAnswer []byte
 			if err := rows.Scan(
 				&details.ID,
-				&details.Question,
-				&details.Answer,
+				&question,
+				&answer,
+				&sealedQuestion,
+				&sealedAnswer,
 			); err != nil {
-				log.Printf(
-					"can not scan test detais from a query result: %v: %v",
-					query,
-					queryArgs,
+				slog.ErrorContext(
+					ctx,
+					"can not scan test details",
+					"query", detailsQuery,
+					"error", err,
 				)
 				return nil, err
 			}
+			switch {
+			case sealedQuestion == nil && sealedAnswer == nil:
+				// answers stored before encryption
+				details.Question, details.Answer = derefString(question), derefString(answer)
+			case dataCipher == nil:
+				signatures[i].AnswersSealed = true
+				continue
+			default:
+				details, err = openAnswer(dataCipher, signature.ID, details.ID, sealedQuestion, sealedAnswer)
+				if err != nil {
+					slog.ErrorContext(
+						ctx,
+						"can not decrypt answers",
+						"signature_id", signature.ID,
+						"error", err,
+					)
+					return nil, err
+				}
+			}
 			allTestDetails = append(allTestDetails, details)
 		}
+		if signatures[i].AnswersSealed {
+			allTestDetails = []TestDetails{}
+		}
 		signatures[i].Answers = allTestDetails
 	}
 	return signatures, nil
 }
+
+// RewrapDataKeys wraps data keys of a batch of signatures and a batch of
+// archived signatures with a current master key. Answers are not
+// re-encrypted. Concurrent calls skip rows locked by each other. Held
+// signatures are rewrapped as well: their content stays readable after
+// a retired master key is removed.
+func (r *SignatureCollection) RewrapDataKeys(ctx context.Context, limit int) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "SignatureCollection.RewrapDataKeys", "UPDATE")
+	defer func() { tracing.End(span, err) }()
+	masterKeys := r.masterKeys.Load()
+	if masterKeys == nil {
+		return 0, ErrNoMasterKey
+	}
+	transaction, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not begin a transaction", "error", err)
+		return 0, err
+	}
+	defer func() {
+		err := transaction.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "can not finish a transaction", "error", err)
+		}
+	}()
+	var rewrapped int64
+	for _, table := range []string{"signatures", "archived_signatures"} {
+		count, err := rewrapTable(ctx, transaction, masterKeys, table, limit)
+		if err != nil {
+			return 0, err
+		}
+		rewrapped += count
+	}
+	if err := transaction.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "can not commit a transaction", "error", err)
+		return 0, err
+	}
+	return rewrapped, nil
+}
+
+func rewrapTable(
+	ctx context.Context,
+	transaction pgx.Tx,
+	masterKeys *envelope.MasterKeys,
+	table string,
+	limit int,
+) (int64, error) {
+	selectQuery := `SELECT id, master_key_id, wrapped_data_key FROM ` + table + `
+	WHERE master_key_id <> $1 ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED;`
+	rows, err := transaction.Query(ctx, selectQuery, masterKeys.CurrentID(), limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "a query error", "query", selectQuery, "error", err)
+		return 0, err
+	}
+	type dataKeyRow struct {
+		signatureID uuid.UUID
+		masterKeyID string
+		wrapped     []byte
+	}
+	var dataKeyRows []dataKeyRow
+	for rows.Next() {
+		var row dataKeyRow
+		if err := rows.Scan(&row.signatureID, &row.masterKeyID, &row.wrapped); err != nil {
+			rows.Close()
+			slog.ErrorContext(ctx, "can not scan a data key", "error", err)
+			return 0, err
+		}
+		dataKeyRows = append(dataKeyRows, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	updateQuery := `UPDATE ` + table + ` SET master_key_id = $2, wrapped_data_key = $3
+	WHERE id = $1;`
+	for _, row := range dataKeyRows {
+		masterKeyID, rewrapped, err := masterKeys.Rewrap(row.masterKeyID, row.wrapped, row.signatureID[:])
+		if err != nil {
+			slog.ErrorContext(
+				ctx,
+				"can not rewrap a data key",
+				"signature_id", row.signatureID,
+				"master_key_id", row.masterKeyID,
+				"error", err,
+			)
+			return 0, err
+		}
+		if _, err := transaction.Exec(ctx, updateQuery, row.signatureID, masterKeyID, rewrapped); err != nil {
+			slog.ErrorContext(ctx, "can not update a data key", "signature_id", row.signatureID, "error", err)
+			return 0, errors.Join(ErrUpdateFailed, err)
+		}
+	}
+	return int64(len(dataKeyRows)), nil
+}
+
+// EraseSignatures crypto-shreds signatures: data keys are destroyed and
+// answers are deleted. Rows stay with their hashes, so a hash chain and
+// issued signatures remain verifiable. Erased signatures are skipped,
+// archived content of their user is deleted as well. Nothing is erased
+// if any signature of a user is under a legal hold. Keys can not be
+// restored, so an erasure is committed only with its audit record.
+func (r *SignatureCollection) EraseSignatures(
+	ctx context.Context,
+	tenantID string,
+	userID string,
+	issuer string,
+	erasedAt time.Time,
+	record AuditRecord,
+) (_ []uuid.UUID, err error) {
+	ctx, span := startSpan(ctx, "SignatureCollection.EraseSignatures", "UPDATE")
+	defer func() { tracing.End(span, err) }()
+	transaction, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not begin a transaction", "error", err)
+		return nil, err
+	}
+	defer func() {
+		err := transaction.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "can not finish a transaction", "user_id", userID, "error", err)
+		}
+	}()
+	if err := lockLegalHolds(ctx, transaction); err != nil {
+		return nil, err
+	}
+	selectQuery := `SELECT id FROM signatures
+	WHERE tenant_id = @tenant_id AND user_id = @user_id
+	AND (@issuer = '' OR issuer = @issuer)
+	AND (erased_at IS NULL OR id IN (SELECT id FROM archived_signatures))
+	FOR UPDATE;`
+	rows, err := transaction.Query(ctx, selectQuery, pgx.NamedArgs{
+		"tenant_id": tenantID,
+		"user_id":   userID,
+		"issuer":    issuer,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "can not select signatures", "user_id", userID, "error", err)
+		return nil, err
+	}
+	userSignatureIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		slog.ErrorContext(ctx, "can not select signatures", "user_id", userID, "error", err)
+		return nil, err
+	}
+	if err := refuseHeld(ctx, transaction, userSignatureIDs); err != nil {
+		return nil, err
+	}
+	eraseQuery := `UPDATE signatures
+	SET erased_at = $2, master_key_id = NULL, wrapped_data_key = NULL
+	WHERE id = ANY($1) AND erased_at IS NULL
+	RETURNING id;`
+	rows, err = transaction.Query(ctx, eraseQuery, userSignatureIDs, erasedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not erase signatures", "user_id", userID, "error", err)
+		return nil, errors.Join(ErrUpdateFailed, err)
+	}
+	signatureIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		slog.ErrorContext(ctx, "can not erase signatures", "user_id", userID, "error", err)
+		return nil, errors.Join(ErrUpdateFailed, err)
+	}
+	deleteQuery := `DELETE FROM test_details WHERE signature_id = ANY($1);`
+	if _, err := transaction.Exec(ctx, deleteQuery, signatureIDs); err != nil {
+		slog.ErrorContext(ctx, "can not delete answers", "user_id", userID, "error", err)
+		return nil, errors.Join(ErrDeleteFailed, err)
+	}
+	// archived answers are deleted with their archived signatures
+	archiveQuery := `DELETE FROM archived_signatures WHERE id = ANY($1) RETURNING id;`
+	rows, err = transaction.Query(ctx, archiveQuery, userSignatureIDs)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not erase archived signatures", "user_id", userID, "error", err)
+		return nil, errors.Join(ErrDeleteFailed, err)
+	}
+	archivedIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		slog.ErrorContext(ctx, "can not erase archived signatures", "user_id", userID, "error", err)
+		return nil, errors.Join(ErrDeleteFailed, err)
+	}
+	signatureIDs = append(signatureIDs, archivedIDs...)
+	erasedIDs := []string{}
+	for _, signatureID := range signatureIDs {
+		erasedIDs = append(erasedIDs, signatureID.String())
+	}
+	details := map[string]any{"signature_ids": erasedIDs}
+	for key, value := range record.Details {
+		details[key] = value
+	}
+	record.Details = details
+	if err := addAuditRecord(ctx, transaction, record); err != nil {
+		return nil, err
+	}
+	if err := transaction.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "can not commit a transaction", "user_id", userID, "error", err)
+		return nil, err
+	}
+	return signatureIDs, nil
+}
+
+// wrappedDataKey is empty for signatures stored before encryption.
+// A keyed data key also keys an answers hash of its signature.
+type wrappedDataKey struct {
+	masterKeyID *string
+	wrapped     []byte
+	keyed       bool
+}
+
+// answerData binds a sealed field to its signature, so a ciphertext
+// can not be moved to another signature or field.
+func answerData(signatureID uuid.UUID, field string) []byte {
+	return append(signatureID[:], field...)
+}
+
+func sealAnswer(dataCipher cipher.AEAD, signatureID uuid.UUID, answer TestDetails) ([]byte, []byte, error) {
+	sealedQuestion, err := envelope.Seal(dataCipher, []byte(answer.Question), answerData(signatureID, "question"))
+	if err != nil {
+		return nil, nil, err
+	}
+	sealedAnswer, err := envelope.Seal(dataCipher, []byte(answer.Answer), answerData(signatureID, "answer"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return sealedQuestion, sealedAnswer, nil
+}
+
+func openAnswer(
+	dataCipher cipher.AEAD,
+	signatureID uuid.UUID,
+	id int,
+	sealedQuestion []byte,
+	sealedAnswer []byte,
+) (TestDetails, error) {
+	question, err := envelope.Open(dataCipher, sealedQuestion, answerData(signatureID, "question"))
+	if err != nil {
+		return TestDetails{}, err
+	}
+	answer, err := envelope.Open(dataCipher, sealedAnswer, answerData(signatureID, "answer"))
+	if err != nil {
+		return TestDetails{}, err
+	}
+	return TestDetails{ID: id, Question: string(question), Answer: string(answer)}, nil
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func startSpan(ctx context.Context, name, operation string) (context.Context, trace.Span) {
+	return tracer.Start(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperation(operation)),
+	)
+}
+
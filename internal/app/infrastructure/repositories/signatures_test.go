@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
+	"github.com/google/uuid"
+)
+
+func queryTestSignature(t *testing.T, collection *SignatureCollection, id uuid.UUID, tenantID string) Signature {
+	t.Helper()
+	spec := specs.NewSignatureSpecificationByID(id.String(), tenantID)
+	signatures, err := collection.Query(context.Background(), spec)
+	if err != nil || len(signatures) != 1 {
+		t.Fatalf("can not find a signature %s: %v", id, err)
+	}
+	return signatures[0]
+}
+
+func TestEraseSignatures(t *testing.T) {
+	ctx := context.Background()
+	dbPool := newTestPool(t)
+	signatures := newTestSignatureCollection(t, dbPool)
+	now := time.Now()
+	erasedID := addTestSignature(t, signatures, "acme", "u1", "quiz-1", now)
+	addTestSignature(t, signatures, "acme", "u1", "quiz-2", now)
+	keptID := addTestSignature(t, signatures, "acme", "u2", "quiz-1", now)
+	stored := queryTestSignature(t, signatures, erasedID, "acme")
+
+	erasedIDs, err := signatures.EraseSignatures(ctx, "acme", "u1", "", now, testAuditRecord("user.erase"))
+	if err != nil {
+		t.Fatalf("can not erase signatures: %v", err)
+	}
+	if len(erasedIDs) != 2 {
+		t.Errorf("unexpected erased signatures: %v", erasedIDs)
+	}
+
+	erased := queryTestSignature(t, signatures, erasedID, "acme")
+	if erased.ErasedAt == nil || len(erased.Answers) != 0 || erased.CommitmentKey != nil {
+		t.Errorf("answers of an erased signature are readable: %+v", erased)
+	}
+	if !bytes.Equal(erased.AnswersHash, stored.AnswersHash) {
+		t.Error("an answers hash of an erased signature is changed")
+	}
+	if !bytes.Equal(RecordHash(erased), erased.RecordHash) || !bytes.Equal(erased.RecordHash, stored.RecordHash) {
+		t.Error("an erased signature does not verify by its record hash")
+	}
+	var wrappedKeys, answers int
+	keyQuery := `SELECT count(*) FROM signatures
+	WHERE user_id = 'u1' AND (wrapped_data_key IS NOT NULL OR master_key_id IS NOT NULL);`
+	if err := dbPool.QueryRow(ctx, keyQuery).Scan(&wrappedKeys); err != nil {
+		t.Fatalf("can not count data keys: %v", err)
+	}
+	answerQuery := `SELECT count(*) FROM test_details WHERE signature_id = ANY($1);`
+	if err := dbPool.QueryRow(ctx, answerQuery, erasedIDs).Scan(&answers); err != nil {
+		t.Fatalf("can not count answers: %v", err)
+	}
+	if wrappedKeys != 0 || answers != 0 {
+		t.Errorf("%d data keys and %d answers of an erased user remain", wrappedKeys, answers)
+	}
+	if kept := queryTestSignature(t, signatures, keptID, "acme"); len(kept.Answers) != 1 {
+		t.Errorf("answers of another user are erased: %+v", kept)
+	}
+
+	var recordedIDs int
+	auditQuery := `SELECT jsonb_array_length(details->'signature_ids') FROM audit_log
+	WHERE action = 'user.erase';`
+	if err := dbPool.QueryRow(ctx, auditQuery).Scan(&recordedIDs); err != nil {
+		t.Fatalf("an erasure has no audit record: %v", err)
+	}
+	if recordedIDs != 2 {
+		t.Errorf("an audit record lists %d erased signatures", recordedIDs)
+	}
+	erasedIDs, err = signatures.EraseSignatures(ctx, "acme", "u1", "", now, testAuditRecord("user.erase"))
+	if err != nil || len(erasedIDs) != 0 {
+		t.Errorf("a repeated erasure erases %v: %v", erasedIDs, err)
+	}
+}
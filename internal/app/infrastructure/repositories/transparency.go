@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TransparencyLogCollection stores tree heads of a log whose leaves are
+// record hashes of the signature chain in chain order.
+type TransparencyLogCollection struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewTransparencyLogCollection(dbPool *pgxpool.Pool) *TransparencyLogCollection {
+	return &TransparencyLogCollection{dbPool}
+}
+
+func (r *TransparencyLogCollection) LeafCount(ctx context.Context) (int64, error) {
+	query := `SELECT COALESCE(MAX(chain_seq), 0) FROM signatures;`
+	var count int64
+	if err := r.dbPool.QueryRow(ctx, query).Scan(&count); err != nil {
+		slog.ErrorContext(ctx, "can not count log leaves", "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *TransparencyLogCollection) LeafData(ctx context.Context, afterSeq, size int64) ([][]byte, error) {
+	query := `SELECT record_hash FROM signatures
+	WHERE chain_seq > $1 AND chain_seq <= $2 ORDER BY chain_seq;`
+	rows, err := r.dbPool.Query(ctx, query, afterSeq, size)
+	if err != nil {
+		slog.ErrorContext(ctx, "a query error", "query", query, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	leaves := make([][]byte, 0, max(size-afterSeq, 0))
+	for rows.Next() {
+		var recordHash []byte
+		if err := rows.Scan(&recordHash); err != nil {
+			slog.ErrorContext(ctx, "can not scan a log leaf", "error", err)
+			return nil, err
+		}
+		leaves = append(leaves, recordHash)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if int64(len(leaves)) != size-afterSeq {
+		slog.ErrorContext(
+			ctx,
+			"unexpected log size",
+			"leaves", len(leaves),
+			"after_seq", afterSeq,
+			"size", size,
+		)
+		return nil, ErrNotExist
+	}
+	return leaves, nil
+}
+
+func (r *TransparencyLogCollection) HashedLeafCount(ctx context.Context) (int64, error) {
+	query := `SELECT COALESCE(MAX(node_index) + 1, 0) FROM tree_nodes WHERE level = 0;`
+	var count int64
+	if err := r.dbPool.QueryRow(ctx, query).Scan(&count); err != nil {
+		slog.ErrorContext(ctx, "can not count hashed log leaves", "error", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// AddTreeNodes inserts a batch of nodes at once, so stored leaves always
+// have their completed subtrees stored as well.
+func (r *TransparencyLogCollection) AddTreeNodes(ctx context.Context, nodes []TreeNode) error {
+	query := `INSERT INTO tree_nodes (level, node_index, hash)
+	SELECT * FROM unnest($1::smallint[], $2::bigint[], $3::bytea[])
+	ON CONFLICT (level, node_index) DO NOTHING;`
+	levels, indexes, hashes := make([]int16, len(nodes)), make([]int64, len(nodes)), make([][]byte, len(nodes))
+	for i, node := range nodes {
+		levels[i], indexes[i], hashes[i] = node.Level, node.Index, node.Hash
+	}
+	if _, err := r.dbPool.Exec(ctx, query, levels, indexes, hashes); err != nil {
+		slog.ErrorContext(ctx, "can not add tree nodes", "count", len(nodes), "error", err)
+		return errors.Join(ErrInsertFailed, err)
+	}
+	return nil
+}
+
+func (r *TransparencyLogCollection) TreeNodes(ctx context.Context, keys []TreeNode) ([]TreeNode, error) {
+	query := `SELECT level, node_index, hash FROM tree_nodes
+	JOIN unnest($1::smallint[], $2::bigint[]) AS requested(level, node_index)
+	USING (level, node_index);`
+	levels, indexes := make([]int16, len(keys)), make([]int64, len(keys))
+	for i, key := range keys {
+		levels[i], indexes[i] = key.Level, key.Index
+	}
+	rows, err := r.dbPool.Query(ctx, query, levels, indexes)
+	if err != nil {
+		slog.ErrorContext(ctx, "a query error", "query", query, "error", err)
+		return nil, err
+	}
+	nodes, err := pgx.CollectRows(rows, pgx.RowToStructByPos[TreeNode])
+	if err != nil {
+		slog.ErrorContext(ctx, "can not scan tree nodes", "error", err)
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func (r *TransparencyLogCollection) AddTreeHead(ctx context.Context, head TreeHead) error {
+	query := `INSERT INTO tree_heads (tree_size, root_hash, created_at, signature)
+	VALUES ($1, $2, $3, $4) ON CONFLICT (tree_size) DO NOTHING;`
+	_, err := r.dbPool.Exec(ctx, query, head.Size, head.RootHash, head.CreatedAt, head.Signature)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not add a tree head", "size", head.Size, "error", err)
+		return errors.Join(ErrInsertFailed, err)
+	}
+	return nil
+}
+
+func (r *TransparencyLogCollection) TreeHead(ctx context.Context, size int64) (*TreeHead, error) {
+	query := `SELECT tree_size, root_hash, created_at, signature FROM tree_heads
+	WHERE $1 < 0 OR tree_size = $1 ORDER BY tree_size DESC LIMIT 1;`
+	var head TreeHead
+	err := r.dbPool.QueryRow(ctx, query, size).Scan(
+		&head.Size,
+		&head.RootHash,
+		&head.CreatedAt,
+		&head.Signature,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "can not read a tree head", "size", size, "error", err)
+		return nil, err
+	}
+	return &head, nil
+}
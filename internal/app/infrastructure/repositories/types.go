@@ -10,8 +10,28 @@ type Signature struct {
 	ID        uuid.UUID
 	RequestID string
 	UserID    string
+	Issuer    string
 	CreatedAt time.Time
 	Answers   []TestDetails
+	// ChainSeq is zero for records created before the hash chain.
+	ChainSeq    int64
+	PrevHash    []byte
+	AnswersHash []byte
+	RecordHash  []byte
+	// TimestampToken is an RFC 3161 token over a hash of an issued signature.
+	TimestampToken []byte
+	TimestampedAt  *time.Time
+	// TenantID is empty for a default tenant.
+	TenantID string
+	// AnswersSealed is true if answers are encrypted and a collection has
+	// no master keys, Answers are empty then.
+	AnswersSealed bool
+	// ErasedAt is set if answers and a data key are destroyed on request
+	// of a user. A signature stays as a tombstone.
+	ErasedAt *time.Time
+	// CommitmentKey keys AnswersHash. It is empty for records created
+	// before keyed commitments and if answers are sealed or erased.
+	CommitmentKey []byte
 }
 
 type TestDetails struct {
@@ -19,3 +39,74 @@ type TestDetails struct {
 	Question string
 	Answer   string
 }
+
+type Verifier struct {
+	ID        uuid.UUID
+	Name      string
+	KeyHash   []byte
+	Scopes    []string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+	// CertIdentity is an identity of a client certificate, see auth.CertificateIdentity.
+	CertIdentity *string
+	TenantID     string
+}
+
+type AuditRecord struct {
+	ID          int64
+	OccurredAt  time.Time
+	Action      string
+	Actor       string
+	SignatureID *uuid.UUID
+	UserID      string
+	Outcome     string
+	ClientIP    string
+	RequestID   string
+	Details     map[string]any
+	TenantID    string
+}
+
+// LegalHold freezes signatures of a tenant selected by exactly one of
+// a signature ID, a user ID or a request ID pattern until it is lifted.
+type LegalHold struct {
+	ID               uuid.UUID
+	TenantID         string
+	SignatureID      *uuid.UUID
+	UserID           *string
+	RequestIDPattern *string
+	Reason           string
+	PlacedBy         string
+	PlacedAt         time.Time
+	LiftedBy         *string
+	LiftedAt         *time.Time
+}
+
+type TreeHead struct {
+	Size      int64
+	RootHash  []byte
+	CreatedAt time.Time
+	Signature []byte
+}
+
+// TreeNode is a hash of a perfect subtree of 2^Level log leaves which
+// starts at the leaf Index * 2^Level.
+type TreeNode struct {
+	Level int16
+	Index int64
+	Hash  []byte
+}
+
+// PurgeOptions control a purge of expired signatures. Archived answers
+// are copied to archive tables before a purge.
+type PurgeOptions struct {
+	Archive  bool
+	DryRun   bool
+	PurgedAt time.Time
+}
+
+// RateLimitBucket is a token bucket. A new bucket has no update time.
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+}
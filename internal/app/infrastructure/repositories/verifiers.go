@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type VerifierCollection struct {
+	dbPool *pgxpool.Pool
+}
+
+func NewVerifierCollection(dbPool *pgxpool.Pool) *VerifierCollection {
+	return &VerifierCollection{dbPool}
+}
+
+func (r *VerifierCollection) Add(ctx context.Context, verifier Verifier, record AuditRecord) (*Verifier, error) {
+	transaction, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not begin a transaction", "error", err)
+		return nil, err
+	}
+	defer func() {
+		err := transaction.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "can not finish a transaction", "verifier_id", verifier.ID, "error", err)
+		}
+	}()
+	insertQuery := `INSERT INTO verifiers (id, name, key_hash, scopes, created_at,
+	cert_identity, tenant_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, name, key_hash, scopes, created_at, revoked_at, cert_identity, tenant_id;`
+	var savedVerifier Verifier
+	err = transaction.QueryRow(
+		ctx,
+		insertQuery,
+		verifier.ID,
+		verifier.Name,
+		verifier.KeyHash,
+		verifier.Scopes,
+		verifier.CreatedAt,
+		verifier.CertIdentity,
+		verifier.TenantID,
+	).Scan(
+		&savedVerifier.ID,
+		&savedVerifier.Name,
+		&savedVerifier.KeyHash,
+		&savedVerifier.Scopes,
+		&savedVerifier.CreatedAt,
+		&savedVerifier.RevokedAt,
+		&savedVerifier.CertIdentity,
+		&savedVerifier.TenantID,
+	)
+	if err != nil {
+		var pgxError *pgconn.PgError
+		if errors.As(err, &pgxError) && pgxError.Code == pgerrcode.UniqueViolation {
+			slog.InfoContext(ctx, "the verifier already exists", "name", verifier.Name)
+			return nil, ErrDuplicate
+		}
+		slog.ErrorContext(ctx, "unexpected DB error", "error", err)
+		return nil, err
+	}
+	if err := addAuditRecord(ctx, transaction, record); err != nil {
+		return nil, err
+	}
+	if err := transaction.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "can not commit a transaction", "verifier_id", verifier.ID, "error", err)
+		return nil, err
+	}
+	return &savedVerifier, nil
+}
+
+func (r *VerifierCollection) Query(ctx context.Context, spec Specification) ([]Verifier, error) {
+	query, queryArgs := spec.ToSQL()
+	rows, err := r.dbPool.Query(ctx, query, pgx.NamedArgs(queryArgs))
+	if err != nil {
+		slog.ErrorContext(ctx, "a query error", "query", query, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var verifiers []Verifier
+	for rows.Next() {
+		var verifier Verifier
+		if err := rows.Scan(
+			&verifier.ID,
+			&verifier.Name,
+			&verifier.KeyHash,
+			&verifier.Scopes,
+			&verifier.CreatedAt,
+			&verifier.RevokedAt,
+			&verifier.CertIdentity,
+			&verifier.TenantID,
+		); err != nil {
+			slog.ErrorContext(ctx, "can not scan a verifier", "query", query, "error", err)
+			return nil, err
+		}
+		verifiers = append(verifiers, verifier)
+	}
+	return verifiers, rows.Err()
+}
+
+func (r *VerifierCollection) Revoke(
+	ctx context.Context,
+	id uuid.UUID,
+	revokedAt time.Time,
+	record AuditRecord,
+) error {
+	transaction, err := r.dbPool.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not begin a transaction", "error", err)
+		return err
+	}
+	defer func() {
+		err := transaction.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.ErrorContext(ctx, "can not finish a transaction", "verifier_id", id, "error", err)
+		}
+	}()
+	query := `UPDATE verifiers SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL;`
+	tag, err := transaction.Exec(ctx, query, id, revokedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not revoke a verifier", "verifier_id", id, "error", err)
+		return errors.Join(ErrUpdateFailed, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotExist
+	}
+	if err := addAuditRecord(ctx, transaction, record); err != nil {
+		return err
+	}
+	if err := transaction.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "can not commit a transaction", "verifier_id", id, "error", err)
+		return err
+	}
+	return nil
+}
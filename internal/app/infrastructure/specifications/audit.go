@@ -0,0 +1,49 @@
+package specifications
+
+import (
+	"strings"
+	"time"
+)
+
+// AuditSpecificationByTimeRange selects audit records in [From, To)
+// ordered by ID. Zero times make a range unbounded, a nil tenant selects
+// records of all tenants.
+type AuditSpecificationByTimeRange struct {
+	From     time.Time
+	To       time.Time
+	AfterID  int64
+	Limit    int
+	TenantID *string
+}
+
+func (s AuditSpecificationByTimeRange) ToSQL() (string, map[string]any) {
+	conditions := []string{"id > @after_id"}
+	args := map[string]any{"after_id": s.AfterID, "limit": s.Limit}
+	if s.TenantID != nil {
+		conditions = append(conditions, "tenant_id = @tenant_id")
+		args["tenant_id"] = *s.TenantID
+	}
+	if !s.From.IsZero() {
+		conditions = append(conditions, "occurred_at >= @from")
+		args["from"] = s.From
+	}
+	if !s.To.IsZero() {
+		conditions = append(conditions, "occurred_at < @to")
+		args["to"] = s.To
+	}
+	query := `SELECT id, occurred_at, action, actor, signature_id, user_id,
+	outcome, client_ip, request_id, details, tenant_id FROM audit_log
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	ORDER BY id LIMIT @limit`
+	return query, args
+}
+
+func NewAuditSpecificationByTimeRange(
+	from time.Time,
+	to time.Time,
+	afterID int64,
+	limit int,
+	tenantID *string,
+) AuditSpecificationByTimeRange {
+	return AuditSpecificationByTimeRange{from, to, afterID, limit, tenantID}
+}
@@ -0,0 +1,33 @@
+package specifications
+
+import "strings"
+
+const legalHoldColumns = `id, tenant_id, signature_id, user_id, request_id_pattern,
+	reason, placed_by, placed_at, lifted_by, lifted_at`
+
+// LegalHoldSpecification selects active holds or all holds ordered by
+// placement time. A nil tenant selects holds of all tenants.
+type LegalHoldSpecification struct {
+	TenantID      *string
+	IncludeLifted bool
+}
+
+func (s LegalHoldSpecification) ToSQL() (string, map[string]any) {
+	conditions := []string{"true"}
+	args := map[string]any{}
+	if s.TenantID != nil {
+		conditions = append(conditions, "tenant_id = @tenant_id")
+		args["tenant_id"] = *s.TenantID
+	}
+	if !s.IncludeLifted {
+		conditions = append(conditions, "lifted_at IS NULL")
+	}
+	query := `SELECT ` + legalHoldColumns + ` FROM legal_holds
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	ORDER BY placed_at, id`
+	return query, args
+}
+
+func NewLegalHoldSpecification(tenantID *string, includeLifted bool) LegalHoldSpecification {
+	return LegalHoldSpecification{tenantID, includeLifted}
+}
@@ -0,0 +1,63 @@
+package specifications
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExpiryRule expires signatures created before a time. A nil tenant or
+// an empty pattern match any signature, a nil time never expires.
+type ExpiryRule struct {
+	TenantID         *string
+	RequestIDPattern string
+	Before           *time.Time
+}
+
+// ExpiredSignatureSpecification selects and locks IDs of signatures with
+// content whose first matching rule expires them. Rows locked by another
+// transaction and signatures under a legal hold are skipped.
+type ExpiredSignatureSpecification struct {
+	Rules   []ExpiryRule
+	AfterID string
+	Limit   int
+}
+
+func (s ExpiredSignatureSpecification) ToSQL() (string, map[string]any) {
+	args := map[string]any{"after_id": s.AfterID, "limit": s.Limit}
+	cases := []string{}
+	for i, rule := range s.Rules {
+		conditions := []string{"true"}
+		if rule.TenantID != nil {
+			conditions = append(conditions, fmt.Sprintf("tenant_id = @tenant_id_%d", i))
+			args[fmt.Sprintf("tenant_id_%d", i)] = *rule.TenantID
+		}
+		if rule.RequestIDPattern != "" {
+			conditions = append(conditions, fmt.Sprintf("request_id LIKE @pattern_%d", i))
+			args[fmt.Sprintf("pattern_%d", i)] = rule.RequestIDPattern
+		}
+		expired := "false"
+		if rule.Before != nil {
+			expired = fmt.Sprintf("created_at < @before_%d", i)
+			args[fmt.Sprintf("before_%d", i)] = *rule.Before
+		}
+		cases = append(cases, fmt.Sprintf("WHEN %s THEN %s", strings.Join(conditions, " AND "), expired))
+	}
+	if len(cases) == 0 {
+		cases = append(cases, "WHEN true THEN false")
+	}
+	query := `SELECT id FROM signatures
+	WHERE erased_at IS NULL AND id > @after_id
+	AND id NOT IN (SELECT id FROM held_signatures)
+	AND CASE ` + strings.Join(cases, " ") + ` ELSE false END
+	ORDER BY id LIMIT @limit FOR UPDATE SKIP LOCKED`
+	return query, args
+}
+
+func NewExpiredSignatureSpecification(
+	rules []ExpiryRule,
+	afterID string,
+	limit int,
+) ExpiredSignatureSpecification {
+	return ExpiredSignatureSpecification{rules, afterID, limit}
+}
@@ -0,0 +1,61 @@
+package specifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpiredSignatureSpecificationKeepsRuleOrder(t *testing.T) {
+	tenantID := "acme"
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rules := []ExpiryRule{
+		{RequestIDPattern: "exam-%"},
+		{TenantID: &tenantID, Before: &before},
+		{Before: &before},
+	}
+	query, args := NewExpiredSignatureSpecification(rules, "after", 10).ToSQL()
+	whens := []string{
+		"WHEN true AND request_id LIKE @pattern_0 THEN false",
+		"WHEN true AND tenant_id = @tenant_id_1 THEN created_at < @before_1",
+		"WHEN true THEN created_at < @before_2",
+	}
+	position := -1
+	for _, when := range whens {
+		next := strings.Index(query, when)
+		if next <= position {
+			t.Fatalf("'%s' is missing or out of order in %s", when, query)
+		}
+		position = next
+	}
+	expectedArgs := map[string]any{
+		"after_id":    "after",
+		"limit":       10,
+		"pattern_0":   "exam-%",
+		"tenant_id_1": "acme",
+		"before_1":    before,
+		"before_2":    before,
+	}
+	if len(args) != len(expectedArgs) {
+		t.Errorf("unexpected arguments: %v", args)
+	}
+	for name, value := range expectedArgs {
+		if args[name] != value {
+			t.Errorf("an argument %s is %v, expected %v", name, args[name], value)
+		}
+	}
+}
+
+func TestExpiredSignatureSpecificationSkipsHeldAndReturnedSignatures(t *testing.T) {
+	query, _ := NewExpiredSignatureSpecification(nil, "after", 10).ToSQL()
+	for _, clause := range []string{
+		"id > @after_id",
+		"id NOT IN (SELECT id FROM held_signatures)",
+		"ORDER BY id LIMIT @limit",
+		"CASE WHEN true THEN false ELSE false END",
+	} {
+		if !strings.Contains(query, clause) {
+			t.Errorf("a query lacks '%s': %s", clause, query)
+		}
+	}
+}
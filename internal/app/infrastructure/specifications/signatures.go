@@ -1,15 +1,38 @@
 package specifications
 
+const signatureColumns = `id, request_id, user_id, issuer, created_at,
+	COALESCE(chain_seq, 0), prev_hash, answers_hash, record_hash,
+	timestamp_token, timestamped_at, tenant_id, master_key_id, wrapped_data_key,
+	erased_at, answers_keyed`
+
+// SignatureSpecificationByID selects a signature of a tenant only.
 type SignatureSpecificationByID struct {
-	ID string
+	ID       string
+	TenantID string
 }
 
 func (s SignatureSpecificationByID) ToSQL() (string, map[string]any) {
-	query := `SELECT id, request_id, user_id, created_at FROM signatures
-	WHERE id = @id`
-	return query, map[string]any{"id": s.ID}
+	query := `SELECT ` + signatureColumns + ` FROM signatures
+	WHERE id = @id AND tenant_id = @tenant_id`
+	return query, map[string]any{"id": s.ID, "tenant_id": s.TenantID}
+}
+
+func NewSignatureSpecificationByID(id string, tenantID string) SignatureSpecificationByID {
+	return SignatureSpecificationByID{id, tenantID}
+}
+
+// SignatureChainSpecification selects a page of chain records in chain order.
+type SignatureChainSpecification struct {
+	AfterSeq int64
+	Limit    int
+}
+
+func (s SignatureChainSpecification) ToSQL() (string, map[string]any) {
+	query := `SELECT ` + signatureColumns + ` FROM signatures
+	WHERE chain_seq > @after_seq ORDER BY chain_seq LIMIT @limit`
+	return query, map[string]any{"after_seq": s.AfterSeq, "limit": s.Limit}
 }
 
-func NewSignatureSpecificationByID(id string) SignatureSpecificationByID {
-	return SignatureSpecificationByID{id}
-}
\ No newline at end of file
+func NewSignatureChainSpecification(afterSeq int64, limit int) SignatureChainSpecification {
+	return SignatureChainSpecification{afterSeq, limit}
+}
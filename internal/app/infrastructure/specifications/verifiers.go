@@ -0,0 +1,41 @@
+package specifications
+
+const verifierColumns = `id, name, key_hash, scopes, created_at, revoked_at, cert_identity,
+	tenant_id`
+
+type VerifierSpecificationByID struct {
+	ID string
+}
+
+func (s VerifierSpecificationByID) ToSQL() (string, map[string]any) {
+	query := `SELECT ` + verifierColumns + ` FROM verifiers WHERE id = @id`
+	return query, map[string]any{"id": s.ID}
+}
+
+func NewVerifierSpecificationByID(id string) VerifierSpecificationByID {
+	return VerifierSpecificationByID{id}
+}
+
+type VerifierSpecificationByCertIdentity struct {
+	CertIdentity string
+}
+
+func (s VerifierSpecificationByCertIdentity) ToSQL() (string, map[string]any) {
+	query := `SELECT ` + verifierColumns + ` FROM verifiers WHERE cert_identity = @cert_identity`
+	return query, map[string]any{"cert_identity": s.CertIdentity}
+}
+
+func NewVerifierSpecificationByCertIdentity(identity string) VerifierSpecificationByCertIdentity {
+	return VerifierSpecificationByCertIdentity{identity}
+}
+
+type AllVerifiersSpecification struct{}
+
+func (s AllVerifiersSpecification) ToSQL() (string, map[string]any) {
+	query := `SELECT ` + verifierColumns + ` FROM verifiers ORDER BY created_at`
+	return query, map[string]any{}
+}
+
+func NewAllVerifiersSpecification() AllVerifiersSpecification {
+	return AllVerifiersSpecification{}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	runtimeDebug "runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrPanic marks a failure caused by a panic, a server is restarted after it.
+var ErrPanic = errors.New("a server panic")
+
+// Hook manages a component of a server. Start must not block: long-running
+// work belongs to goroutines which report failures with Lifecycle.Fail.
+// A hook without Start owns a resource acquired at registration,
+// so it is stopped even if the lifecycle never starts.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+	// StopTimeout overrides a default stop timeout of a lifecycle.
+	StopTimeout time.Duration
+}
+
+// Lifecycle starts hooks in a registration order and stops them in reverse.
+type Lifecycle struct {
+	mu          sync.Mutex
+	hooks       []Hook
+	running     []bool
+	stopTimeout time.Duration
+	failures    chan error
+}
+
+func NewLifecycle(stopTimeout time.Duration) *Lifecycle {
+	return &Lifecycle{stopTimeout: stopTimeout, failures: make(chan error, 1)}
+}
+
+func (l *Lifecycle) Append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+	l.running = append(l.running, hook.Start == nil)
+}
+
+// Start runs start hooks. If a hook fails, already started components
+// are stopped and the start error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := append([]Hook(nil), l.hooks...)
+	l.mu.Unlock()
+	for i, hook := range hooks {
+		if hook.Start == nil {
+			continue
+		}
+		slog.DebugContext(ctx, "start a component", "component", hook.Name)
+		if err := hook.Start(ctx); err != nil {
+			startErr := fmt.Errorf("can not start '%s': %w", hook.Name, err)
+			return errors.Join(startErr, l.Stop(context.WithoutCancel(ctx)))
+		}
+		l.mu.Lock()
+		l.running[i] = true
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop runs stop hooks of running components in reverse order. Every hook
+// has its own timeout; a hook exceeding it is abandoned, and stopping
+// continues with the next component. Stop returns all failures.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	var errs []error
+	for i := len(l.hooks) - 1; i >= 0; i-- {
+		l.mu.Lock()
+		hook, running := l.hooks[i], l.running[i]
+		l.running[i] = false
+		l.mu.Unlock()
+		if !running || hook.Stop == nil {
+			continue
+		}
+		if err := l.stopHook(ctx, hook); err != nil {
+			slog.ErrorContext(ctx, "can not stop a component", "component", hook.Name, "error", err)
+			errs = append(errs, fmt.Errorf("can not stop '%s': %w", hook.Name, err))
+			continue
+		}
+		slog.DebugContext(ctx, "a component is stopped", "component", hook.Name)
+	}
+	return errors.Join(errs...)
+}
+
+func (l *Lifecycle) stopHook(ctx context.Context, hook Hook) error {
+	timeout := hook.StopTimeout
+	if timeout == 0 {
+		timeout = l.stopTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- hook.Stop(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timeout %v has been elapsed: %w", timeout, ctx.Err())
+	}
+}
+
+// Fail reports a runtime failure of a started component.
+// Only the first failure is kept.
+func (l *Lifecycle) Fail(component string, err error) {
+	select {
+	case l.failures <- fmt.Errorf("'%s' has failed: %w", component, err):
+	default:
+	}
+}
+
+// recoverPanic reports a panic of a component goroutine as its failure.
+// It must be deferred directly by the goroutine.
+func (l *Lifecycle) recoverPanic(component string) {
+	if p := recover(); p != nil {
+		slog.Error(
+			"a component panic",
+			"component", component,
+			"panic", fmt.Sprint(p),
+			"stack", string(runtimeDebug.Stack()),
+		)
+		l.Fail(component, fmt.Errorf("%w: %v", ErrPanic, p))
+	}
+}
+
+// Failures delivers a first runtime failure of a component.
+func (l *Lifecycle) Failures() <-chan error {
+	return l.failures
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackgroundHookPanicFailsLifecycle(t *testing.T) {
+	lifecycle := NewLifecycle(time.Second)
+	lifecycle.Append(backgroundHook("worker", lifecycle, func(ctx context.Context) {
+		panic("a worker bug")
+	}))
+	if err := lifecycle.Start(context.Background()); err != nil {
+		t.Fatalf("can not start: %v", err)
+	}
+	select {
+	case err := <-lifecycle.Failures():
+		if !errors.Is(err, ErrPanic) {
+			t.Errorf("a failure is not a panic: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a panic is not reported")
+	}
+	if err := lifecycle.Stop(context.Background()); err != nil {
+		t.Errorf("can not stop a panicked worker: %v", err)
+	}
+}
+
+func TestBackgroundHookStops(t *testing.T) {
+	lifecycle := NewLifecycle(time.Second)
+	lifecycle.Append(backgroundHook("worker", lifecycle, func(ctx context.Context) {
+		<-ctx.Done()
+	}))
+	if err := lifecycle.Start(context.Background()); err != nil {
+		t.Fatalf("can not start: %v", err)
+	}
+	if err := lifecycle.Stop(context.Background()); err != nil {
+		t.Errorf("can not stop a worker: %v", err)
+	}
+	select {
+	case err := <-lifecycle.Failures():
+		t.Errorf("unexpected failure: %v", err)
+	default:
+	}
+}
@@ -0,0 +1,62 @@
+// Package logging configures structured JSON logging of the service.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup makes a JSON logger the default one of slog and log packages.
+// The returned level can be changed at runtime.
+func Setup(w io.Writer, level slog.Level) *slog.LevelVar {
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
+	slog.SetDefault(slog.New(NewHandler(w, levelVar)))
+	return levelVar
+}
+
+// NewHandler creates a JSON handler which redacts secrets and adds
+// request-scoped attributes of a context to every record.
+func NewHandler(w io.Writer, level slog.Leveler) slog.Handler {
+	jsonHandler := slog.NewJSONHandler(
+		w,
+		&slog.HandlerOptions{Level: level, ReplaceAttr: redact},
+	)
+	return contextHandler{jsonHandler}
+}
+
+type attrsKey struct{}
+
+// WithAttrs returns a context whose log records carry attributes.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
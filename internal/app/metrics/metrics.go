@@ -0,0 +1,117 @@
+// Package metrics exposes Prometheus metrics of the service.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	m "github.com/AndreyAD1/test-signer/internal/app/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "test_signer"
+
+type Metrics struct {
+	registry           *prometheus.Registry
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	signatureOutcomes  *prometheus.CounterVec
+	repositoryDuration *prometheus.HistogramVec
+	retainedSignatures *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	registry := prometheus.NewRegistry()
+	metrics := Metrics{
+		registry: registry,
+		httpRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "http_requests_total",
+				Help:      "HTTP requests by route, method and status code.",
+			},
+			[]string{"route", "method", "status"},
+		),
+		httpDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "http_request_duration_seconds",
+				Help:      "HTTP request latency by route and method.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"route", "method"},
+		),
+		signatureOutcomes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "signature_operations_total",
+				Help:      "Signature creations and verifications by outcome.",
+			},
+			[]string{"operation", "outcome"},
+		),
+		repositoryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "repository_call_duration_seconds",
+				Help:      "Repository call latency by method and outcome.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"repository", "method", "outcome"},
+		),
+		retainedSignatures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "retention_signatures_total",
+				Help:      "Expired signatures by a retention action: purge, archive or dry_run.",
+			},
+			[]string{"action"},
+		),
+	}
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.httpRequests,
+		metrics.httpDuration,
+		metrics.signatureOutcomes,
+		metrics.repositoryDuration,
+		metrics.retainedSignatures,
+	)
+	return &metrics
+}
+
+// Register adds collectors such as a DB pool collector.
+func (mt *Metrics) Register(collectors ...prometheus.Collector) {
+	mt.registry.MustRegister(collectors...)
+}
+
+func (mt *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(mt.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts requests of a route. A route is a mux pattern,
+// not a raw path, to keep label cardinality bounded. A panicking request
+// is counted as 500 before a recovery middleware answers it.
+func (mt *Metrics) Middleware(route string) m.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := m.NewStatusRecorder(w)
+			defer func() {
+				status := recorder.Status()
+				p := recover()
+				if p != nil {
+					status = http.StatusInternalServerError
+				}
+				mt.httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+				mt.httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(status)).Inc()
+				if p != nil {
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
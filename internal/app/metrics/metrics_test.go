@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/AndreyAD1/test-signer/internal/app/middleware"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  string
+	}{
+		{
+			name:    "success",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			status:  "200",
+		},
+		{
+			name:    "client error",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadRequest) },
+			status:  "400",
+		},
+		{
+			name:    "panic",
+			handler: func(w http.ResponseWriter, r *http.Request) { panic("a handler bug") },
+			status:  "500",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metrics := New()
+			handler := m.Recovery(metrics.Middleware("/api/v1/sign")(test.handler))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/sign", nil))
+			requests := metrics.httpRequests.WithLabelValues("/api/v1/sign", "POST", test.status)
+			if count := testutil.ToFloat64(requests); count != 1 {
+				t.Errorf("%v requests with a status %s", count, test.status)
+			}
+			if count := testutil.CollectAndCount(metrics.httpDuration); count != 1 {
+				t.Errorf("%d latency series", count)
+			}
+		})
+	}
+}
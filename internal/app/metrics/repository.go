@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/google/uuid"
+)
+
+// SignatureRepository measures call latency of a wrapped repository.
+type SignatureRepository struct {
+	repo    r.SignatureRepository
+	metrics *Metrics
+}
+
+func NewSignatureRepository(repo r.SignatureRepository, metrics *Metrics) *SignatureRepository {
+	return &SignatureRepository{repo, metrics}
+}
+
+func (s *SignatureRepository) Add(ctx context.Context, signature r.Signature) (*r.Signature, error) {
+	start := time.Now()
+	savedSignature, err := s.repo.Add(ctx, signature)
+	s.observe("add", start, err)
+	return savedSignature, err
+}
+
+func (s *SignatureRepository) Query(ctx context.Context, spec r.Specification) ([]r.Signature, error) {
+	start := time.Now()
+	signatures, err := s.repo.Query(ctx, spec)
+	s.observe("query", start, err)
+	return signatures, err
+}
+
+func (s *SignatureRepository) observe(method string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	s.metrics.repositoryDuration.
+		WithLabelValues("signatures", method, outcome).
+		Observe(time.Since(start).Seconds())
+}
+
+// RetentionRepository counts purged signatures and measures call latency
+// of a wrapped repository.
+type RetentionRepository struct {
+	repo    r.RetentionRepository
+	metrics *Metrics
+}
+
+func NewRetentionRepository(repo r.RetentionRepository, metrics *Metrics) *RetentionRepository {
+	return &RetentionRepository{repo, metrics}
+}
+
+func (s *RetentionRepository) PurgeSignatures(
+	ctx context.Context,
+	spec r.Specification,
+	options r.PurgeOptions,
+) ([]uuid.UUID, error) {
+	start := time.Now()
+	signatureIDs, err := s.repo.PurgeSignatures(ctx, spec, options)
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	s.metrics.repositoryDuration.
+		WithLabelValues("retention", "purge_signatures", outcome).
+		Observe(time.Since(start).Seconds())
+	action := "purge"
+	switch {
+	case options.DryRun:
+		action = "dry_run"
+	case options.Archive:
+		action = "archive"
+	}
+	s.metrics.retainedSignatures.WithLabelValues(action).Add(float64(len(signatureIDs)))
+	return signatureIDs, err
+}
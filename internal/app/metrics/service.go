@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+)
+
+// SignatureService counts signature operations of a wrapped service
+// by error class.
+type SignatureService struct {
+	services.SignatureService
+	metrics *Metrics
+}
+
+func NewSignatureService(svc services.SignatureService, metrics *Metrics) *SignatureService {
+	return &SignatureService{svc, metrics}
+}
+
+func (s *SignatureService) CreateSignature(
+	ctx context.Context,
+	requestID string,
+	owner services.Owner,
+	answers []services.TestAnswer,
+) ([]byte, error) {
+	signature, err := s.SignatureService.CreateSignature(ctx, requestID, owner, answers)
+	s.metrics.signatureOutcomes.WithLabelValues("create", errorClass(err)).Inc()
+	return signature, err
+}
+
+func (s *SignatureService) VerifySignature(
+	ctx context.Context,
+	owner services.Owner,
+	signature []byte,
+) (services.StoredSignature, error) {
+	storedSignature, err := s.SignatureService.VerifySignature(ctx, owner, signature)
+	s.metrics.signatureOutcomes.WithLabelValues("verify", errorClass(err)).Inc()
+	return storedSignature, err
+}
+
+func errorClass(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, services.ErrDuplicatedSignature):
+		return "duplicate"
+	case errors.Is(err, services.ErrInvalidSignature):
+		return "invalid"
+	case errors.Is(err, services.ErrWrongOwner):
+		return "wrong_owner"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	}
+	return "error"
+}
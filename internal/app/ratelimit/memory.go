@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+)
+
+// MemoryStore keeps buckets of a single replica.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]r.RateLimitBucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]r.RateLimitBucket{}}
+}
+
+func (s *MemoryStore) UpdateBucket(
+	_ context.Context,
+	key string,
+	update func(r.RateLimitBucket) r.RateLimitBucket,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = r.RateLimitBucket{Key: key}
+	}
+	s.buckets[key] = update(bucket)
+	return nil
+}
+
+func (s *MemoryStore) DeleteBuckets(_ context.Context, updatedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for key, bucket := range s.buckets {
+		if bucket.UpdatedAt.Before(updatedBefore) {
+			delete(s.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,175 @@
+// Package ratelimit limits requests with token buckets which are kept
+// in memory or in a database shared by replicas.
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/internal/app/auth"
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	m "github.com/AndreyAD1/test-signer/internal/app/middleware"
+	"github.com/AndreyAD1/test-signer/internal/app/services"
+)
+
+// Limit refills a bucket by Rate tokens per second up to Burst tokens.
+// A zero rate disables a limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) enabled() bool {
+	return l.Rate > 0
+}
+
+// refillTime is a time to fill an empty bucket.
+func (l Limit) refillTime() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// take refills a bucket and takes a token from it. A denied request
+// has to wait for a returned duration.
+func (l Limit) take(bucket r.RateLimitBucket, now time.Time) (r.RateLimitBucket, bool, time.Duration) {
+	tokens := float64(l.Burst)
+	if !bucket.UpdatedAt.IsZero() {
+		// clocks of replicas may differ
+		elapsed := math.Max(now.Sub(bucket.UpdatedAt).Seconds(), 0)
+		tokens = math.Min(tokens, bucket.Tokens+elapsed*l.Rate)
+		if now.Before(bucket.UpdatedAt) {
+			now = bucket.UpdatedAt
+		}
+	}
+	bucket.UpdatedAt = now
+	if tokens >= 1 {
+		bucket.Tokens = tokens - 1
+		return bucket, true, 0
+	}
+	bucket.Tokens = tokens
+	return bucket, false, time.Duration((1 - tokens) / l.Rate * float64(time.Second))
+}
+
+type Limiter struct {
+	name  string
+	store r.RateLimitRepository
+	limit atomic.Pointer[Limit]
+	clock services.Clock
+}
+
+// NewLimiter creates a limiter. A name separates its buckets from buckets
+// of other limiters in a shared store.
+func NewLimiter(name string, store r.RateLimitRepository, limit Limit, clock services.Clock) *Limiter {
+	limiter := Limiter{name: name, store: store, clock: clock}
+	limiter.limit.Store(&limit)
+	return &limiter
+}
+
+// SetLimit applies a new limit to existing buckets.
+func (l *Limiter) SetLimit(limit Limit) {
+	l.limit.Store(&limit)
+}
+
+// Allow takes a token from a bucket of a key.
+func (l *Limiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	limit := *l.limit.Load()
+	if !limit.enabled() {
+		return true, 0, nil
+	}
+	var allowed bool
+	var retryAfter time.Duration
+	err := l.store.UpdateBucket(
+		ctx,
+		l.name+":"+key,
+		func(bucket r.RateLimitBucket) r.RateLimitBucket {
+			bucket, allowed, retryAfter = limit.take(bucket, l.clock.Now())
+			return bucket
+		},
+	)
+	return allowed, retryAfter, err
+}
+
+// Middleware rejects requests exceeding a limit with 429 Too Many Requests.
+// Requests are allowed if a store fails: a limit protects the service,
+// it must not make the service unavailable.
+func (l *Limiter) Middleware(key func(*http.Request) string) m.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bucketKey := key(r)
+			allowed, retryAfter, err := l.Allow(r.Context(), bucketKey)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "can not check a rate limit", "limiter", l.name, "error", err)
+				allowed = true
+			}
+			if !allowed {
+				slog.InfoContext(r.Context(), "a rate limit is exceeded", "limiter", l.name, "key", bucketKey)
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UserKey selects a bucket by an authenticated user.
+func UserKey(r *http.Request) string {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	return "user:" + principal.TenantID + ":" + principal.Issuer + ":" + principal.UserID
+}
+
+// ClientKey selects a bucket by an authenticated client,
+// otherwise by an IP address of a request.
+func ClientKey(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok && principal.ClientID != "" {
+		return "client:" + principal.ClientID
+	}
+	return IPKey(r)
+}
+
+// IPKey selects a bucket by an IP address of a request.
+func IPKey(r *http.Request) string {
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+	return "ip:" + clientIP
+}
+
+// RunCleanup deletes full buckets of limiters until a context is done.
+// A deleted bucket is the same as a new one, so limits are not affected.
+func RunCleanup(
+	ctx context.Context,
+	store r.RateLimitRepository,
+	clock services.Clock,
+	interval time.Duration,
+	limiters ...*Limiter,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var idle time.Duration
+		for _, limiter := range limiters {
+			if limit := *limiter.limit.Load(); limit.enabled() {
+				idle = max(idle, limit.refillTime())
+			}
+		}
+		deleted, err := store.DeleteBuckets(ctx, clock.Now().Add(-idle))
+		if err != nil {
+			slog.ErrorContext(ctx, "can not delete rate limit buckets", "error", err)
+			continue
+		}
+		slog.DebugContext(ctx, "rate limit buckets are deleted", "count", deleted)
+	}
+}
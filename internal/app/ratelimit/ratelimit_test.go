@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+)
+
+var testNow = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// failingStore fails every bucket update.
+type failingStore struct{}
+
+func (failingStore) UpdateBucket(context.Context, string, func(r.RateLimitBucket) r.RateLimitBucket) error {
+	return errors.New("a database is unavailable")
+}
+
+func (failingStore) DeleteBuckets(context.Context, time.Time) (int64, error) {
+	return 0, errors.New("a database is unavailable")
+}
+
+func TestLimitTake(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 3}
+	tests := []struct {
+		name       string
+		bucket     r.RateLimitBucket
+		allowed    bool
+		tokens     float64
+		updatedAt  time.Time
+		retryAfter time.Duration
+	}{
+		{
+			name:      "a new bucket is full",
+			bucket:    r.RateLimitBucket{},
+			allowed:   true,
+			tokens:    2,
+			updatedAt: testNow,
+		},
+		{
+			name:       "an empty bucket",
+			bucket:     r.RateLimitBucket{Tokens: 0, UpdatedAt: testNow},
+			tokens:     0,
+			updatedAt:  testNow,
+			retryAfter: 500 * time.Millisecond,
+		},
+		{
+			name:      "a refill",
+			bucket:    r.RateLimitBucket{Tokens: 0, UpdatedAt: testNow.Add(-time.Second)},
+			allowed:   true,
+			tokens:    1,
+			updatedAt: testNow,
+		},
+		{
+			name:      "a refill is capped by a burst",
+			bucket:    r.RateLimitBucket{Tokens: 1, UpdatedAt: testNow.Add(-time.Hour)},
+			allowed:   true,
+			tokens:    2,
+			updatedAt: testNow,
+		},
+		{
+			name:       "a partial token",
+			bucket:     r.RateLimitBucket{Tokens: 0.5, UpdatedAt: testNow.Add(-100 * time.Millisecond)},
+			tokens:     0.7,
+			updatedAt:  testNow,
+			retryAfter: 150 * time.Millisecond,
+		},
+		{
+			name:       "a bucket updated by a clock ahead",
+			bucket:     r.RateLimitBucket{Tokens: 0.5, UpdatedAt: testNow.Add(10 * time.Second)},
+			tokens:     0.5,
+			updatedAt:  testNow.Add(10 * time.Second),
+			retryAfter: 250 * time.Millisecond,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bucket, allowed, retryAfter := limit.take(test.bucket, testNow)
+			if allowed != test.allowed {
+				t.Errorf("allowed is %v, expected %v", allowed, test.allowed)
+			}
+			if diff := bucket.Tokens - test.tokens; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("%v tokens remain, expected %v", bucket.Tokens, test.tokens)
+			}
+			if !bucket.UpdatedAt.Equal(test.updatedAt) {
+				t.Errorf("a bucket is updated at %s, expected %s", bucket.UpdatedAt, test.updatedAt)
+			}
+			if diff := retryAfter - test.retryAfter; diff > time.Microsecond || diff < -time.Microsecond {
+				t.Errorf("retry after %s, expected %s", retryAfter, test.retryAfter)
+			}
+		})
+	}
+}
+
+func TestAllowRefillsWithClock(t *testing.T) {
+	clock := &fakeClock{now: testNow}
+	limiter := NewLimiter("test", NewMemoryStore(), Limit{Rate: 1, Burst: 2}, clock)
+	ctx := context.Background()
+	for i, expected := range []bool{true, true, false} {
+		allowed, _, err := limiter.Allow(ctx, "u1")
+		if err != nil || allowed != expected {
+			t.Fatalf("request %d: allowed is %v, expected %v: %v", i, allowed, expected, err)
+		}
+	}
+	if allowed, _, _ := limiter.Allow(ctx, "u2"); !allowed {
+		t.Error("a bucket of another key is empty")
+	}
+	clock.now = clock.now.Add(time.Second)
+	if allowed, _, _ := limiter.Allow(ctx, "u1"); !allowed {
+		t.Error("a bucket is not refilled after a second")
+	}
+	if allowed, _, _ := limiter.Allow(ctx, "u1"); allowed {
+		t.Error("a bucket is refilled by more than a rate")
+	}
+}
+
+func TestMiddlewareRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		limit      Limit
+		retryAfter string
+	}{
+		{name: "less than a second", limit: Limit{Rate: 4, Burst: 1}, retryAfter: "1"},
+		{name: "a whole second", limit: Limit{Rate: 1, Burst: 1}, retryAfter: "1"},
+		{name: "rounded up", limit: Limit{Rate: 0.4, Burst: 1}, retryAfter: "3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := NewLimiter("test", NewMemoryStore(), test.limit, &fakeClock{now: testNow})
+			handler := limiter.Middleware(IPKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			first := httptest.NewRecorder()
+			handler.ServeHTTP(first, httptest.NewRequest("GET", "/api/v1/log/tree-head", nil))
+			if first.Code != http.StatusOK {
+				t.Fatalf("a first request is rejected: %d", first.Code)
+			}
+			second := httptest.NewRecorder()
+			handler.ServeHTTP(second, httptest.NewRequest("GET", "/api/v1/log/tree-head", nil))
+			if second.Code != http.StatusTooManyRequests {
+				t.Fatalf("unexpected status of an exceeding request: %d", second.Code)
+			}
+			if retryAfter := second.Header().Get("Retry-After"); retryAfter != test.retryAfter {
+				t.Errorf("Retry-After is %s, expected %s", retryAfter, test.retryAfter)
+			}
+		})
+	}
+}
+
+func TestMiddlewareFailsOpen(t *testing.T) {
+	limiter := NewLimiter("test", failingStore{}, Limit{Rate: 1, Burst: 1}, &fakeClock{now: testNow})
+	handler := limiter.Middleware(IPKey)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 3; i++ {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest("GET", "/api/v1/log/tree-head", nil))
+		if response.Code != http.StatusOK {
+			t.Errorf("a request is rejected by a failing store: %d", response.Code)
+		}
+	}
+}
+
+func TestMemoryStoreDeleteBuckets(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	for key, updatedAt := range map[string]time.Time{
+		"idle":   testNow.Add(-time.Hour),
+		"recent": testNow.Add(-time.Second),
+	} {
+		update := func(bucket r.RateLimitBucket) r.RateLimitBucket {
+			bucket.UpdatedAt = updatedAt
+			return bucket
+		}
+		if err := store.UpdateBucket(ctx, key, update); err != nil {
+			t.Fatalf("can not update a bucket: %v", err)
+		}
+	}
+	deleted, err := store.DeleteBuckets(ctx, testNow.Add(-time.Minute))
+	if err != nil || deleted != 1 {
+		t.Fatalf("%d buckets are deleted: %v", deleted, err)
+	}
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("an idle bucket is kept")
+	}
+	if _, ok := store.buckets["recent"]; !ok {
+		t.Error("a recent bucket is deleted")
+	}
+}
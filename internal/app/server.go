@@ -2,101 +2,830 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/AndreyAD1/test-signer/internal/app/admin"
+	"github.com/AndreyAD1/test-signer/internal/app/auth"
+	"github.com/AndreyAD1/test-signer/internal/app/certificates"
+	"github.com/AndreyAD1/test-signer/internal/app/envelope"
 	h "github.com/AndreyAD1/test-signer/internal/app/handlers"
+	"github.com/AndreyAD1/test-signer/internal/app/health"
 	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/internal/app/metrics"
+	m "github.com/AndreyAD1/test-signer/internal/app/middleware"
+	"github.com/AndreyAD1/test-signer/internal/app/ratelimit"
 	"github.com/AndreyAD1/test-signer/internal/app/services"
+	"github.com/AndreyAD1/test-signer/internal/app/timestamping"
+	"github.com/AndreyAD1/test-signer/internal/app/tracing"
 	"github.com/AndreyAD1/test-signer/internal/configuration"
 )
 
+// ConfigLoader reads a current configuration on SIGHUP.
+type ConfigLoader func() (configuration.ServerConfig, error)
+
 type Server struct {
-	shutdownFuncs []func()
-	httpServer    *http.Server
-}
+	lifecycle  *Lifecycle
+	health     *health.Checker
+	drainDelay time.Duration
 
-var defaultTimeout = 5
+	// reloadable components
+	config          atomic.Pointer[configuration.ServerConfig]
+	loadConfig      ConfigLoader
+	logLevel        *slog.LevelVar
+	authenticator   *auth.Authenticator
+	keySets         *keySets
+	signatureSvc    *services.SignatureSvc
+	signatureRepo   *r.SignatureCollection
+	transparencySvc *services.TransparencySvc
+	certReloader    *certificates.Reloader
+	signLimiter     *ratelimit.Limiter
+	verifyLimiter   *ratelimit.Limiter
+	logLimiter      *ratelimit.Limiter
+	retentionSvc    *services.RetentionSvc
+}
 
-func NewServer(ctx context.Context, config configuration.ServerConfig) (*Server, error) {
-	signatureRepo, err := r.NewSignatureCollection(ctx, config.DatabaseURL)
+// NewServer creates components and registers them in a lifecycle.
+// Resources of already created components are released on a failure.
+// On SIGHUP a server reloads a configuration with loadConfig and
+// applies a new log level to logLevel.
+func NewServer(
+	ctx context.Context,
+	config configuration.ServerConfig,
+	loadConfig ConfigLoader,
+	logLevel *slog.LevelVar,
+) (_ *Server, err error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	lifecycle := NewLifecycle(config.ShutdownTimeout)
+	defer func() {
+		if err != nil {
+			lifecycle.Stop(context.WithoutCancel(ctx))
+		}
+	}()
+	shutdownTracing, err := tracing.Setup(
+		ctx,
+		tracing.Config{
+			OTLPEndpoint: config.OTLPEndpoint,
+			Insecure:     config.OTLPInsecure,
+			SampleRatio:  config.TracingSampleRatio,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Append(Hook{Name: "tracing", Stop: shutdownTracing})
+	dbPool, err := r.NewPool(ctx, config.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Append(Hook{
+		Name: "database",
+		Stop: func(context.Context) error {
+			dbPool.Close()
+			return nil
+		},
+	})
+	serviceMetrics := metrics.New()
+	serviceMetrics.Register(metrics.NewPoolCollector(dbPool))
+	masterKeys, err := envelope.NewMasterKeys(config.MasterKey, config.RetiredMasterKeys)
+	if err != nil {
+		return nil, err
+	}
+	signatureCollection := r.NewSignatureCollection(dbPool, masterKeys)
+	signatureRepo := metrics.NewSignatureRepository(signatureCollection, serviceMetrics)
+	auditSvc := services.NewAuditSvc(r.NewAuditCollection(dbPool), services.SystemClock{})
+	timestampAuthority, err := newTimestampAuthority(config)
+	if err != nil {
+		return nil, err
+	}
+	keyrings, err := newKeyrings(config)
 	if err != nil {
 		return nil, err
 	}
-
 	signatureSvc, err := services.NewSignatureSvc(
 		signatureRepo,
-		config.SignKey,
+		keyrings,
+		auditSvc,
+		timestampAuthority,
+		services.SystemClock{},
+		services.RandomIDGenerator{},
 	)
 	if err != nil {
 		return nil, err
 	}
+	authenticator, closers, err := newAuthenticator(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	jwks := &keySets{closers: closers}
+	lifecycle.Append(closerHook("jwks", jwks.close))
+	transparencySvc, err := services.NewTransparencySvc(
+		r.NewTransparencyLogCollection(dbPool),
+		config.LogSigningKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Append(backgroundHook("tree head publisher", lifecycle, func(ctx context.Context) {
+		transparencySvc.RunPublisher(ctx, config.LogTreeHeadInterval)
+	}))
+
+	retentionSvc := services.NewRetentionSvc(
+		metrics.NewRetentionRepository(r.NewRetentionCollection(dbPool), serviceMetrics),
+		auditSvc,
+		services.SystemClock{},
+		retentionPolicy(config),
+	)
+	lifecycle.Append(backgroundHook("retention worker", lifecycle, func(ctx context.Context) {
+		retentionSvc.Run(ctx, config.RetentionInterval)
+	}))
+
+	verifierSvc := services.NewVerifierSvc(r.NewVerifierCollection(dbPool), auditSvc, services.SystemClock{})
+	clientAuthenticator := auth.NewClientAuthenticator(
+		authenticator,
+		func(ctx context.Context, apiKey string) (auth.Principal, error) {
+			verifier, err := verifierSvc.AuthenticateAPIKey(ctx, apiKey)
+			return verifierPrincipal(verifier), err
+		},
+		func(ctx context.Context, identity string) (auth.Principal, error) {
+			verifier, err := verifierSvc.AuthenticateCertificate(ctx, identity)
+			return verifierPrincipal(verifier), err
+		},
+	)
 	handlers := h.HandlerContainer{
-		ApiSecret:    config.APISecret,
-		SignatureSvc: signatureSvc,
-		Timeout:      time.Duration(defaultTimeout),
+		SignatureSvc:    metrics.NewSignatureService(signatureSvc, serviceMetrics),
+		AuditSvc:        auditSvc,
+		TransparencySvc: transparencySvc,
+		ErasureSvc:      services.NewErasureSvc(signatureCollection, auditSvc, services.SystemClock{}),
+		LegalHoldSvc: services.NewLegalHoldSvc(
+			r.NewLegalHoldCollection(dbPool),
+			auditSvc,
+			services.SystemClock{},
+		),
 	}
 
+	checker := health.NewChecker(config.HealthCheckTimeout)
+	checker.Add("database", dbPool.Ping)
+	checker.Add("migrations", func(ctx context.Context) error {
+		return r.CheckSchemaVersion(ctx, dbPool)
+	})
+	checker.Add("signing_keys", func(context.Context) error {
+		if err := signatureSvc.CheckKeys(); err != nil {
+			return err
+		}
+		return transparencySvc.CheckKeys()
+	})
+
 	srvMux := http.NewServeMux()
-	srvMux.HandleFunc("/api/v1/sign", handlers.SignAnswersHandler())
-	srvMux.HandleFunc("/api/v1/verify", handlers.VerifySignatureHandler())
+	get := []string{http.MethodGet}
+	srvMux.Handle("/healthz", m.Chain(http.HandlerFunc(checker.LivenessHandler()), m.Methods(get...)))
+	srvMux.Handle("/readyz", m.Chain(http.HandlerFunc(checker.ReadinessHandler()), m.Methods(get...)))
+	// timedRoute overrides REQUEST_TIMEOUT of slow endpoints
+	timedRoute := func(
+		pattern string,
+		timeout time.Duration,
+		handler http.HandlerFunc,
+		methods []string,
+		extra ...m.Middleware,
+	) {
+		middlewares := []m.Middleware{
+			tracing.Middleware(pattern),
+			serviceMetrics.Middleware(pattern),
+			m.Methods(methods...),
+			m.Timeout(timeout),
+			m.MaxBodySize(config.MaxBodyBytes),
+		}
+		srvMux.Handle(pattern, m.Chain(handler, append(middlewares, extra...)...))
+	}
+	route := func(pattern string, handler http.HandlerFunc, methods []string, extra ...m.Middleware) {
+		timedRoute(pattern, config.RequestTimeout, handler, methods, extra...)
+	}
+	var rateLimitStore r.RateLimitRepository = ratelimit.NewMemoryStore()
+	if config.RateLimitBackend == "postgres" {
+		rateLimitStore = r.NewRateLimitCollection(dbPool)
+	}
+	clock := services.SystemClock{}
+	signLimiter := ratelimit.NewLimiter("sign", rateLimitStore, signLimit(config), clock)
+	verifyLimiter := ratelimit.NewLimiter("verify", rateLimitStore, verifyLimit(config), clock)
+	logLimiter := ratelimit.NewLimiter("log", rateLimitStore, logLimit(config), clock)
+	lifecycle.Append(backgroundHook("rate limit cleanup", lifecycle, func(ctx context.Context) {
+		ratelimit.RunCleanup(ctx, rateLimitStore, clock, time.Minute, signLimiter, verifyLimiter, logLimiter)
+	}))
+
+	post := []string{http.MethodPost}
+	route(
+		"/api/v1/sign",
+		handlers.SignAnswersHandler(),
+		post,
+		authenticator.Middleware,
+		signLimiter.Middleware(ratelimit.UserKey),
+	)
+	timedRoute(
+		"/api/v1/verify",
+		config.VerifyTimeout,
+		handlers.VerifySignatureHandler(),
+		post,
+		clientAuthenticator.Middleware(services.ScopeVerify),
+		verifyLimiter.Middleware(ratelimit.ClientKey),
+	)
+	route("/api/v1/log/tree-head", handlers.TreeHeadHandler(), get, logLimiter.Middleware(ratelimit.IPKey))
+	route(
+		"/api/v1/log/consistency",
+		handlers.ConsistencyProofHandler(),
+		get,
+		logLimiter.Middleware(ratelimit.IPKey),
+	)
+	route("/api/v1/log/public-key", handlers.LogPublicKeyHandler(), get, logLimiter.Middleware(ratelimit.IPKey))
+	timedRoute(
+		"/api/v1/admin/audit",
+		config.AuditExportTimeout,
+		handlers.AuditLogHandler(),
+		get,
+		clientAuthenticator.Middleware(services.ScopeAuditRead),
+	)
+	route(
+		"/api/v1/admin/erasures",
+		handlers.EraseUserHandler(),
+		post,
+		clientAuthenticator.Middleware(services.ScopeErase),
+	)
+	route(
+		"/api/v1/admin/legal-holds",
+		handlers.LegalHoldsHandler(),
+		[]string{http.MethodGet, http.MethodPost},
+		clientAuthenticator.Middleware(services.ScopeLegalHold),
+	)
+	route(
+		"/api/v1/admin/legal-holds/lift",
+		handlers.LiftLegalHoldHandler(),
+		post,
+		clientAuthenticator.Middleware(services.ScopeLegalHold),
+	)
 	httpServer := http.Server{
 		Addr:    config.ServerAddress,
-		Handler: srvMux,
+		Handler: m.Chain(srvMux, m.RequestID, m.Recovery),
+	}
+	var reloader *certificates.Reloader
+	if config.TLSEnabled() {
+		reloader, err = newCertificateReloader(config)
+		if err != nil {
+			return nil, err
+		}
+		minVersion, err := certificates.ParseVersion(config.TLSMinVersion)
+		if err != nil {
+			return nil, err
+		}
+		httpServer.TLSConfig = reloader.TLSConfig(minVersion)
+		lifecycle.Append(backgroundHook("certificate watcher", lifecycle, func(ctx context.Context) {
+			reloader.Watch(ctx, config.TLSReloadInterval)
+		}))
+	}
+	server := &Server{
+		lifecycle:       lifecycle,
+		health:          checker,
+		drainDelay:      config.ShutdownDrainDelay,
+		loadConfig:      loadConfig,
+		logLevel:        logLevel,
+		authenticator:   authenticator,
+		keySets:         jwks,
+		signatureSvc:    signatureSvc,
+		signatureRepo:   signatureCollection,
+		transparencySvc: transparencySvc,
+		certReloader:    reloader,
+		signLimiter:     signLimiter,
+		verifyLimiter:   verifyLimiter,
+		logLimiter:      logLimiter,
+		retentionSvc:    retentionSvc,
+	}
+	server.config.Store(&config)
+
+	adminMux := http.NewServeMux()
+	adminMux.Handle("/metrics", serviceMetrics.Handler())
+	admin.RegisterProfiler(adminMux)
+	adminMux.Handle("/buildinfo", m.Chain(http.HandlerFunc(admin.BuildInfoHandler()), m.Methods(get...)))
+	adminMux.Handle(
+		"/config",
+		m.Chain(
+			http.HandlerFunc(admin.ConfigHandler(func() []configuration.Variable {
+				return configuration.Variables(server.config.Load())
+			})),
+			m.Methods(get...),
+		),
+	)
+	adminMux.Handle("/keys", m.Chain(http.HandlerFunc(admin.KeysHandler(server.keys)), m.Methods(get...)))
+	adminServer := http.Server{
+		Addr:    config.AdminAddress,
+		Handler: m.Chain(adminMux, m.Recovery),
 	}
-	return &Server{httpServer: &httpServer}, nil
+	lifecycle.Append(httpServerHook("admin server", &adminServer, lifecycle))
+	lifecycle.Append(httpServerHook("HTTP server", &httpServer, lifecycle))
+	return server, nil
 }
 
-func (s *Server) Shutdown(timeout time.Duration) {
-	// set the timeout to prevent a system hang
-	timeoutFunc := time.AfterFunc(timeout, func() {
-		logMsg := fmt.Sprintf(
-			"timeout %v has been elapsed, force exit",
-			timeout.Seconds(),
+// newAuthenticator also returns functions which stop JWKS refreshes.
+// They are already called if an authenticator can not be created.
+func newAuthenticator(
+	ctx context.Context,
+	config configuration.ServerConfig,
+) (_ *auth.Authenticator, closers []func(), err error) {
+	defer func() {
+		if err != nil {
+			closeAll(closers)
+		}
+	}()
+	authConfig := auth.Config{
+		Secret:         config.APISecret,
+		Issuer:         config.JWTIssuer,
+		Audience:       config.JWTAudience,
+		ClientAudience: config.JWTClientAudience,
+		Leeway:         config.JWTLeeway,
+		Algorithms:     config.JWTAlgorithms,
+		TenantClaim:    config.TenantClaim,
+	}
+	if jwksSource := config.JWKSURL + config.JWKSFile; jwksSource != "" {
+		keySet, err := auth.NewJWKS(ctx, jwksSource, config.JWKSRefresh)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can not load a JWKS: %w", err)
+		}
+		closers = append(closers, keySet.Close)
+		authConfig.KeySet = keySet
+	}
+	for _, issuer := range config.TrustedIssuers {
+		trustedIssuer, keySet, err := auth.DiscoverIssuer(
+			ctx,
+			issuer.Issuer,
+			issuer.Audience,
+			issuer.UserClaim,
+			config.JWKSRefresh,
 		)
-		log.Fatal(logMsg)
-	})
-	defer timeoutFunc.Stop()
-	for _, f := range s.shutdownFuncs {
-		f()
+		if err != nil {
+			return nil, closers, fmt.Errorf("can not discover an issuer '%s': %w", issuer.Issuer, err)
+		}
+		closers = append(closers, keySet.Close)
+		trustedIssuer.Tenant = issuer.Tenant
+		trustedIssuer.ClientAudience = issuer.ClientAudience
+		authConfig.TrustedIssuers = append(authConfig.TrustedIssuers, trustedIssuer)
 	}
+	authenticator, err := auth.NewAuthenticator(authConfig)
+	if err != nil {
+		return nil, closers, err
+	}
+	return authenticator, closers, nil
 }
 
-func (s *Server) Run(ctx context.Context) error {
-	ctx, cancelCtx := context.WithCancel(ctx)
-	idleConnectionsClosed := make(chan struct{})
+// keySets owns JWKS refreshes of a current authenticator.
+type keySets struct {
+	mu      sync.Mutex
+	closers []func()
+}
+
+// replace stops refreshes of key sets which are no longer used.
+func (k *keySets) replace(closers []func()) {
+	k.mu.Lock()
+	previous := k.closers
+	k.closers = closers
+	k.mu.Unlock()
+	closeAll(previous)
+}
+
+func (k *keySets) close() {
+	k.replace(nil)
+}
+
+func closeAll(closers []func()) {
+	for _, closeFunc := range closers {
+		closeFunc()
+	}
+}
+
+// newKeyrings loads SIGN_KEY of a default tenant and TENANT_SIGN_KEYS.
+func newKeyrings(config configuration.ServerConfig) (*services.Keyrings, error) {
+	defaultKeyring, err := services.NewKeyring(config.SignKey, config.RetiredSignKeys)
+	if err != nil {
+		return nil, err
+	}
+	tenants := map[string]*services.Keyring{}
+	for tenantID, keys := range config.TenantSignKeys {
+		keyring, err := services.NewKeyring(keys.SignKey, keys.RetiredSignKeys)
+		if err != nil {
+			return nil, fmt.Errorf("a tenant '%s': %w", tenantID, err)
+		}
+		tenants[tenantID] = keyring
+	}
+	return services.NewKeyrings(defaultKeyring, tenants), nil
+}
+
+// verifierPrincipal is a principal of a verifier client.
+func verifierPrincipal(verifier services.Verifier) auth.Principal {
+	return auth.Principal{
+		ClientID: verifier.ID,
+		Scopes:   verifier.Scopes,
+		TenantID: verifier.TenantID,
+	}
+}
+
+func signLimit(config configuration.ServerConfig) ratelimit.Limit {
+	return ratelimit.Limit{Rate: config.SignRateLimit, Burst: config.SignRateBurst}
+}
+
+func verifyLimit(config configuration.ServerConfig) ratelimit.Limit {
+	return ratelimit.Limit{Rate: config.VerifyRateLimit, Burst: config.VerifyRateBurst}
+}
+
+func logLimit(config configuration.ServerConfig) ratelimit.Limit {
+	return ratelimit.Limit{Rate: config.LogRateLimit, Burst: config.LogRateBurst}
+}
 
-	go func() {
-		signalCh := make(chan os.Signal, 4)
-		signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+// retentionPolicy maps RETENTION_* variables to a policy.
+func retentionPolicy(config configuration.ServerConfig) services.RetentionPolicy {
+	policy := services.RetentionPolicy{
+		Period:    retentionPeriod(config.RetentionPeriod),
+		Rules:     []services.RetentionRule{},
+		Archive:   config.RetentionAction == "archive",
+		DryRun:    config.RetentionDryRun,
+		BatchSize: config.RetentionBatchSize,
+	}
+	for _, rule := range config.RetentionRules {
+		policy.Rules = append(policy.Rules, services.RetentionRule{
+			TenantID:         rule.TenantID,
+			RequestIDPattern: rule.RequestIDPattern,
+			Period:           retentionPeriod(rule.Period),
+		})
+	}
+	return policy
+}
+
+func retentionPeriod(period configuration.Period) services.RetentionPeriod {
+	return services.RetentionPeriod{
+		Years:    period.Years,
+		Days:     period.Days,
+		Duration: period.Duration,
+	}
+}
+
+// ValidateConfig checks a configuration and loads its keys and certificates
+// without connecting to a database or identity providers.
+func ValidateConfig(config configuration.ServerConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if _, err := newKeyrings(config); err != nil {
+		return err
+	}
+	if _, err := envelope.NewMasterKeys(config.MasterKey, config.RetiredMasterKeys); err != nil {
+		return err
+	}
+	if _, err := services.NewTransparencySvc(nil, config.LogSigningKey); err != nil {
+		return err
+	}
+	if !config.TLSEnabled() {
+		return nil
+	}
+	if _, err := certificates.ParseVersion(config.TLSMinVersion); err != nil {
+		return err
+	}
+	_, err := newCertificateReloader(config)
+	return err
+}
+
+func newCertificateReloader(config configuration.ServerConfig) (*certificates.Reloader, error) {
+	return certificates.NewReloader(
+		config.TLSCertFile,
+		config.TLSKeyFile,
+		config.TLSClientCAFile,
+	)
+}
+
+func newTimestampAuthority(config configuration.ServerConfig) (timestamping.Authority, error) {
+	if config.TSAURL != "" {
+		var roots *x509.CertPool
+		if config.TSACAFile != "" {
+			rawRoots, err := os.ReadFile(config.TSACAFile)
+			if err != nil {
+				return nil, fmt.Errorf("can not read TSA roots: %w", err)
+			}
+			roots = x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(rawRoots) {
+				return nil, fmt.Errorf("no certificates in '%s'", config.TSACAFile)
+			}
+		}
+		return timestamping.NewClient(config.TSAURL, config.TSATimeout, roots), nil
+	}
+	if !config.TSALocal {
+		return nil, nil
+	}
+	if config.TSACertFile == "" {
+		slog.Warn("use an ephemeral local TSA: its tokens can not be verified after a restart")
+		return timestamping.NewEphemeralLocalAuthority()
+	}
+	return timestamping.NewLocalAuthority(config.TSACertFile, config.TSAKeyFile)
+}
+
+// Run starts components and stops them on a signal, a context cancellation
+// or a runtime failure of a component. SIGHUP reloads a configuration.
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.lifecycle.Start(ctx); err != nil {
+		return err
+	}
+	signalCh := make(chan os.Signal, 4)
+	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signalCh)
+	var runErr error
+	for stop := false; !stop; {
 		select {
 		case sig := <-signalCh:
-			log.Printf("receive an OS signal '%v'", sig)
+			slog.Info("receive an OS signal", "signal", sig.String())
+			if sig == syscall.SIGHUP {
+				s.reload(ctx)
+				continue
+			}
+			stop = true
 		case <-ctx.Done():
-			log.Printf("start shutdown because of context")
+			slog.Info("start shutdown because of context")
+			stop = true
+		case runErr = <-s.lifecycle.Failures():
+			slog.Error("start shutdown because of a component failure", "error", runErr)
+			stop = true
 		}
+	}
 
-		shutdownCtx, shutdownCtxCancel := context.WithTimeout(ctx, 5*time.Second)
-		defer shutdownCtxCancel()
-		if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
-			log.Printf("a server shutdown error: %v", err)
-		}
-		close(idleConnectionsClosed)
-		cancelCtx()
-	}()
+	// fail readiness first, so load balancers stop sending new requests
+	s.health.StartDraining()
+	if runErr == nil && s.drainDelay > 0 {
+		slog.Info("drain connections before shutdown", "delay", s.drainDelay)
+		time.Sleep(s.drainDelay)
+	}
+	return errors.Join(runErr, s.lifecycle.Stop(context.WithoutCancel(ctx)))
+}
+
+// reload prepares all reloadable components before it applies any of them,
+// so a failure keeps a whole previous configuration.
+func (s *Server) reload(ctx context.Context) {
+	if err := s.applyConfig(ctx); err != nil {
+		slog.ErrorContext(ctx, "can not reload a configuration, keep the previous one", "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "a configuration is reloaded")
+}
 
-	go func() {
-		if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
-			log.Printf("an HTTP server runtime error: %v", err)
+func (s *Server) applyConfig(ctx context.Context) error {
+	if s.loadConfig == nil {
+		return errors.New("a configuration loader is not set")
+	}
+	config, err := s.loadConfig()
+	if err != nil {
+		return fmt.Errorf("a configuration error: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	previous := s.config.Load()
+	if config.TLSEnabled() != previous.TLSEnabled() {
+		return errors.New("a restart is required to enable or disable TLS")
+	}
+	keyrings, err := newKeyrings(config)
+	if err != nil {
+		return err
+	}
+	masterKeys, err := envelope.NewMasterKeys(config.MasterKey, config.RetiredMasterKeys)
+	if err != nil {
+		return err
+	}
+	var reloader *certificates.Reloader
+	if config.TLSEnabled() {
+		if reloader, err = newCertificateReloader(config); err != nil {
+			return err
 		}
-		cancelCtx()
-	}()
+	}
+	authenticator, closers, err := newAuthenticator(ctx, config)
+	if err != nil {
+		return err
+	}
 
-	<-idleConnectionsClosed
+	s.authenticator.Replace(authenticator)
+	s.keySets.replace(closers)
+	s.signatureSvc.SetKeyrings(keyrings)
+	s.signatureRepo.SetMasterKeys(masterKeys)
+	if reloader != nil {
+		s.certReloader.Replace(reloader)
+	}
+	s.signLimiter.SetLimit(signLimit(config))
+	s.verifyLimiter.SetLimit(verifyLimit(config))
+	s.logLimiter.SetLimit(logLimit(config))
+	s.retentionSvc.SetPolicy(retentionPolicy(config))
+	if s.logLevel != nil {
+		s.logLevel.Set(config.Level())
+	}
+	if changed := restartRequired(*previous, config); len(changed) > 0 {
+		slog.WarnContext(ctx, "a restart is required to apply a configuration", "variables", changed)
+	}
+	s.config.Store(&config)
 	return nil
 }
+
+// keys lists fingerprints of keys and certificates in use.
+func (s *Server) keys() []admin.Key {
+	keys := []admin.Key{}
+	keyrings := s.signatureSvc.Keyrings()
+	for _, tenantID := range keyrings.Tenants() {
+		keyring, err := keyrings.Tenant(tenantID)
+		if err != nil {
+			continue
+		}
+		signKey, retiredSignKeys := "SIGN_KEY", "RETIRED_SIGN_KEYS"
+		if tenantID != "" {
+			signKey = fmt.Sprintf("TENANT_SIGN_KEYS[%s]", tenantID)
+			retiredSignKeys = signKey + ".retired_sign_keys"
+		}
+		current, retired := keyring.Fingerprints()
+		keys = append(keys, admin.Key{Name: signKey, Algorithm: "AES-256-GCM", Fingerprint: current})
+		for i, fingerprint := range retired {
+			keys = append(keys, admin.Key{
+				Name:        fmt.Sprintf("%s[%d]", retiredSignKeys, i),
+				Algorithm:   "AES-256-GCM",
+				Fingerprint: fingerprint,
+			})
+		}
+	}
+	masterKeys := s.signatureRepo.MasterKeys()
+	keys = append(keys, admin.Key{
+		Name:        "MASTER_KEY",
+		Algorithm:   "AES-256-GCM",
+		Fingerprint: masterKeys.CurrentID(),
+	})
+	for i, fingerprint := range masterKeys.RetiredIDs() {
+		keys = append(keys, admin.Key{
+			Name:        fmt.Sprintf("RETIRED_MASTER_KEYS[%d]", i),
+			Algorithm:   "AES-256-GCM",
+			Fingerprint: fingerprint,
+		})
+	}
+	logKey := sha256.Sum256(s.transparencySvc.PublicKey())
+	keys = append(keys, admin.Key{
+		Name:        "LOG_SIGNING_KEY",
+		Algorithm:   "Ed25519",
+		Fingerprint: hex.EncodeToString(logKey[:]),
+	})
+	if s.certReloader == nil {
+		return keys
+	}
+	leaf, err := s.certReloader.Leaf()
+	if err != nil {
+		slog.Error("can not parse a TLS certificate", "error", err)
+		return keys
+	}
+	certificate := sha256.Sum256(leaf.Raw)
+	keys = append(keys, admin.Key{
+		Name:        "TLS_CERT_FILE",
+		Algorithm:   leaf.PublicKeyAlgorithm.String(),
+		Fingerprint: hex.EncodeToString(certificate[:]),
+		NotAfter:    &leaf.NotAfter,
+	})
+	return keys
+}
+
+// restartRequired lists changed variables which are read only on start.
+// It does not list values, because some of them are secrets.
+func restartRequired(previous, next configuration.ServerConfig) []string {
+	changes := map[string]bool{
+		"DATABASE_URL":           previous.DatabaseURL != next.DatabaseURL,
+		"SERVER_ADDRESS":         previous.ServerAddress != next.ServerAddress,
+		"ADMIN_ADDRESS":          previous.AdminAddress != next.AdminAddress,
+		"REQUEST_TIMEOUT":        previous.RequestTimeout != next.RequestTimeout,
+		"VERIFY_TIMEOUT":         previous.VerifyTimeout != next.VerifyTimeout,
+		"AUDIT_EXPORT_TIMEOUT":   previous.AuditExportTimeout != next.AuditExportTimeout,
+		"MAX_BODY_BYTES":         previous.MaxBodyBytes != next.MaxBodyBytes,
+		"LOG_SIGNING_KEY":        previous.LogSigningKey != next.LogSigningKey,
+		"LOG_TREE_HEAD_INTERVAL": previous.LogTreeHeadInterval != next.LogTreeHeadInterval,
+		"TSA_URL":                previous.TSAURL != next.TSAURL,
+		"TSA_LOCAL":              previous.TSALocal != next.TSALocal,
+		"TSA_CERT_FILE":          previous.TSACertFile != next.TSACertFile,
+		"OTLP_ENDPOINT":          previous.OTLPEndpoint != next.OTLPEndpoint,
+		"TRACING_SAMPLE_RATIO":   previous.TracingSampleRatio != next.TracingSampleRatio,
+		"TLS_MIN_VERSION":        previous.TLSMinVersion != next.TLSMinVersion,
+		"TLS_RELOAD_INTERVAL":    previous.TLSReloadInterval != next.TLSReloadInterval,
+		"RATE_LIMIT_BACKEND":     previous.RateLimitBackend != next.RateLimitBackend,
+		"RETENTION_INTERVAL":     previous.RetentionInterval != next.RetentionInterval,
+	}
+	changed := []string{}
+	for name, isChanged := range changes {
+		if isChanged {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Close stops components which are still running.
+func (s *Server) Close(ctx context.Context) error {
+	return s.lifecycle.Stop(context.WithoutCancel(ctx))
+}
+
+// httpServerHook listens on start, so an unavailable address fails
+// the start instead of a background goroutine. An address like
+// 'unix:/run/test-signer/admin.sock' is a Unix socket.
+func httpServerHook(name string, server *http.Server, lifecycle *Lifecycle) Hook {
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			listener, err := listen(server.Addr)
+			if err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "start a server", "server", name, "address", listener.Addr().String())
+			go func() {
+				defer lifecycle.recoverPanic(name)
+				serve := server.Serve
+				if server.TLSConfig != nil {
+					serve = func(listener net.Listener) error {
+						return server.ServeTLS(listener, "", "")
+					}
+				}
+				if err := serve(listener); !errors.Is(err, http.ErrServerClosed) {
+					lifecycle.Fail(name, err)
+				}
+			}()
+			return nil
+		},
+		Stop: server.Shutdown,
+	}
+}
+
+func listen(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, "unix:")
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+	// a socket of a previous process which has not stopped gracefully
+	if info, err := os.Lstat(path); err == nil && info.Mode().Type() == fs.ModeSocket {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o660); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// backgroundHook runs a function until a component stops. A panic of
+// the function fails a lifecycle.
+func backgroundHook(name string, lifecycle *Lifecycle, run func(ctx context.Context)) Hook {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+	return Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				defer lifecycle.recoverPanic(name)
+				run(ctx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+func closerHook(name string, closeFunc func()) Hook {
+	return Hook{
+		Name: name,
+		Stop: func(context.Context) error {
+			closeFunc()
+			return nil
+		},
+	}
+}
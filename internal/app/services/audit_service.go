@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
+	"github.com/google/uuid"
+)
+
+const (
+	ActionVerifySignature = "signature.verify"
+	ActionCreateVerifier  = "verifier.create"
+	ActionRevokeVerifier  = "verifier.revoke"
+	ActionQueryAudit      = "audit.query"
+	ActionExportAudit     = "audit.export"
+	ActionVerifyChain     = "chain.verify"
+	ActionRewrapDataKeys  = "keys.rewrap"
+	ActionEraseUser       = "user.erase"
+	ActionPurgeSignatures = "retention.purge"
+	ActionPlaceLegalHold  = "legal_hold.place"
+	ActionLiftLegalHold   = "legal_hold.lift"
+)
+
+const (
+	OutcomeSuccess    = "success"
+	OutcomeInvalid    = "invalid"
+	OutcomeWrongOwner = "wrong_owner"
+	OutcomeError      = "error"
+	OutcomeLegalHold  = "legal_hold"
+)
+
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+type actorKey struct{}
+
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) Actor {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok {
+		return Actor{ID: "unknown"}
+	}
+	return actor
+}
+
+type AuditSvc struct {
+	auditRepo r.AuditRepository
+	clock     Clock
+}
+
+func NewAuditSvc(repo r.AuditRepository, clock Clock) *AuditSvc {
+	return &AuditSvc{repo, clock}
+}
+
+// Record appends an event performed by a context actor.
+func (s *AuditSvc) Record(ctx context.Context, event AuditEvent) error {
+	return s.auditRepo.Add(ctx, newAuditRecord(ctx, event, s.clock.Now()))
+}
+
+// newAuditRecord attributes an event to a context actor. A repository
+// stores it in a transaction of a change which the event records.
+func newAuditRecord(ctx context.Context, event AuditEvent, occurredAt time.Time) r.AuditRecord {
+	actor := ActorFromContext(ctx)
+	record := r.AuditRecord{
+		OccurredAt: occurredAt,
+		Action:     event.Action,
+		Actor:      actor.ID,
+		UserID:     event.UserID,
+		Outcome:    event.Outcome,
+		ClientIP:   actor.ClientIP,
+		RequestID:  actor.RequestID,
+		Details:    event.Details,
+		TenantID:   actor.TenantID,
+	}
+	if event.SignatureID != "" {
+		signatureID, err := uuid.Parse(event.SignatureID)
+		if err == nil {
+			record.SignatureID = &signatureID
+		}
+	}
+	return record
+}
+
+func (s *AuditSvc) QueryEvents(
+	ctx context.Context,
+	from time.Time,
+	to time.Time,
+	afterID int64,
+	limit int,
+) ([]AuditEvent, error) {
+	details := map[string]any{"from": from, "to": to, "after_id": afterID}
+	event := AuditEvent{Action: ActionQueryAudit, Outcome: OutcomeSuccess, Details: details}
+	if err := s.Record(ctx, event); err != nil {
+		return nil, err
+	}
+	return s.queryPage(ctx, from, to, afterID, limit)
+}
+
+// ExportEvents writes all events of a time range as JSON lines.
+func (s *AuditSvc) ExportEvents(ctx context.Context, from time.Time, to time.Time, w io.Writer) error {
+	details := map[string]any{"from": from, "to": to}
+	event := AuditEvent{Action: ActionExportAudit, Outcome: OutcomeSuccess, Details: details}
+	if err := s.Record(ctx, event); err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	var afterID int64
+	for {
+		events, err := s.queryPage(ctx, from, to, afterID, maxAuditPageSize)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+		if len(events) < maxAuditPageSize {
+			return nil
+		}
+		afterID = events[len(events)-1].ID
+	}
+}
+
+func (s *AuditSvc) queryPage(
+	ctx context.Context,
+	from time.Time,
+	to time.Time,
+	afterID int64,
+	limit int,
+) ([]AuditEvent, error) {
+	if limit <= 0 {
+		limit = defaultAuditPageSize
+	}
+	limit = min(limit, maxAuditPageSize)
+	var tenantID *string
+	if actor := ActorFromContext(ctx); !actor.AllTenants {
+		tenantID = &actor.TenantID
+	}
+	spec := specs.NewAuditSpecificationByTimeRange(from, to, afterID, limit, tenantID)
+	records, err := s.auditRepo.Query(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	events := []AuditEvent{}
+	for _, record := range records {
+		event := AuditEvent{
+			ID:         record.ID,
+			OccurredAt: record.OccurredAt,
+			Action:     record.Action,
+			Actor:      record.Actor,
+			UserID:     record.UserID,
+			Outcome:    record.Outcome,
+			ClientIP:   record.ClientIP,
+			RequestID:  record.RequestID,
+			Details:    record.Details,
+			TenantID:   record.TenantID,
+		}
+		if record.SignatureID != nil {
+			event.SignatureID = record.SignatureID.String()
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// recordAudit logs a failed audit write of an operation that has failed anyway.
+func recordAudit(ctx context.Context, audit AuditRecorder, event AuditEvent) error {
+	err := audit.Record(ctx, event)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not record an audit event", "action", event.Action, "error", err)
+	}
+	return err
+}
+
+func outcomeOf(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, ErrInvalidSignature):
+		return OutcomeInvalid
+	case errors.Is(err, ErrWrongOwner):
+		return OutcomeWrongOwner
+	case errors.Is(err, ErrLegalHold):
+		return OutcomeLegalHold
+	}
+	return OutcomeError
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+)
+
+// fakeAuditRepo keeps appended audit records.
+type fakeAuditRepo struct {
+	records []r.AuditRecord
+}
+
+func (f *fakeAuditRepo) Add(ctx context.Context, record r.AuditRecord) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+func (f *fakeAuditRepo) Query(ctx context.Context, spec r.Specification) ([]r.AuditRecord, error) {
+	return f.records, nil
+}
+
+func TestAuditRecordOccursAtClockTime(t *testing.T) {
+	repo := &fakeAuditRepo{}
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	service := NewAuditSvc(repo, clock)
+	ctx := ContextWithActor(context.Background(), Actor{ID: "client:auditor", TenantID: "acme", RequestID: "r1"})
+	event := AuditEvent{Action: ActionQueryAudit, Outcome: OutcomeSuccess}
+	if err := service.Record(ctx, event); err != nil {
+		t.Fatalf("can not record an event: %v", err)
+	}
+	if len(repo.records) != 1 {
+		t.Fatalf("unexpected records: %v", repo.records)
+	}
+	record := repo.records[0]
+	if !record.OccurredAt.Equal(clock.Now()) {
+		t.Errorf("a record occurred at %s, expected %s", record.OccurredAt, clock.Now())
+	}
+	if record.Actor != "client:auditor" || record.TenantID != "acme" || record.RequestID != "r1" {
+		t.Errorf("a record is not attributed to an actor: %+v", record)
+	}
+}
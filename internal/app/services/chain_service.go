@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
+)
+
+const chainPageSize = 500
+
+type ChainSvc struct {
+	signatureRepo r.SignatureRepository
+	audit         AuditRecorder
+}
+
+func NewChainSvc(repo r.SignatureRepository, audit AuditRecorder) *ChainSvc {
+	return &ChainSvc{repo, audit}
+}
+
+// VerifyChain walks signature records in chain order and reports
+// the first broken link. A repository must open encrypted answers, only
+// answers of erased signatures are not compared with their hash.
+func (s *ChainSvc) VerifyChain(ctx context.Context) (ChainReport, error) {
+	report, err := s.verifyChain(ctx)
+	outcome := outcomeOf(err)
+	details := map[string]any{"records": report.Records}
+	if err == nil && report.Break != nil {
+		outcome = OutcomeInvalid
+		details["broken_seq"] = report.Break.Seq
+	}
+	event := AuditEvent{Action: ActionVerifyChain, Outcome: outcome, Details: details}
+	if auditErr := recordAudit(ctx, s.audit, event); auditErr != nil && err == nil {
+		return ChainReport{}, auditErr
+	}
+	return report, err
+}
+
+func (s *ChainSvc) verifyChain(ctx context.Context) (ChainReport, error) {
+	report := ChainReport{}
+	expectedSeq := int64(1)
+	prevHash := r.GenesisHash
+	for {
+		spec := specs.NewSignatureChainSpecification(expectedSeq-1, chainPageSize)
+		signatures, err := s.signatureRepo.Query(ctx, spec)
+		if err != nil {
+			return report, err
+		}
+		for _, signature := range signatures {
+			if reason := checkChainLink(signature, expectedSeq, prevHash); reason != "" {
+				report.Break = &ChainBreak{
+					Seq:         expectedSeq,
+					SignatureID: signature.ID.String(),
+					Reason:      reason,
+				}
+				return report, nil
+			}
+			report.Records++
+			expectedSeq++
+			prevHash = signature.RecordHash
+		}
+		if len(signatures) < chainPageSize {
+			return report, nil
+		}
+	}
+}
+
+func checkChainLink(signature r.Signature, expectedSeq int64, prevHash []byte) string {
+	if signature.ChainSeq != expectedSeq {
+		return fmt.Sprintf(
+			"records %d-%d are missing",
+			expectedSeq,
+			signature.ChainSeq-1,
+		)
+	}
+	if !bytes.Equal(signature.PrevHash, prevHash) {
+		return "a previous hash does not match the previous record"
+	}
+	// a record hash still covers an answers hash of erased answers
+	if signature.ErasedAt == nil {
+		if signature.AnswersSealed {
+			return "answers can not be decrypted"
+		}
+		if !bytes.Equal(r.AnswersHash(signature.CommitmentKey, signature.Answers), signature.AnswersHash) {
+			return "answers do not match the answers hash"
+		}
+	}
+	if !bytes.Equal(r.RecordHash(signature), signature.RecordHash) {
+		return "record fields do not match the record hash"
+	}
+	return ""
+}
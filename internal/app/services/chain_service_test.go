@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+)
+
+// chainRecord is a first chain record, a nil key makes an unkeyed answers hash.
+func chainRecord(commitmentKey []byte, answers []r.TestDetails) r.Signature {
+	signature := r.Signature{
+		ID:            (&sequentialIDs{}).New(),
+		RequestID:     "r1",
+		UserID:        "u1",
+		CreatedAt:     testCreatedAt,
+		Answers:       answers,
+		ChainSeq:      1,
+		CommitmentKey: commitmentKey,
+		PrevHash:      r.GenesisHash,
+	}
+	signature.AnswersHash = r.AnswersHash(signature.CommitmentKey, answers)
+	signature.RecordHash = r.RecordHash(signature)
+	return signature
+}
+
+func TestCheckChainLinkAnswers(t *testing.T) {
+	answers := []r.TestDetails{{Question: "q1", Answer: "a1"}}
+	erasedAt := testCreatedAt
+	tests := []struct {
+		name   string
+		key    []byte
+		modify func(*r.Signature)
+		broken bool
+	}{
+		{name: "unkeyed answers", modify: func(*r.Signature) {}},
+		{name: "keyed answers", key: []byte("a commitment key"), modify: func(*r.Signature) {}},
+		{
+			name:   "keyed answers without a key",
+			key:    []byte("a commitment key"),
+			modify: func(s *r.Signature) { s.CommitmentKey = nil },
+			broken: true,
+		},
+		{
+			name:   "changed answers",
+			modify: func(s *r.Signature) { s.Answers = []r.TestDetails{{Question: "q1", Answer: "a2"}} },
+			broken: true,
+		},
+		{
+			name: "sealed answers",
+			modify: func(s *r.Signature) {
+				s.Answers = []r.TestDetails{}
+				s.AnswersSealed = true
+			},
+			broken: true,
+		},
+		{
+			name: "erased answers",
+			key:  []byte("a commitment key"),
+			modify: func(s *r.Signature) {
+				s.CommitmentKey = nil
+				s.Answers = []r.TestDetails{}
+				s.ErasedAt = &erasedAt
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signature := chainRecord(test.key, answers)
+			test.modify(&signature)
+			reason := checkChainLink(signature, 1, r.GenesisHash)
+			if (reason != "") != test.broken {
+				t.Errorf("unexpected reason: '%s', broken %t", reason, test.broken)
+			}
+		})
+	}
+}
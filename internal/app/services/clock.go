@@ -0,0 +1,27 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type IDGenerator interface {
+	New() uuid.UUID
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+type RandomIDGenerator struct{}
+
+func (RandomIDGenerator) New() uuid.UUID {
+	return uuid.New()
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+)
+
+type ErasureSvc struct {
+	erasureRepo r.ErasureRepository
+	audit       AuditRecorder
+	clock       Clock
+}
+
+func NewErasureSvc(repo r.ErasureRepository, audit AuditRecorder, clock Clock) *ErasureSvc {
+	return &ErasureSvc{repo, audit, clock}
+}
+
+// EraseUser erases answers of all signatures of a user on a request
+// to erasure. Signatures stay verifiable as tombstones, their
+// verification reports erased content. Repeated erasure erases nothing.
+// A user with signatures under a legal hold is not erased at all.
+func (s *ErasureSvc) EraseUser(ctx context.Context, owner Owner) (Erasure, error) {
+	erasedAt := s.clock.Now()
+	event := AuditEvent{
+		Action:  ActionEraseUser,
+		UserID:  owner.UserID,
+		Outcome: OutcomeSuccess,
+		Details: map[string]any{"issuer": owner.Issuer},
+	}
+	// shredded keys can not be restored, an erasure is not committed
+	// without its audit record
+	signatureIDs, err := s.erasureRepo.EraseSignatures(
+		ctx,
+		owner.TenantID,
+		owner.UserID,
+		owner.Issuer,
+		erasedAt,
+		newAuditRecord(ctx, event, erasedAt),
+	)
+	if errors.Is(err, r.ErrLegalHold) {
+		err = errors.Join(ErrLegalHold, err)
+	}
+	if err != nil {
+		event.Outcome = outcomeOf(err)
+		event.Details["signature_ids"] = []string{}
+		recordAudit(ctx, s.audit, event)
+		return Erasure{}, err
+	}
+	erasure := Erasure{
+		UserID:       owner.UserID,
+		Issuer:       owner.Issuer,
+		TenantID:     owner.TenantID,
+		SignatureIDs: []string{},
+		ErasedAt:     erasedAt,
+	}
+	for _, signatureID := range signatureIDs {
+		erasure.SignatureIDs = append(erasure.SignatureIDs, signatureID.String())
+	}
+	slog.InfoContext(
+		ctx,
+		"user signatures are erased",
+		"user_id", owner.UserID,
+		"signatures", len(erasure.SignatureIDs),
+	)
+	return erasure, nil
+}
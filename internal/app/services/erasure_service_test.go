@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/google/uuid"
+)
+
+// fakeErasureRepo erases signatures with an audit record committed together.
+type fakeErasureRepo struct {
+	signatureIDs []uuid.UUID
+	records      []r.AuditRecord
+	err          error
+}
+
+func (f *fakeErasureRepo) EraseSignatures(
+	ctx context.Context,
+	tenantID, userID, issuer string,
+	erasedAt time.Time,
+	record r.AuditRecord,
+) ([]uuid.UUID, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.records = append(f.records, record)
+	return f.signatureIDs, nil
+}
+
+func TestEraseUserIsAuditedWithErasure(t *testing.T) {
+	repo := &fakeErasureRepo{signatureIDs: []uuid.UUID{uuid.New()}}
+	audit := &fakeAudit{}
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	service := NewErasureSvc(repo, audit, clock)
+	ctx := ContextWithActor(context.Background(), Actor{ID: "client:dpo", TenantID: "acme"})
+
+	erasure, err := service.EraseUser(ctx, Owner{UserID: "u1", TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("can not erase a user: %v", err)
+	}
+	if !erasure.ErasedAt.Equal(clock.Now()) || len(erasure.SignatureIDs) != 1 {
+		t.Errorf("unexpected erasure: %+v", erasure)
+	}
+	if len(repo.records) != 1 {
+		t.Fatalf("unexpected committed audit records: %d", len(repo.records))
+	}
+	record := repo.records[0]
+	if record.Action != ActionEraseUser || record.Outcome != OutcomeSuccess || record.UserID != "u1" {
+		t.Errorf("unexpected record: %s %s of %s", record.Action, record.Outcome, record.UserID)
+	}
+	if len(audit.events) != 0 {
+		t.Errorf("an erasure is audited outside its transaction: %v", audit.events)
+	}
+}
+
+func TestEraseHeldUserIsAudited(t *testing.T) {
+	repo := &fakeErasureRepo{err: r.ErrLegalHold}
+	audit := &fakeAudit{}
+	service := NewErasureSvc(repo, audit, newFakeClock(time.Now()))
+
+	_, err := service.EraseUser(context.Background(), Owner{UserID: "u1"})
+	if !errors.Is(err, ErrLegalHold) {
+		t.Errorf("unexpected error of a held user: %v", err)
+	}
+	if event := audit.last(); event.Action != ActionEraseUser || event.Outcome != OutcomeLegalHold {
+		t.Errorf("unexpected audit event: %s %s", event.Action, event.Outcome)
+	}
+	if len(repo.records) != 0 {
+		t.Errorf("a refused erasure is committed with audit records: %d", len(repo.records))
+	}
+}
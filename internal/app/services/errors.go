@@ -6,4 +6,18 @@ var (
 	ErrInvalidSignature = errors.New("invalid signature")
 	ErrDuplicatedSignature = errors.New("signature already exists")
 	ErrWrongOwner = errors.New("a user does not own a signature")
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrVerifierNotFound = errors.New("a verifier does not exist")
+	ErrDuplicatedVerifier = errors.New("verifier already exists")
+	ErrUnknownScope = errors.New("unknown scope")
+	ErrTreeHeadNotFound = errors.New("a tree head does not exist")
+	ErrNotLogged = errors.New("a signature is not included in a published tree head")
+	ErrInvalidTreeSize = errors.New("invalid tree size")
+	ErrKeyNotLoaded = errors.New("a key is not loaded")
+	ErrUnknownCertificate = errors.New("a client certificate does not belong to a verifier")
+	ErrUnknownTenant = errors.New("a tenant has no signing keys")
+	ErrLegalHold = errors.New("signatures are under a legal hold")
+	ErrInvalidLegalHold = errors.New("a legal hold needs a reason and one of a signature ID, a user ID or a request ID pattern")
+	ErrLegalHoldNotFound = errors.New("an active legal hold does not exist")
+	ErrSignatureNotFound = errors.New("a signature does not exist")
 )
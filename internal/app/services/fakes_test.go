@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
+	"github.com/google/uuid"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(duration time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(duration)
+}
+
+// sequentialIDs returns version 4 UUIDs 00000000-0000-4000-8000-000000000001,
+// ...-000000000002 and so on.
+type sequentialIDs struct {
+	mu      sync.Mutex
+	counter uint64
+}
+
+func (g *sequentialIDs) New() uuid.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.counter++
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[8:], g.counter)
+	id[6] = 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return id
+}
+
+// fakeSignatureRepo keeps signatures in memory and enforces a unique
+// request of a user as a signatures table does.
+type fakeSignatureRepo struct {
+	mu         sync.Mutex
+	signatures []r.Signature
+}
+
+func (f *fakeSignatureRepo) Add(ctx context.Context, signature r.Signature) (*r.Signature, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, stored := range f.signatures {
+		if stored.TenantID == signature.TenantID &&
+			stored.Issuer == signature.Issuer &&
+			stored.UserID == signature.UserID &&
+			stored.RequestID == signature.RequestID {
+			return nil, r.ErrDuplicate
+		}
+	}
+	f.signatures = append(f.signatures, signature)
+	return &signature, nil
+}
+
+func (f *fakeSignatureRepo) Query(ctx context.Context, spec r.Specification) ([]r.Signature, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	byID, ok := spec.(specs.SignatureSpecificationByID)
+	if !ok {
+		return nil, r.ErrNotExist
+	}
+	signatures := []r.Signature{}
+	for _, stored := range f.signatures {
+		if stored.ID.String() == byID.ID && stored.TenantID == byID.TenantID {
+			signatures = append(signatures, stored)
+		}
+	}
+	return signatures, nil
+}
+
+// fakeAudit collects recorded events.
+type fakeAudit struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (f *fakeAudit) Record(ctx context.Context, event AuditEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeAudit) last() AuditEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.events) == 0 {
+		return AuditEvent{}
+	}
+	return f.events[len(f.events)-1]
+}
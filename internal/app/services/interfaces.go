@@ -1,8 +1,49 @@
 package services
 
-import "context"
+import (
+	"context"
+	"crypto/ed25519"
+	"io"
+	"time"
+
+	"github.com/AndreyAD1/test-signer/pkg/merkle"
+)
 
 type SignatureService interface {
-	CreateSignature(context.Context, string, string, []TestAnswer) ([]byte, error)
-	VerifySignature(context.Context, string, []byte) (StoredSignature, error)
+	CreateSignature(context.Context, string, Owner, []TestAnswer) ([]byte, error)
+	VerifySignature(context.Context, Owner, []byte) (StoredSignature, error)
+}
+
+type ErasureService interface {
+	EraseUser(context.Context, Owner) (Erasure, error)
+}
+
+type LegalHoldService interface {
+	PlaceHold(context.Context, LegalHold) (LegalHold, error)
+	LiftHold(context.Context, string) (LegalHold, error)
+	ListHolds(context.Context, bool) ([]LegalHold, error)
+}
+
+type VerifierService interface {
+	CreateVerifier(context.Context, string, []string, string, string) (Verifier, string, error)
+	ListVerifiers(context.Context) ([]Verifier, error)
+	RevokeVerifier(context.Context, string) error
+	AuthenticateAPIKey(context.Context, string) (Verifier, error)
+}
+
+type AuditRecorder interface {
+	Record(context.Context, AuditEvent) error
+}
+
+type AuditService interface {
+	AuditRecorder
+	QueryEvents(context.Context, time.Time, time.Time, int64, int) ([]AuditEvent, error)
+	ExportEvents(context.Context, time.Time, time.Time, io.Writer) error
+}
+
+type TransparencyService interface {
+	PublicKey() ed25519.PublicKey
+	TreeHead(context.Context, int64) (merkle.TreeHead, error)
+	InclusionProof(context.Context, int64, []byte) (InclusionProof, error)
+	ConsistencyProof(context.Context, int64, int64) ([][]byte, error)
 }
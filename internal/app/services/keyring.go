@@ -0,0 +1,125 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const signKeyLength = 32
+
+// Keyring seals signatures with a current key and opens signatures sealed
+// by the current or a retired key, so signing keys can rotate without
+// invalidating issued signatures.
+type Keyring struct {
+	current cipher.AEAD
+	retired []cipher.AEAD
+	// fingerprints of the current key and retired keys
+	fingerprints []string
+}
+
+// NewKeyring creates AES-256-GCM ciphers from the first 32 bytes of every key.
+func NewKeyring(current string, retired []string) (*Keyring, error) {
+	currentCipher, err := newSignCipher(current)
+	if err != nil {
+		return nil, err
+	}
+	keyring := Keyring{current: currentCipher, fingerprints: []string{fingerprint(current)}}
+	for i, key := range retired {
+		retiredCipher, err := newSignCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("a retired key %d: %w", i, err)
+		}
+		keyring.retired = append(keyring.retired, retiredCipher)
+		keyring.fingerprints = append(keyring.fingerprints, fingerprint(key))
+	}
+	return &keyring, nil
+}
+
+// Fingerprints identify the current key and retired keys without
+// disclosing them.
+func (k *Keyring) Fingerprints() (current string, retired []string) {
+	return k.fingerprints[0], k.fingerprints[1:]
+}
+
+func fingerprint(key string) string {
+	hash := sha256.Sum256(append([]byte("test-signer sign key\x00"), key[:signKeyLength]...))
+	return hex.EncodeToString(hash[:8])
+}
+
+func newSignCipher(key string) (cipher.AEAD, error) {
+	if len([]byte(key)) < signKeyLength {
+		return nil, fmt.Errorf("too short key: %d bytes, %d bytes required", len(key), signKeyLength)
+	}
+	block, err := aes.NewCipher([]byte(key)[:signKeyLength])
+	if err != nil {
+		return nil, fmt.Errorf("Error creating AES cipher: %w", err)
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating GCM: %w", err)
+	}
+	return aesgcm, nil
+}
+
+// Seal encrypts a plaintext with the current key and prepends a nonce.
+func (k *Keyring) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, k.current.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("Error generating nonce: %w", err)
+	}
+	return k.current.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts a sealed text with the first key which authenticates it.
+func (k *Keyring) Open(sealed []byte) ([]byte, error) {
+	nonceSize := k.current.NonceSize()
+	if len(sealed) <= nonceSize {
+		return nil, ErrInvalidSignature
+	}
+	nonce, ciphertext := sealed[:nonceSize], sealed[nonceSize:]
+	plaintext, err := k.current.Open(nil, nonce, ciphertext, nil)
+	for i := 0; err != nil && i < len(k.retired); i++ {
+		plaintext, err = k.retired[i].Open(nil, nonce, ciphertext, nil)
+	}
+	return plaintext, err
+}
+
+// Keyrings selects a keyring of a tenant. A default tenant has an empty ID.
+type Keyrings struct {
+	tenants map[string]*Keyring
+}
+
+func NewKeyrings(defaultKeyring *Keyring, tenants map[string]*Keyring) *Keyrings {
+	keyrings := Keyrings{tenants: map[string]*Keyring{"": defaultKeyring}}
+	for tenantID, keyring := range tenants {
+		if tenantID != "" {
+			keyrings.tenants[tenantID] = keyring
+		}
+	}
+	return &keyrings
+}
+
+// Tenant returns a keyring of a tenant, a tenant without keys can not sign.
+func (k *Keyrings) Tenant(tenantID string) (*Keyring, error) {
+	keyring, ok := k.tenants[tenantID]
+	if !ok || keyring == nil {
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownTenant, tenantID)
+	}
+	return keyring, nil
+}
+
+// Tenants lists tenant IDs in order, a default tenant is the first one.
+func (k *Keyrings) Tenants() []string {
+	tenantIDs := make([]string, 0, len(k.tenants))
+	for tenantID := range k.tenants {
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	sort.Strings(tenantIDs)
+	return tenantIDs
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
+	"github.com/google/uuid"
+)
+
+type LegalHoldSvc struct {
+	holdRepo r.LegalHoldRepository
+	audit    AuditRecorder
+	clock    Clock
+}
+
+func NewLegalHoldSvc(repo r.LegalHoldRepository, audit AuditRecorder, clock Clock) *LegalHoldSvc {
+	return &LegalHoldSvc{repo, audit, clock}
+}
+
+// PlaceHold freezes signatures of a hold tenant selected by exactly one of
+// a signature ID, a user ID or a request ID pattern. A pattern is an SQL
+// LIKE pattern. Held signatures are neither erased nor purged.
+func (s *LegalHoldSvc) PlaceHold(ctx context.Context, hold LegalHold) (LegalHold, error) {
+	targets := 0
+	storedHold := r.LegalHold{
+		ID:       uuid.New(),
+		TenantID: hold.TenantID,
+		Reason:   hold.Reason,
+		PlacedBy: ActorFromContext(ctx).ID,
+		PlacedAt: s.clock.Now(),
+	}
+	if hold.SignatureID != "" {
+		signatureID, err := uuid.Parse(hold.SignatureID)
+		if err != nil {
+			return LegalHold{}, fmt.Errorf("%w: '%s'", ErrSignatureNotFound, hold.SignatureID)
+		}
+		storedHold.SignatureID = &signatureID
+		targets++
+	}
+	if hold.UserID != "" {
+		storedHold.UserID = &hold.UserID
+		targets++
+	}
+	if hold.RequestIDPattern != "" {
+		storedHold.RequestIDPattern = &hold.RequestIDPattern
+		targets++
+	}
+	if targets != 1 || hold.Reason == "" {
+		return LegalHold{}, ErrInvalidLegalHold
+	}
+	event := AuditEvent{
+		Action:      ActionPlaceLegalHold,
+		SignatureID: hold.SignatureID,
+		UserID:      hold.UserID,
+		Outcome:     OutcomeSuccess,
+		Details: map[string]any{
+			"hold_id":            storedHold.ID.String(),
+			"tenant_id":          hold.TenantID,
+			"request_id_pattern": hold.RequestIDPattern,
+			"reason":             hold.Reason,
+		},
+	}
+	// a hold is not placed without its audit record
+	savedHold, err := s.holdRepo.Add(ctx, storedHold, newAuditRecord(ctx, event, storedHold.PlacedAt))
+	if err != nil {
+		event.Outcome = outcomeOf(err)
+		recordAudit(ctx, s.audit, event)
+	}
+	if errors.Is(err, r.ErrNoDependency) {
+		return LegalHold{}, errors.Join(ErrSignatureNotFound, err)
+	}
+	if err != nil {
+		return LegalHold{}, err
+	}
+	slog.InfoContext(ctx, "a legal hold is placed", "hold_id", savedHold.ID, "tenant_id", savedHold.TenantID)
+	return toLegalHold(*savedHold), nil
+}
+
+// LiftHold lifts an active hold of an actor tenant. A lifted hold stays
+// in a history of holds.
+func (s *LegalHoldSvc) LiftHold(ctx context.Context, id string) (LegalHold, error) {
+	holdID, err := uuid.Parse(id)
+	if err != nil {
+		return LegalHold{}, fmt.Errorf("%w: '%s'", ErrLegalHoldNotFound, id)
+	}
+	actor := ActorFromContext(ctx)
+	var tenantID *string
+	if !actor.AllTenants {
+		tenantID = &actor.TenantID
+	}
+	liftedAt := s.clock.Now()
+	event := AuditEvent{
+		Action:  ActionLiftLegalHold,
+		Outcome: OutcomeSuccess,
+		Details: map[string]any{"hold_id": id},
+	}
+	// a hold is not lifted without its audit record
+	liftedHold, err := s.holdRepo.Lift(
+		ctx,
+		holdID,
+		tenantID,
+		actor.ID,
+		liftedAt,
+		newAuditRecord(ctx, event, liftedAt),
+	)
+	if err != nil {
+		event.Outcome = outcomeOf(err)
+		recordAudit(ctx, s.audit, event)
+	}
+	if errors.Is(err, r.ErrNotExist) {
+		return LegalHold{}, errors.Join(ErrLegalHoldNotFound, err)
+	}
+	if err != nil {
+		return LegalHold{}, err
+	}
+	slog.InfoContext(ctx, "a legal hold is lifted", "hold_id", id)
+	return toLegalHold(*liftedHold), nil
+}
+
+// ListHolds lists active holds of an actor tenant, or all holds
+// including lifted ones.
+func (s *LegalHoldSvc) ListHolds(ctx context.Context, includeLifted bool) ([]LegalHold, error) {
+	var tenantID *string
+	if actor := ActorFromContext(ctx); !actor.AllTenants {
+		tenantID = &actor.TenantID
+	}
+	storedHolds, err := s.holdRepo.Query(ctx, specs.NewLegalHoldSpecification(tenantID, includeLifted))
+	if err != nil {
+		return nil, err
+	}
+	holds := []LegalHold{}
+	for _, hold := range storedHolds {
+		holds = append(holds, toLegalHold(hold))
+	}
+	return holds, nil
+}
+
+func toLegalHold(hold r.LegalHold) LegalHold {
+	result := LegalHold{
+		ID:               hold.ID.String(),
+		TenantID:         hold.TenantID,
+		UserID:           derefString(hold.UserID),
+		RequestIDPattern: derefString(hold.RequestIDPattern),
+		Reason:           hold.Reason,
+		PlacedBy:         hold.PlacedBy,
+		PlacedAt:         hold.PlacedAt,
+		LiftedBy:         derefString(hold.LiftedBy),
+		LiftedAt:         hold.LiftedAt,
+	}
+	if hold.SignatureID != nil {
+		result.SignatureID = hold.SignatureID.String()
+	}
+	return result
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/google/uuid"
+)
+
+// fakeLegalHoldRepo keeps holds with audit records committed together.
+// A failing repository refuses every change.
+type fakeLegalHoldRepo struct {
+	mu      sync.Mutex
+	holds   map[uuid.UUID]r.LegalHold
+	records []r.AuditRecord
+	err     error
+}
+
+func newFakeLegalHoldRepo() *fakeLegalHoldRepo {
+	return &fakeLegalHoldRepo{holds: map[uuid.UUID]r.LegalHold{}}
+}
+
+func (f *fakeLegalHoldRepo) Add(ctx context.Context, hold r.LegalHold, record r.AuditRecord) (*r.LegalHold, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.holds[hold.ID] = hold
+	f.records = append(f.records, record)
+	return &hold, nil
+}
+
+func (f *fakeLegalHoldRepo) Query(ctx context.Context, spec r.Specification) ([]r.LegalHold, error) {
+	return nil, r.ErrNotExist
+}
+
+func (f *fakeLegalHoldRepo) Lift(
+	ctx context.Context,
+	id uuid.UUID,
+	tenantID *string,
+	liftedBy string,
+	liftedAt time.Time,
+	record r.AuditRecord,
+) (*r.LegalHold, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	hold, ok := f.holds[id]
+	if !ok || hold.LiftedAt != nil || (tenantID != nil && *tenantID != hold.TenantID) {
+		return nil, r.ErrNotExist
+	}
+	hold.LiftedBy = &liftedBy
+	hold.LiftedAt = &liftedAt
+	f.holds[id] = hold
+	f.records = append(f.records, record)
+	return &hold, nil
+}
+
+func TestPlaceHoldTargets(t *testing.T) {
+	signatureID := uuid.NewString()
+	tests := []struct {
+		name  string
+		hold  LegalHold
+		valid bool
+	}{
+		{"a signature", LegalHold{SignatureID: signatureID, Reason: "litigation"}, true},
+		{"a user", LegalHold{UserID: "u1", Reason: "litigation"}, true},
+		{"a pattern", LegalHold{RequestIDPattern: "exam-%", Reason: "litigation"}, true},
+		{"no target", LegalHold{Reason: "litigation"}, false},
+		{"two targets", LegalHold{UserID: "u1", RequestIDPattern: "exam-%", Reason: "litigation"}, false},
+		{"no reason", LegalHold{UserID: "u1"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := newFakeLegalHoldRepo()
+			service := NewLegalHoldSvc(repo, &fakeAudit{}, newFakeClock(time.Now()))
+			_, err := service.PlaceHold(context.Background(), test.hold)
+			if test.valid && err != nil {
+				t.Errorf("a valid hold is refused: %v", err)
+			}
+			if !test.valid && !errors.Is(err, ErrInvalidLegalHold) {
+				t.Errorf("unexpected error of an invalid hold: %v", err)
+			}
+			if !test.valid && len(repo.holds) != 0 {
+				t.Error("an invalid hold is placed")
+			}
+		})
+	}
+}
+
+func TestLegalHoldChangesAreAuditedWithThem(t *testing.T) {
+	repo := newFakeLegalHoldRepo()
+	audit := &fakeAudit{}
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	service := NewLegalHoldSvc(repo, audit, clock)
+	ctx := ContextWithActor(context.Background(), Actor{ID: "client:legal", TenantID: "acme"})
+
+	hold, err := service.PlaceHold(ctx, LegalHold{TenantID: "acme", UserID: "u1", Reason: "litigation"})
+	if err != nil {
+		t.Fatalf("can not place a hold: %v", err)
+	}
+	if !hold.PlacedAt.Equal(clock.Now()) || hold.PlacedBy != "client:legal" {
+		t.Errorf("unexpected placement: %s by %s", hold.PlacedAt, hold.PlacedBy)
+	}
+	clock.Advance(time.Hour)
+	liftedHold, err := service.LiftHold(ctx, hold.ID)
+	if err != nil {
+		t.Fatalf("can not lift a hold: %v", err)
+	}
+	if liftedHold.LiftedAt == nil || !liftedHold.LiftedAt.Equal(clock.Now()) {
+		t.Errorf("unexpected lifting time: %v", liftedHold.LiftedAt)
+	}
+	if len(repo.records) != 2 {
+		t.Fatalf("unexpected committed audit records: %d", len(repo.records))
+	}
+	for i, action := range []string{ActionPlaceLegalHold, ActionLiftLegalHold} {
+		record := repo.records[i]
+		if record.Action != action || record.Outcome != OutcomeSuccess || record.Actor != "client:legal" {
+			t.Errorf("unexpected record: %s %s by %s", record.Action, record.Outcome, record.Actor)
+		}
+		if record.Details["hold_id"] != hold.ID {
+			t.Errorf("a record of %s has a hold %v", action, record.Details["hold_id"])
+		}
+	}
+	if len(audit.events) != 0 {
+		t.Errorf("successful changes are audited outside a transaction: %v", audit.events)
+	}
+}
+
+func TestLegalHoldFailuresAreAudited(t *testing.T) {
+	repo := newFakeLegalHoldRepo()
+	audit := &fakeAudit{}
+	service := NewLegalHoldSvc(repo, audit, newFakeClock(time.Now()))
+	ctx := ContextWithActor(context.Background(), Actor{ID: "client:legal", TenantID: "acme"})
+	hold, err := service.PlaceHold(ctx, LegalHold{TenantID: "acme", UserID: "u1", Reason: "litigation"})
+	if err != nil {
+		t.Fatalf("can not place a hold: %v", err)
+	}
+
+	otherCtx := ContextWithActor(context.Background(), Actor{ID: "client:other", TenantID: "other"})
+	if _, err := service.LiftHold(otherCtx, hold.ID); !errors.Is(err, ErrLegalHoldNotFound) {
+		t.Errorf("a hold is lifted by another tenant: %v", err)
+	}
+	if event := audit.last(); event.Action != ActionLiftLegalHold || event.Outcome != OutcomeError {
+		t.Errorf("unexpected audit event: %s %s", event.Action, event.Outcome)
+	}
+	repo.err = r.ErrNoDependency
+	_, err = service.PlaceHold(ctx, LegalHold{TenantID: "acme", SignatureID: uuid.NewString(), Reason: "litigation"})
+	if !errors.Is(err, ErrSignatureNotFound) {
+		t.Errorf("unexpected error of an unknown signature: %v", err)
+	}
+	if event := audit.last(); event.Action != ActionPlaceLegalHold || event.Outcome != OutcomeError {
+		t.Errorf("unexpected audit event: %s %s", event.Action, event.Outcome)
+	}
+	if len(repo.records) != 1 {
+		t.Errorf("failed changes are committed with audit records: %d", len(repo.records))
+	}
+}
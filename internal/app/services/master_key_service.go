@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+)
+
+const rewrapBatchSize = 500
+
+type MasterKeySvc struct {
+	dataKeyRepo r.DataKeyRepository
+	audit       AuditRecorder
+}
+
+func NewMasterKeySvc(repo r.DataKeyRepository, audit AuditRecorder) *MasterKeySvc {
+	return &MasterKeySvc{repo, audit}
+}
+
+// RewrapDataKeys wraps data keys of all signatures with a current master
+// key in batches, so a retired master key can be removed afterwards.
+// Encrypted answers are not changed.
+func (s *MasterKeySvc) RewrapDataKeys(ctx context.Context) (int64, error) {
+	var rewrapped int64
+	var err error
+	for {
+		var count int64
+		count, err = s.dataKeyRepo.RewrapDataKeys(ctx, rewrapBatchSize)
+		rewrapped += count
+		if err != nil || count < rewrapBatchSize {
+			break
+		}
+	}
+	event := AuditEvent{
+		Action:  ActionRewrapDataKeys,
+		Outcome: outcomeOf(err),
+		Details: map[string]any{"rewrapped": rewrapped},
+	}
+	if auditErr := recordAudit(ctx, s.audit, event); auditErr != nil && err == nil {
+		return rewrapped, auditErr
+	}
+	return rewrapped, err
+}
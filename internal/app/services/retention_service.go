@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
+	"github.com/google/uuid"
+)
+
+// RetentionPeriod is an age of signatures whose content expires.
+// A zero period never expires.
+type RetentionPeriod struct {
+	Years    int
+	Days     int
+	Duration time.Duration
+}
+
+func (p RetentionPeriod) IsZero() bool {
+	return p == RetentionPeriod{}
+}
+
+// RetentionRule is a period of signatures of a tenant, of a test identified
+// by a request ID pattern or both. A nil tenant matches all tenants.
+type RetentionRule struct {
+	TenantID         *string
+	RequestIDPattern string
+	Period           RetentionPeriod
+}
+
+// RetentionPolicy selects expired signatures by the most specific matching
+// rule: a tenant and a pattern, then a pattern, then a tenant, then a default.
+type RetentionPolicy struct {
+	Period    RetentionPeriod
+	Rules     []RetentionRule
+	Archive   bool
+	DryRun    bool
+	BatchSize int
+}
+
+// Enabled reports whether any signature can expire.
+func (p RetentionPolicy) Enabled() bool {
+	if !p.Period.IsZero() {
+		return true
+	}
+	for _, rule := range p.Rules {
+		if !rule.Period.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetentionPolicy) expiryRules(now time.Time) []specs.ExpiryRule {
+	rules := append([]RetentionRule{}, p.Rules...)
+	specificity := func(rule RetentionRule) int {
+		score := 0
+		if rule.RequestIDPattern != "" {
+			score += 2
+		}
+		if rule.TenantID != nil {
+			score++
+		}
+		return score
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return specificity(rules[i]) > specificity(rules[j])
+	})
+	rules = append(rules, RetentionRule{Period: p.Period})
+	expiryRules := []specs.ExpiryRule{}
+	for _, rule := range rules {
+		expiryRule := specs.ExpiryRule{
+			TenantID:         rule.TenantID,
+			RequestIDPattern: rule.RequestIDPattern,
+		}
+		if !rule.Period.IsZero() {
+			before := now.AddDate(-rule.Period.Years, 0, -rule.Period.Days).Add(-rule.Period.Duration)
+			expiryRule.Before = &before
+		}
+		expiryRules = append(expiryRules, expiryRule)
+	}
+	return expiryRules
+}
+
+type RetentionReport struct {
+	SignatureIDs []string
+	DryRun       bool
+	// Skipped is true if another replica was purging signatures.
+	Skipped bool
+}
+
+type RetentionSvc struct {
+	retentionRepo r.RetentionRepository
+	audit         AuditRecorder
+	clock         Clock
+	policy        atomic.Pointer[RetentionPolicy]
+}
+
+func NewRetentionSvc(
+	repo r.RetentionRepository,
+	audit AuditRecorder,
+	clock Clock,
+	policy RetentionPolicy,
+) *RetentionSvc {
+	service := RetentionSvc{retentionRepo: repo, audit: audit, clock: clock}
+	service.policy.Store(&policy)
+	return &service
+}
+
+// SetPolicy replaces a policy. A purge in progress finishes with
+// the previous policy.
+func (s *RetentionSvc) SetPolicy(policy RetentionPolicy) {
+	s.policy.Store(&policy)
+}
+
+// PurgeExpired purges or archives content of expired signatures in batches.
+// Signatures under a legal hold are kept. A dry run only reports
+// signatures which would be purged.
+func (s *RetentionSvc) PurgeExpired(ctx context.Context) (RetentionReport, error) {
+	policy := *s.policy.Load()
+	report := RetentionReport{SignatureIDs: []string{}, DryRun: policy.DryRun}
+	if !policy.Enabled() {
+		return report, nil
+	}
+	now := s.clock.Now()
+	rules := policy.expiryRules(now)
+	options := r.PurgeOptions{Archive: policy.Archive, DryRun: policy.DryRun, PurgedAt: now}
+	afterID := uuid.Nil
+	for {
+		spec := specs.NewExpiredSignatureSpecification(rules, afterID.String(), policy.BatchSize)
+		signatureIDs, err := s.retentionRepo.PurgeSignatures(ctx, spec, options)
+		if errors.Is(err, r.ErrLocked) {
+			report.Skipped = true
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+		batch := []string{}
+		for _, signatureID := range signatureIDs {
+			batch = append(batch, signatureID.String())
+		}
+		report.SignatureIDs = append(report.SignatureIDs, batch...)
+		if len(signatureIDs) > 0 && !policy.DryRun {
+			if err := s.recordPurge(ctx, batch, policy); err != nil {
+				return report, err
+			}
+		}
+		if len(signatureIDs) < policy.BatchSize {
+			return report, nil
+		}
+		afterID = signatureIDs[len(signatureIDs)-1]
+	}
+}
+
+func (s *RetentionSvc) recordPurge(ctx context.Context, signatureIDs []string, policy RetentionPolicy) error {
+	action := "purge"
+	if policy.Archive {
+		action = "archive"
+	}
+	event := AuditEvent{
+		Action:  ActionPurgeSignatures,
+		Outcome: OutcomeSuccess,
+		Details: map[string]any{"action": action, "signature_ids": signatureIDs},
+	}
+	return recordAudit(ctx, s.audit, event)
+}
+
+// Run purges expired signatures every interval until a context is done.
+func (s *RetentionSvc) Run(ctx context.Context, interval time.Duration) {
+	ctx = ContextWithActor(ctx, Actor{ID: "system:retention", AllTenants: true})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		report, err := s.PurgeExpired(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.ErrorContext(ctx, "can not purge expired signatures", "error", err)
+		case report.DryRun && len(report.SignatureIDs) > 0:
+			slog.InfoContext(ctx, "expired signatures would be purged", "signatures", len(report.SignatureIDs))
+		case len(report.SignatureIDs) > 0:
+			slog.InfoContext(ctx, "expired signatures are purged", "signatures", len(report.SignatureIDs))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
+	"github.com/google/uuid"
+)
+
+// fakeRetentionRepo returns batches in order and records specifications.
+type fakeRetentionRepo struct {
+	batches [][]uuid.UUID
+	specs   []specs.ExpiredSignatureSpecification
+}
+
+func (f *fakeRetentionRepo) PurgeSignatures(
+	ctx context.Context,
+	spec r.Specification,
+	options r.PurgeOptions,
+) ([]uuid.UUID, error) {
+	f.specs = append(f.specs, spec.(specs.ExpiredSignatureSpecification))
+	if len(f.batches) == 0 {
+		return nil, nil
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+func TestExpiryRulesBySpecificity(t *testing.T) {
+	tenantID := "acme"
+	period := RetentionPeriod{Days: 1}
+	policy := RetentionPolicy{
+		Period: RetentionPeriod{Years: 1},
+		Rules: []RetentionRule{
+			{TenantID: &tenantID, Period: period},
+			{RequestIDPattern: "exam-%", Period: period},
+			{TenantID: &tenantID, RequestIDPattern: "quiz-%", Period: period},
+		},
+	}
+	rules := policy.expiryRules(time.Now())
+	expected := []struct {
+		tenant  bool
+		pattern string
+	}{{true, "quiz-%"}, {false, "exam-%"}, {true, ""}, {false, ""}}
+	if len(rules) != len(expected) {
+		t.Fatalf("unexpected rules: %v", rules)
+	}
+	for i, rule := range rules {
+		if (rule.TenantID != nil) != expected[i].tenant || rule.RequestIDPattern != expected[i].pattern {
+			t.Errorf("a rule %d: %v %q", i, rule.TenantID, rule.RequestIDPattern)
+		}
+	}
+}
+
+func TestPurgeExpiredContinuesAfterLastID(t *testing.T) {
+	ids := &sequentialIDs{}
+	first, second, third := ids.New(), ids.New(), ids.New()
+	repo := &fakeRetentionRepo{batches: [][]uuid.UUID{{first, second}, {third}}}
+	audit := &fakeAudit{}
+	policy := RetentionPolicy{Period: RetentionPeriod{Days: 1}, BatchSize: 2}
+	service := NewRetentionSvc(repo, audit, newFakeClock(time.Now()), policy)
+
+	report, err := service.PurgeExpired(context.Background())
+	if err != nil {
+		t.Fatalf("can not purge expired signatures: %v", err)
+	}
+	if len(report.SignatureIDs) != 3 {
+		t.Errorf("unexpected purged signatures: %v", report.SignatureIDs)
+	}
+	if len(repo.specs) != 2 || repo.specs[0].AfterID != uuid.Nil.String() || repo.specs[1].AfterID != second.String() {
+		t.Errorf("unexpected batches: %+v", repo.specs)
+	}
+	if len(audit.events) != 2 {
+		t.Errorf("batches are purged with %d audit events", len(audit.events))
+	}
+}
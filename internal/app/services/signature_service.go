@@ -1,69 +1,108 @@
 package services
 
 import (
+	"bytes"
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
+	"crypto/sha256"
 	"errors"
 	"fmt"
-	"io"
-	"time"
+	"sync/atomic"
 
 	"encoding/json"
 
-	"log"
+	"log/slog"
 
 	"github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
 	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
 	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
-	"github.com/google/uuid"
+	"github.com/AndreyAD1/test-signer/internal/app/timestamping"
+	"github.com/AndreyAD1/test-signer/internal/app/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/AndreyAD1/test-signer/internal/app/services")
+
 type SignatureSvc struct {
 	signatureRepo r.SignatureRepository
-	cipher        cipher.AEAD
+	keyrings      atomic.Pointer[Keyrings]
+	audit         AuditRecorder
+	timestamps    timestamping.Authority
+	clock         Clock
+	ids           IDGenerator
 }
 
+// NewSignatureSvc creates a service. A nil timestamp authority disables
+// RFC 3161 timestamps.
 func NewSignatureSvc(
 	repo r.SignatureRepository,
-	key string,
+	keyrings *Keyrings,
+	audit AuditRecorder,
+	timestamps timestamping.Authority,
+	clock Clock,
+	ids IDGenerator,
 ) (*SignatureSvc, error) {
-	if len([]byte(key)) < 32 {
-		return nil, fmt.Errorf("too short key: %s", key)
+	if keyrings == nil {
+		return nil, ErrKeyNotLoaded
 	}
-	block, err := aes.NewCipher([]byte(key)[:32])
-	if err != nil {
-		log.Printf("Error creating AES cipher: %v", err)
-		return nil, fmt.Errorf("Error creating AES cipher: %w", err)
+	service := SignatureSvc{
+		signatureRepo: repo,
+		audit:         audit,
+		timestamps:    timestamps,
+		clock:         clock,
+		ids:           ids,
 	}
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		log.Printf("Error creating GCM: %v", err)
-		return nil, fmt.Errorf("Error creating GCM: %q", err)
-	}
-	return &SignatureSvc{repo, aesgcm}, nil
+	service.keyrings.Store(keyrings)
+	return &service, nil
+}
+
+// SetKeyrings replaces signing keys. Requests in progress finish
+// with the keys they have started with.
+func (s *SignatureSvc) SetKeyrings(keyrings *Keyrings) {
+	s.keyrings.Store(keyrings)
+}
+
+// Keyrings returns current signing keys of tenants.
+func (s *SignatureSvc) Keyrings() *Keyrings {
+	return s.keyrings.Load()
 }
 
 func (s *SignatureSvc) CreateSignature(
 	ctx context.Context,
 	requestID string,
-	userID string,
+	owner Owner,
 	testAnswers []TestAnswer,
-) ([]byte, error) {
-	signatureID := uuid.New()
-	externalSignature := ExternalSignature{signatureID.String(), userID}
+) (_ []byte, err error) {
+	ctx, span := tracer.Start(
+		ctx,
+		"SignatureSvc.CreateSignature",
+		trace.WithAttributes(tracing.AnswerCountKey.Int(len(testAnswers))),
+	)
+	defer func() { tracing.End(span, err) }()
+	signatureID := s.ids.New()
+	span.SetAttributes(tracing.SignatureIDKey.String(signatureID.String()))
+	keyring, err := s.keyrings.Load().Tenant(owner.TenantID)
+	if err != nil {
+		slog.WarnContext(ctx, "a tenant can not sign", "tenant_id", owner.TenantID)
+		return []byte{}, err
+	}
+	externalSignature := ExternalSignature{
+		signatureID.String(),
+		owner.UserID,
+		owner.Issuer,
+		owner.TenantID,
+	}
 	sign, err := json.Marshal(externalSignature)
 	if err != nil {
 		return []byte{}, err
 	}
-	nonce := make([]byte, 12)
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		log.Printf("Error generating nonce: %v", err)
-		return []byte{}, fmt.Errorf("Error generating nonce: %w", err)
+	_, sealSpan := tracer.Start(ctx, "SignatureSvc.seal")
+	ciphertext, err := keyring.Seal(sign)
+	tracing.End(sealSpan, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "can not seal a signature", "error", err)
+		return []byte{}, err
 	}
-	ciphertext := s.cipher.Seal(nil, nonce, sign, nil)
-	ciphertext = append(nonce, ciphertext...)
 
 	answers := []repositories.TestDetails{}
 	for _, a := range testAnswers {
@@ -71,36 +110,106 @@ func (s *SignatureSvc) CreateSignature(
 		answers = append(answers, d)
 	}
 	storageSignature := repositories.Signature{
-		ID: signatureID,
+		ID:        signatureID,
 		RequestID: requestID,
-		UserID: userID,
-		CreatedAt: time.Now(),
-		Answers: answers,
+		UserID:    owner.UserID,
+		Issuer:    owner.Issuer,
+		CreatedAt: s.clock.Now(),
+		Answers:   answers,
+		TenantID:  owner.TenantID,
+	}
+	if s.timestamps != nil {
+		digest := sha256.Sum256(ciphertext)
+		timestampCtx, timestampSpan := tracer.Start(ctx, "SignatureSvc.timestamp")
+		token, err := s.timestamps.Timestamp(timestampCtx, digest[:])
+		tracing.End(timestampSpan, err)
+		if err != nil {
+			slog.ErrorContext(ctx, "can not timestamp a signature", "user_id", owner.UserID, "error", err)
+			return []byte{}, fmt.Errorf("can not timestamp a signature: %w", err)
+		}
+		storageSignature.TimestampToken = token.Raw
+		storageSignature.TimestampedAt = &token.Time
 	}
 	if _, err = s.signatureRepo.Add(ctx, storageSignature); err != nil {
 		if errors.Is(err, repositories.ErrDuplicate) {
-			log.Printf("duplicated request from a user: %v", userID)
+			slog.InfoContext(ctx, "a duplicated request", "user_id", owner.UserID, "request_id", requestID)
 			return []byte{}, errors.Join(ErrDuplicatedSignature, err)
 		}
-		log.Printf("an unexpected repository error: %v: %v", userID, err)
+		slog.ErrorContext(ctx, "an unexpected repository error", "user_id", owner.UserID, "error", err)
 		return []byte{}, err
 	}
 	return ciphertext, nil
 }
 
-func (s *SignatureSvc) VerifySignature(ctx context.Context, username string, ciphered []byte) (StoredSignature, error) {
-	nonce, ciphered := ciphered[:12], ciphered[12:]
-	decyphered, err := s.cipher.Open(nil, nonce, ciphered, nil)
+// CheckKeys confirms signing keys of every tenant seal and open data.
+func (s *SignatureSvc) CheckKeys() error {
+	keyrings := s.keyrings.Load()
+	if keyrings == nil {
+		return ErrKeyNotLoaded
+	}
+	probe := []byte("readiness probe")
+	for _, tenantID := range keyrings.Tenants() {
+		keyring, err := keyrings.Tenant(tenantID)
+		if err != nil {
+			return errors.Join(ErrKeyNotLoaded, err)
+		}
+		sealed, err := keyring.Seal(probe)
+		if err != nil {
+			return errors.Join(ErrKeyNotLoaded, err)
+		}
+		opened, err := keyring.Open(sealed)
+		if err != nil || !bytes.Equal(opened, probe) {
+			return errors.Join(ErrKeyNotLoaded, err)
+		}
+	}
+	return nil
+}
+
+// VerifySignature records every verification attempt in an audit log.
+// Answers are not returned if a successful verification can not be audited.
+func (s *SignatureSvc) VerifySignature(ctx context.Context, owner Owner, ciphered []byte) (StoredSignature, error) {
+	ctx, span := tracer.Start(ctx, "SignatureSvc.VerifySignature")
+	defer span.End()
+	signature, err := s.verifySignature(ctx, owner, ciphered)
+	span.SetAttributes(tracing.SignatureIDKey.String(signature.ID))
+	tracing.RecordError(span, err)
+	event := AuditEvent{
+		Action:      ActionVerifySignature,
+		SignatureID: signature.ID,
+		UserID:      owner.UserID,
+		Outcome:     outcomeOf(err),
+	}
+	if auditErr := recordAudit(ctx, s.audit, event); auditErr != nil && err == nil {
+		return StoredSignature{}, auditErr
+	}
+	return signature, err
+}
+
+func (s *SignatureSvc) verifySignature(ctx context.Context, owner Owner, ciphered []byte) (StoredSignature, error) {
+	signatureDigest := sha256.Sum256(ciphered)
+	keyring, err := s.keyrings.Load().Tenant(owner.TenantID)
+	if err != nil {
+		slog.DebugContext(ctx, "a tenant can not verify", "tenant_id", owner.TenantID)
+		return StoredSignature{}, ErrInvalidSignature
+	}
+	_, openSpan := tracer.Start(ctx, "SignatureSvc.open")
+	decyphered, err := keyring.Open(ciphered)
+	openSpan.End()
 	if err != nil {
-		log.Printf("Error decrypting data: %v", err)
+		slog.DebugContext(ctx, "can not decrypt a signature", "error", err)
 		return StoredSignature{}, ErrInvalidSignature
 	}
 	var receivedSignature ExternalSignature
 	if err := json.Unmarshal(decyphered, &receivedSignature); err != nil {
-		log.Printf("can not unmarshal a decyphered signature: %v", err)
+		slog.WarnContext(ctx, "can not unmarshal a decrypted signature", "error", err)
 		return StoredSignature{}, ErrInvalidSignature
 	}
-	spec := specs.NewSignatureSpecificationByID(receivedSignature.ID)
+	// tenants may share a key, so a sealed tenant is checked and a query
+	// is scoped to a tenant of a caller as well
+	if receivedSignature.TenantID != owner.TenantID {
+		return StoredSignature{}, ErrInvalidSignature
+	}
+	spec := specs.NewSignatureSpecificationByID(receivedSignature.ID, owner.TenantID)
 	signatures, err := s.signatureRepo.Query(ctx, spec)
 	if err != nil {
 		return StoredSignature{}, err
@@ -109,12 +218,46 @@ func (s *SignatureSvc) VerifySignature(ctx context.Context, username string, cip
 		return StoredSignature{}, ErrInvalidSignature
 	}
 	foundSignature := signatures[0]
-	if receivedSignature.UserID != username {
-		return StoredSignature{}, ErrWrongOwner
+	signatureID := StoredSignature{ID: foundSignature.ID.String()}
+	if receivedSignature.UserID != owner.UserID || foundSignature.UserID != owner.UserID {
+		return signatureID, ErrWrongOwner
+	}
+	if foundSignature.TenantID != owner.TenantID {
+		return StoredSignature{}, ErrInvalidSignature
+	}
+	if receivedSignature.Issuer != foundSignature.Issuer {
+		slog.WarnContext(ctx, "a signature issuer mismatch", "signature_id", foundSignature.ID)
+		return signatureID, ErrInvalidSignature
+	}
+	if owner.Issuer != "" && owner.Issuer != foundSignature.Issuer {
+		return signatureID, ErrWrongOwner
+	}
+	if foundSignature.TimestampToken != nil {
+		_, err := timestamping.Verify(foundSignature.TimestampToken, signatureDigest[:], nil)
+		if err != nil {
+			slog.ErrorContext(
+				ctx,
+				"a stored timestamp token is invalid",
+				"signature_id", foundSignature.ID,
+				"error", err,
+			)
+			return signatureID, ErrInvalidSignature
+		}
 	}
 	answers := []string{}
 	for _, answer := range foundSignature.Answers {
 		answers = append(answers, answer.Answer)
 	}
-	return StoredSignature{Answers: answers, Timestamp: foundSignature.CreatedAt}, nil
+	storedSignature := StoredSignature{
+		ID:             signatureID.ID,
+		Answers:        answers,
+		Timestamp:      foundSignature.CreatedAt,
+		Issuer:         foundSignature.Issuer,
+		ChainSeq:       foundSignature.ChainSeq,
+		RecordHash:     foundSignature.RecordHash,
+		TimestampToken: foundSignature.TimestampToken,
+		TimestampedAt:  foundSignature.TimestampedAt,
+		ErasedAt:       foundSignature.ErasedAt,
+	}
+	return storedSignature, nil
 }
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCreatedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type signatureFixture struct {
+	service *SignatureSvc
+	repo    *fakeSignatureRepo
+	audit   *fakeAudit
+	clock   *fakeClock
+}
+
+// newSignatureFixture creates a service whose default tenant shares a key
+// with a tenant 'acme', a tenant 'other' has its own key.
+func newSignatureFixture(t *testing.T) signatureFixture {
+	t.Helper()
+	sharedKeyring, err := NewKeyring(strings.Repeat("s", signKeyLength), nil)
+	if err != nil {
+		t.Fatalf("can not create a keyring: %v", err)
+	}
+	otherKeyring, err := NewKeyring(strings.Repeat("o", signKeyLength), nil)
+	if err != nil {
+		t.Fatalf("can not create a keyring: %v", err)
+	}
+	keyrings := NewKeyrings(sharedKeyring, map[string]*Keyring{"acme": sharedKeyring, "other": otherKeyring})
+	fixture := signatureFixture{
+		repo:  &fakeSignatureRepo{},
+		audit: &fakeAudit{},
+		clock: newFakeClock(testCreatedAt),
+	}
+	fixture.service, err = NewSignatureSvc(fixture.repo, keyrings, fixture.audit, nil, fixture.clock, &sequentialIDs{})
+	if err != nil {
+		t.Fatalf("can not create a service: %v", err)
+	}
+	return fixture
+}
+
+func TestCreateSignature(t *testing.T) {
+	fixture := newSignatureFixture(t)
+	owner := Owner{UserID: "u1", Issuer: "local"}
+	answers := []TestAnswer{{Question: "q1", Answer: "a1"}}
+	for _, requestID := range []string{"r1", "r2"} {
+		if _, err := fixture.service.CreateSignature(context.Background(), requestID, owner, answers); err != nil {
+			t.Fatalf("can not create a signature of '%s': %v", requestID, err)
+		}
+		fixture.clock.Advance(time.Minute)
+	}
+
+	expectedIDs := []string{
+		"00000000-0000-4000-8000-000000000001",
+		"00000000-0000-4000-8000-000000000002",
+	}
+	if len(fixture.repo.signatures) != len(expectedIDs) {
+		t.Fatalf("unexpected stored signatures: %d", len(fixture.repo.signatures))
+	}
+	for i, signature := range fixture.repo.signatures {
+		if signature.ID.String() != expectedIDs[i] {
+			t.Errorf("unexpected ID: %s, expected %s", signature.ID, expectedIDs[i])
+		}
+		expectedCreatedAt := testCreatedAt.Add(time.Duration(i) * time.Minute)
+		if !signature.CreatedAt.Equal(expectedCreatedAt) {
+			t.Errorf("unexpected CreatedAt: %s, expected %s", signature.CreatedAt, expectedCreatedAt)
+		}
+		if len(signature.Answers) != 1 || signature.Answers[0].Answer != "a1" {
+			t.Errorf("unexpected answers: %v", signature.Answers)
+		}
+	}
+}
+
+func TestCreateSignatureDuplicate(t *testing.T) {
+	fixture := newSignatureFixture(t)
+	owner := Owner{UserID: "u1"}
+	answers := []TestAnswer{{Question: "q1", Answer: "a1"}}
+	if _, err := fixture.service.CreateSignature(context.Background(), "r1", owner, answers); err != nil {
+		t.Fatalf("can not create a signature: %v", err)
+	}
+	_, err := fixture.service.CreateSignature(context.Background(), "r1", owner, answers)
+	if !errors.Is(err, ErrDuplicatedSignature) {
+		t.Fatalf("unexpected error of a duplicate: %v", err)
+	}
+	if len(fixture.repo.signatures) != 1 {
+		t.Errorf("unexpected stored signatures: %d", len(fixture.repo.signatures))
+	}
+	// the same request of another user is not a duplicate
+	if _, err := fixture.service.CreateSignature(context.Background(), "r1", Owner{UserID: "u2"}, answers); err != nil {
+		t.Errorf("can not create a signature of another user: %v", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	fixture := newSignatureFixture(t)
+	signer := Owner{UserID: "u1", Issuer: "local"}
+	answers := []TestAnswer{{Question: "q1", Answer: "a1"}}
+	signature, err := fixture.service.CreateSignature(context.Background(), "r1", signer, answers)
+	if err != nil {
+		t.Fatalf("can not create a signature: %v", err)
+	}
+	signatureID := "00000000-0000-4000-8000-000000000001"
+
+	tests := []struct {
+		name      string
+		owner     Owner
+		signature []byte
+		err       error
+		outcome   string
+	}{
+		{name: "owner", owner: signer, signature: signature, outcome: OutcomeSuccess},
+		{
+			name:      "any issuer of an owner",
+			owner:     Owner{UserID: "u1"},
+			signature: signature,
+			outcome:   OutcomeSuccess,
+		},
+		{
+			name:      "wrong owner",
+			owner:     Owner{UserID: "u2", Issuer: "local"},
+			signature: signature,
+			err:       ErrWrongOwner,
+			outcome:   OutcomeWrongOwner,
+		},
+		{
+			name:      "wrong issuer",
+			owner:     Owner{UserID: "u1", Issuer: "partner"},
+			signature: signature,
+			err:       ErrWrongOwner,
+			outcome:   OutcomeWrongOwner,
+		},
+		{
+			name:      "tenant sharing a key",
+			owner:     Owner{UserID: "u1", Issuer: "local", TenantID: "acme"},
+			signature: signature,
+			err:       ErrInvalidSignature,
+			outcome:   OutcomeInvalid,
+		},
+		{
+			name:      "tenant of another key",
+			owner:     Owner{UserID: "u1", Issuer: "local", TenantID: "other"},
+			signature: signature,
+			err:       ErrInvalidSignature,
+			outcome:   OutcomeInvalid,
+		},
+		{
+			name:      "unknown tenant",
+			owner:     Owner{UserID: "u1", Issuer: "local", TenantID: "unknown"},
+			signature: signature,
+			err:       ErrInvalidSignature,
+			outcome:   OutcomeInvalid,
+		},
+		{
+			name:      "tampered signature",
+			owner:     signer,
+			signature: append([]byte{}, signature[:len(signature)-1]...),
+			err:       ErrInvalidSignature,
+			outcome:   OutcomeInvalid,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stored, err := fixture.service.VerifySignature(context.Background(), test.owner, test.signature)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("unexpected error: %v, expected %v", err, test.err)
+			}
+			event := fixture.audit.last()
+			if event.Action != ActionVerifySignature || event.Outcome != test.outcome {
+				t.Errorf("unexpected audit event: %s %s, expected %s", event.Action, event.Outcome, test.outcome)
+			}
+			if test.err != nil {
+				if len(stored.Answers) != 0 {
+					t.Errorf("answers are returned on an error: %v", stored.Answers)
+				}
+				return
+			}
+			if stored.ID != signatureID || event.SignatureID != signatureID {
+				t.Errorf("unexpected signature ID: %s, audited %s", stored.ID, event.SignatureID)
+			}
+			if !stored.Timestamp.Equal(testCreatedAt) {
+				t.Errorf("unexpected timestamp: %s, expected %s", stored.Timestamp, testCreatedAt)
+			}
+			if len(stored.Answers) != 1 || stored.Answers[0] != "a1" {
+				t.Errorf("unexpected answers: %v", stored.Answers)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/pkg/merkle"
+)
+
+// logBatchSize is a number of leaves read at once to hash subtrees.
+const logBatchSize = 10000
+
+// TransparencySvc maintains a Merkle log whose leaves are record hashes of
+// the signature chain. Leaf i is a signature with chain sequence i+1.
+type TransparencySvc struct {
+	logRepo r.TransparencyLogRepository
+	key     ed25519.PrivateKey
+}
+
+// NewTransparencySvc accepts a base64 encoded Ed25519 seed.
+func NewTransparencySvc(repo r.TransparencyLogRepository, encodedSeed string) (*TransparencySvc, error) {
+	seed, err := base64.StdEncoding.DecodeString(encodedSeed)
+	if err != nil {
+		return nil, fmt.Errorf("a log signing key is not base64: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("a log signing key must have %d bytes", ed25519.SeedSize)
+	}
+	return &TransparencySvc{repo, ed25519.NewKeyFromSeed(seed)}, nil
+}
+
+func (s *TransparencySvc) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// CheckKeys confirms the tree head signing key signs verifiable data.
+func (s *TransparencySvc) CheckKeys() error {
+	if len(s.key) != ed25519.PrivateKeySize {
+		return ErrKeyNotLoaded
+	}
+	probe := []byte("readiness probe")
+	if !ed25519.Verify(s.PublicKey(), probe, ed25519.Sign(s.key, probe)) {
+		return ErrKeyNotLoaded
+	}
+	return nil
+}
+
+// PublishTreeHead signs a head over all current leaves if the log has grown.
+func (s *TransparencySvc) PublishTreeHead(ctx context.Context) (merkle.TreeHead, error) {
+	size, err := s.logRepo.LeafCount(ctx)
+	if err != nil {
+		return merkle.TreeHead{}, err
+	}
+	// subtrees of published heads are hashed as well, e.g. after an upgrade
+	if err := s.appendLeaves(ctx, size); err != nil {
+		return merkle.TreeHead{}, err
+	}
+	latest, err := s.TreeHead(ctx, -1)
+	if err != nil && !errors.Is(err, ErrTreeHeadNotFound) {
+		return merkle.TreeHead{}, err
+	}
+	if err == nil && int64(latest.Size) >= size {
+		return latest, nil
+	}
+	rootHash, err := s.rangeHashes(ctx, []merkle.Range{{Start: 0, End: uint64(size)}})
+	if err != nil {
+		return merkle.TreeHead{}, err
+	}
+	head := merkle.SignTreeHead(s.key, merkle.TreeHead{
+		Size:      uint64(size),
+		Timestamp: time.Now(),
+		RootHash:  rootHash[0],
+	})
+	storedHead := r.TreeHead{
+		Size:      size,
+		RootHash:  head.RootHash,
+		CreatedAt: head.Timestamp,
+		Signature: head.Signature,
+	}
+	if err := s.logRepo.AddTreeHead(ctx, storedHead); err != nil {
+		return merkle.TreeHead{}, err
+	}
+	// another replica may have published the same size first
+	return s.TreeHead(ctx, size)
+}
+
+// RunPublisher publishes tree heads periodically until a context is done.
+func (s *TransparencySvc) RunPublisher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.PublishTreeHead(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "can not publish a tree head", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// TreeHead returns a published head of a given size or the latest one for size -1.
+func (s *TransparencySvc) TreeHead(ctx context.Context, size int64) (merkle.TreeHead, error) {
+	head, err := s.logRepo.TreeHead(ctx, size)
+	if errors.Is(err, r.ErrNotExist) {
+		return merkle.TreeHead{}, ErrTreeHeadNotFound
+	}
+	if err != nil {
+		return merkle.TreeHead{}, err
+	}
+	treeHead := merkle.TreeHead{
+		Size:      uint64(head.Size),
+		Timestamp: head.CreatedAt.UTC(),
+		RootHash:  head.RootHash,
+		Signature: head.Signature,
+	}
+	return treeHead, nil
+}
+
+// InclusionProof proves that a chain record is included in the latest
+// published tree head.
+func (s *TransparencySvc) InclusionProof(
+	ctx context.Context,
+	chainSeq int64,
+	recordHash []byte,
+) (InclusionProof, error) {
+	if chainSeq <= 0 {
+		return InclusionProof{}, ErrNotLogged
+	}
+	head, err := s.TreeHead(ctx, -1)
+	if err != nil {
+		return InclusionProof{}, err
+	}
+	leafIndex := uint64(chainSeq - 1)
+	if leafIndex >= head.Size {
+		return InclusionProof{}, ErrNotLogged
+	}
+	ranges, err := merkle.InclusionRanges(leafIndex, head.Size)
+	if err != nil {
+		return InclusionProof{}, err
+	}
+	path, err := s.rangeHashes(ctx, ranges)
+	if err != nil {
+		return InclusionProof{}, err
+	}
+	proof := InclusionProof{
+		LeafIndex:  leafIndex,
+		RecordHash: recordHash,
+		TreeHead:   head,
+		AuditPath:  path,
+	}
+	return proof, nil
+}
+
+// ConsistencyProof proves that a tree of the first size is a prefix of
+// a tree of the second size. The second size must not exceed the latest
+// published tree head.
+func (s *TransparencySvc) ConsistencyProof(ctx context.Context, first, second int64) ([][]byte, error) {
+	head, err := s.TreeHead(ctx, -1)
+	if err != nil {
+		return nil, err
+	}
+	if first < 0 || first > second || second > int64(head.Size) {
+		return nil, ErrInvalidTreeSize
+	}
+	ranges, err := merkle.ConsistencyRanges(uint64(first), uint64(second))
+	if err != nil {
+		return nil, err
+	}
+	return s.rangeHashes(ctx, ranges)
+}
+
+// appendLeaves stores hashes of subtrees completed by leaves up to a size
+// in batches, so proofs read O(log² n) stored hashes instead of all leaves.
+func (s *TransparencySvc) appendLeaves(ctx context.Context, size int64) error {
+	hashed, err := s.logRepo.HashedLeafCount(ctx)
+	if err != nil {
+		return err
+	}
+	for hashed < size {
+		batchEnd := min(size, hashed+logBatchSize)
+		leafData, err := s.logRepo.LeafData(ctx, hashed, batchEnd)
+		if err != nil {
+			return err
+		}
+		frontier, err := s.subtreeHashes(ctx, merkle.Range{Start: 0, End: uint64(hashed)}.Subtrees())
+		if err != nil {
+			return err
+		}
+		leafHashes := make([][]byte, 0, len(leafData))
+		for _, data := range leafData {
+			leafHashes = append(leafHashes, merkle.LeafHash(data))
+		}
+		added, err := merkle.AppendLeaves(uint64(hashed), frontier, leafHashes)
+		if err != nil {
+			return err
+		}
+		nodes := make([]r.TreeNode, 0, len(added))
+		for subtree, hash := range added {
+			nodes = append(nodes, r.TreeNode{Level: int16(subtree.Level), Index: int64(subtree.Index), Hash: hash})
+		}
+		if err := s.logRepo.AddTreeNodes(ctx, nodes); err != nil {
+			return err
+		}
+		hashed = batchEnd
+	}
+	return nil
+}
+
+// rangeHashes computes root hashes of ranges of leaves from stored subtrees.
+func (s *TransparencySvc) rangeHashes(ctx context.Context, ranges []merkle.Range) ([][]byte, error) {
+	subtrees := []merkle.Subtree{}
+	for _, leafRange := range ranges {
+		subtrees = append(subtrees, leafRange.Subtrees()...)
+	}
+	hashes, err := s.subtreeHashes(ctx, subtrees)
+	if err != nil {
+		return nil, err
+	}
+	rangeHashes := make([][]byte, 0, len(ranges))
+	for _, leafRange := range ranges {
+		hash, err := merkle.RangeHash(leafRange, hashes)
+		if err != nil {
+			return nil, err
+		}
+		rangeHashes = append(rangeHashes, hash)
+	}
+	return rangeHashes, nil
+}
+
+func (s *TransparencySvc) subtreeHashes(ctx context.Context, subtrees []merkle.Subtree) (map[merkle.Subtree][]byte, error) {
+	hashes := map[merkle.Subtree][]byte{}
+	if len(subtrees) == 0 {
+		return hashes, nil
+	}
+	keys := make([]r.TreeNode, 0, len(subtrees))
+	for _, subtree := range subtrees {
+		keys = append(keys, r.TreeNode{Level: int16(subtree.Level), Index: int64(subtree.Index)})
+	}
+	nodes, err := s.logRepo.TreeNodes(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		hashes[merkle.Subtree{Level: uint8(node.Level), Index: uint64(node.Index)}] = node.Hash
+	}
+	return hashes, nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"sync"
+	"testing"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/AndreyAD1/test-signer/pkg/merkle"
+)
+
+// fakeLogRepo keeps log leaves, tree nodes and heads in memory and counts
+// leaves read by services.
+type fakeLogRepo struct {
+	mu         sync.Mutex
+	leaves     [][]byte
+	nodes      map[[2]int64][]byte
+	heads      []r.TreeHead
+	leavesRead int64
+}
+
+func newFakeLogRepo(size int) *fakeLogRepo {
+	repo := fakeLogRepo{nodes: map[[2]int64][]byte{}}
+	for i := 0; i < size; i++ {
+		repo.leaves = append(repo.leaves, binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+	return &repo
+}
+
+func (f *fakeLogRepo) LeafCount(ctx context.Context) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.leaves)), nil
+}
+
+func (f *fakeLogRepo) LeafData(ctx context.Context, afterSeq, size int64) ([][]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if size > int64(len(f.leaves)) {
+		return nil, r.ErrNotExist
+	}
+	f.leavesRead += size - afterSeq
+	return f.leaves[afterSeq:size], nil
+}
+
+func (f *fakeLogRepo) HashedLeafCount(ctx context.Context) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var count int64
+	for key := range f.nodes {
+		if key[0] == 0 {
+			count = max(count, key[1]+1)
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeLogRepo) AddTreeNodes(ctx context.Context, nodes []r.TreeNode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, node := range nodes {
+		f.nodes[[2]int64{int64(node.Level), node.Index}] = node.Hash
+	}
+	return nil
+}
+
+func (f *fakeLogRepo) TreeNodes(ctx context.Context, keys []r.TreeNode) ([]r.TreeNode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	nodes := []r.TreeNode{}
+	for _, key := range keys {
+		if hash, ok := f.nodes[[2]int64{int64(key.Level), key.Index}]; ok {
+			nodes = append(nodes, r.TreeNode{Level: key.Level, Index: key.Index, Hash: hash})
+		}
+	}
+	return nodes, nil
+}
+
+func (f *fakeLogRepo) AddTreeHead(ctx context.Context, head r.TreeHead) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.heads = append(f.heads, head)
+	return nil
+}
+
+func (f *fakeLogRepo) TreeHead(ctx context.Context, size int64) (*r.TreeHead, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.heads) - 1; i >= 0; i-- {
+		if size < 0 || f.heads[i].Size == size {
+			head := f.heads[i]
+			return &head, nil
+		}
+	}
+	return nil, r.ErrNotExist
+}
+
+func (f *fakeLogRepo) append(count int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := 0; i < count; i++ {
+		f.leaves = append(f.leaves, binary.BigEndian.AppendUint64(nil, uint64(len(f.leaves))))
+	}
+}
+
+func newTestTransparencySvc(t *testing.T, repo *fakeLogRepo) *TransparencySvc {
+	t.Helper()
+	seed := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("l", ed25519.SeedSize)))
+	service, err := NewTransparencySvc(repo, seed)
+	if err != nil {
+		t.Fatalf("can not create a service: %v", err)
+	}
+	return service
+}
+
+func TestTransparencyProofs(t *testing.T) {
+	repo := newFakeLogRepo(logBatchSize + 21)
+	service := newTestTransparencySvc(t, repo)
+	ctx := context.Background()
+	first, err := service.PublishTreeHead(ctx)
+	if err != nil {
+		t.Fatalf("can not publish a tree head: %v", err)
+	}
+	repo.append(13)
+	second, err := service.PublishTreeHead(ctx)
+	if err != nil {
+		t.Fatalf("can not publish a tree head: %v", err)
+	}
+	if repo.leavesRead != logBatchSize+21+13 {
+		t.Errorf("leaves are read more than once: %d", repo.leavesRead)
+	}
+	leafHashes := make([][]byte, 0, len(repo.leaves))
+	for _, leaf := range repo.leaves {
+		leafHashes = append(leafHashes, merkle.LeafHash(leaf))
+	}
+	if !bytes.Equal(second.RootHash, merkle.RootHash(leafHashes)) {
+		t.Fatalf("unexpected root hash of size %d", second.Size)
+	}
+
+	for _, chainSeq := range []int64{1, 2, logBatchSize, int64(second.Size)} {
+		proof, err := service.InclusionProof(ctx, chainSeq, repo.leaves[chainSeq-1])
+		if err != nil {
+			t.Fatalf("can not prove an inclusion of %d: %v", chainSeq, err)
+		}
+		err = merkle.VerifyInclusion(
+			proof.LeafIndex,
+			proof.TreeHead.Size,
+			merkle.LeafHash(proof.RecordHash),
+			proof.AuditPath,
+			proof.TreeHead.RootHash,
+		)
+		if err != nil {
+			t.Errorf("an inclusion proof of %d is invalid: %v", chainSeq, err)
+		}
+	}
+	consistency, err := service.ConsistencyProof(ctx, int64(first.Size), int64(second.Size))
+	if err != nil {
+		t.Fatalf("can not prove a consistency: %v", err)
+	}
+	err = merkle.VerifyConsistency(first.Size, second.Size, first.RootHash, second.RootHash, consistency)
+	if err != nil {
+		t.Errorf("a consistency proof is invalid: %v", err)
+	}
+	if repo.leavesRead != logBatchSize+21+13 {
+		t.Errorf("proofs read log leaves: %d", repo.leavesRead)
+	}
+}
+
+func TestTransparencyProofsAfterUpgrade(t *testing.T) {
+	repo := newFakeLogRepo(10)
+	// a head is published before subtree hashes are stored
+	repo.heads = append(repo.heads, r.TreeHead{Size: 10, RootHash: []byte("root")})
+	service := newTestTransparencySvc(t, repo)
+	if _, err := service.PublishTreeHead(context.Background()); err != nil {
+		t.Fatalf("can not publish a tree head: %v", err)
+	}
+	if _, err := service.ConsistencyProof(context.Background(), 3, 10); err != nil {
+		t.Errorf("subtrees of a published head are not hashed: %v", err)
+	}
+}
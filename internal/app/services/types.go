@@ -1,18 +1,121 @@
 package services
 
-import "time"
+import (
+	"time"
+
+	"github.com/AndreyAD1/test-signer/pkg/merkle"
+)
 
 type TestAnswer struct {
 	Question string
 	Answer   string
 }
 
+// Owner identifies a user by an identity provider. An empty issuer
+// of a verification request matches any issuer. A tenant of a verification
+// request is a tenant of a verifier, never a value of a request body.
+type Owner struct {
+	UserID   string
+	Issuer   string
+	TenantID string
+}
+
 type ExternalSignature struct {
-	ID     string `json:"id"`
-	UserID string `json:"user_id"`
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	Issuer   string `json:"iss,omitempty"`
+	TenantID string `json:"tenant,omitempty"`
 }
 
 type StoredSignature struct {
+	ID string `json:"id"`
 	Answers []string `json:"answers"`
 	Timestamp time.Time `json:"timestamp"`
+	Issuer string `json:"issuer"`
+	ChainSeq int64 `json:"-"`
+	RecordHash []byte `json:"-"`
+	TimestampToken []byte `json:"timestamp_token,omitempty"`
+	TimestampedAt *time.Time `json:"timestamped_at,omitempty"`
+	// ErasedAt is set if answers are erased, a signature itself stays valid.
+	ErasedAt *time.Time `json:"erased_at,omitempty"`
+}
+
+// Erasure reports signatures of a user whose content is erased.
+type Erasure struct {
+	UserID       string    `json:"user_id"`
+	Issuer       string    `json:"issuer,omitempty"`
+	TenantID     string    `json:"tenant_id,omitempty"`
+	SignatureIDs []string  `json:"signature_ids"`
+	ErasedAt     time.Time `json:"erased_at"`
+}
+
+// LegalHold freezes signatures of a tenant until it is lifted: a signature,
+// all signatures of a user or of tests whose request IDs match a pattern.
+type LegalHold struct {
+	ID               string     `json:"id"`
+	TenantID         string     `json:"tenant_id,omitempty"`
+	SignatureID      string     `json:"signature_id,omitempty"`
+	UserID           string     `json:"user_id,omitempty"`
+	RequestIDPattern string     `json:"request_id_pattern,omitempty"`
+	Reason           string     `json:"reason"`
+	PlacedBy         string     `json:"placed_by"`
+	PlacedAt         time.Time  `json:"placed_at"`
+	LiftedBy         string     `json:"lifted_by,omitempty"`
+	LiftedAt         *time.Time `json:"lifted_at,omitempty"`
+}
+
+type Verifier struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Scopes       []string   `json:"scopes"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CertIdentity string     `json:"cert_identity,omitempty"`
+	TenantID     string     `json:"tenant_id,omitempty"`
+}
+
+// Actor is an identity performing an operation, e.g. a verifier client
+// or an administrator. An actor reads audit events of its tenant only,
+// unless it operates all tenants.
+type Actor struct {
+	ID         string
+	ClientIP   string
+	RequestID  string
+	TenantID   string
+	AllTenants bool
+}
+
+type AuditEvent struct {
+	ID          int64          `json:"id"`
+	OccurredAt  time.Time      `json:"occurred_at"`
+	Action      string         `json:"action"`
+	Actor       string         `json:"actor"`
+	SignatureID string         `json:"signature_id,omitempty"`
+	UserID      string         `json:"user_id,omitempty"`
+	Outcome     string         `json:"outcome"`
+	ClientIP    string         `json:"client_ip,omitempty"`
+	RequestID   string         `json:"request_id,omitempty"`
+	Details     map[string]any `json:"details,omitempty"`
+	TenantID    string         `json:"tenant_id,omitempty"`
+}
+
+type ChainReport struct {
+	Records int64
+	Break   *ChainBreak
+}
+
+// ChainBreak is the first chain record that does not link to its predecessor.
+type ChainBreak struct {
+	Seq         int64
+	SignatureID string
+	Reason      string
+}
+
+// InclusionProof is an RFC 9162 audit path of a signature record hash.
+// A leaf hash is merkle.LeafHash(RecordHash).
+type InclusionProof struct {
+	LeafIndex  uint64          `json:"leaf_index"`
+	RecordHash []byte          `json:"record_hash"`
+	TreeHead   merkle.TreeHead `json:"tree_head"`
+	AuditPath  [][]byte        `json:"audit_path"`
 }
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	specs "github.com/AndreyAD1/test-signer/internal/app/infrastructure/specifications"
+	"github.com/google/uuid"
+)
+
+const (
+	ScopeVerify      = "signatures:verify"
+	ScopeReadAnswers = "signatures:read-answers"
+	ScopeAuditRead   = "audit:read"
+	ScopeErase       = "signatures:erase"
+	ScopeLegalHold   = "signatures:hold"
+)
+
+var KnownScopes = []string{ScopeVerify, ScopeReadAnswers, ScopeAuditRead, ScopeErase, ScopeLegalHold}
+
+const apiKeySecretLength = 32
+
+type VerifierSvc struct {
+	verifierRepo r.VerifierRepository
+	audit        AuditRecorder
+	clock        Clock
+}
+
+func NewVerifierSvc(repo r.VerifierRepository, audit AuditRecorder, clock Clock) *VerifierSvc {
+	return &VerifierSvc{repo, audit, clock}
+}
+
+// CreateVerifier registers a verifier client and returns its API key.
+// The key is shown only once, a repository keeps its hash. A non-empty
+// certificate identity also lets a verifier authenticate with mutual TLS.
+// A verifier verifies signatures of its tenant only.
+func (s *VerifierSvc) CreateVerifier(
+	ctx context.Context,
+	name string,
+	scopes []string,
+	certIdentity string,
+	tenantID string,
+) (Verifier, string, error) {
+	for _, scope := range scopes {
+		if !isKnownScope(scope) {
+			return Verifier{}, "", fmt.Errorf("%w: '%s'", ErrUnknownScope, scope)
+		}
+	}
+	secret := make([]byte, apiKeySecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return Verifier{}, "", fmt.Errorf("can not generate an API key: %w", err)
+	}
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+	verifier := r.Verifier{
+		ID:        uuid.New(),
+		Name:      name,
+		KeyHash:   hashAPIKeySecret(encodedSecret),
+		Scopes:    scopes,
+		CreatedAt: s.clock.Now(),
+		TenantID:  tenantID,
+	}
+	if certIdentity != "" {
+		verifier.CertIdentity = &certIdentity
+	}
+	event := AuditEvent{
+		Action:  ActionCreateVerifier,
+		Outcome: OutcomeSuccess,
+		Details: map[string]any{
+			"verifier_id":   verifier.ID.String(),
+			"name":          name,
+			"scopes":        scopes,
+			"cert_identity": certIdentity,
+			"tenant_id":     tenantID,
+		},
+	}
+	// a verifier is not created without its audit record
+	savedVerifier, err := s.verifierRepo.Add(ctx, verifier, newAuditRecord(ctx, event, verifier.CreatedAt))
+	if err != nil {
+		event.Outcome = outcomeOf(err)
+		recordAudit(ctx, s.audit, event)
+	}
+	if errors.Is(err, r.ErrDuplicate) {
+		return Verifier{}, "", errors.Join(ErrDuplicatedVerifier, err)
+	}
+	if err != nil {
+		return Verifier{}, "", err
+	}
+	apiKey := savedVerifier.ID.String() + "." + encodedSecret
+	return toVerifier(*savedVerifier), apiKey, nil
+}
+
+func (s *VerifierSvc) ListVerifiers(ctx context.Context) ([]Verifier, error) {
+	storedVerifiers, err := s.verifierRepo.Query(ctx, specs.NewAllVerifiersSpecification())
+	if err != nil {
+		return nil, err
+	}
+	verifiers := []Verifier{}
+	for _, verifier := range storedVerifiers {
+		verifiers = append(verifiers, toVerifier(verifier))
+	}
+	return verifiers, nil
+}
+
+func (s *VerifierSvc) RevokeVerifier(ctx context.Context, id string) error {
+	verifierID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%w: '%s'", ErrVerifierNotFound, id)
+	}
+	revokedAt := s.clock.Now()
+	event := AuditEvent{
+		Action:  ActionRevokeVerifier,
+		Outcome: OutcomeSuccess,
+		Details: map[string]any{"verifier_id": id},
+	}
+	err = s.verifierRepo.Revoke(ctx, verifierID, revokedAt, newAuditRecord(ctx, event, revokedAt))
+	if err != nil {
+		event.Outcome = outcomeOf(err)
+		recordAudit(ctx, s.audit, event)
+	}
+	if errors.Is(err, r.ErrNotExist) {
+		return errors.Join(ErrVerifierNotFound, err)
+	}
+	return err
+}
+
+// AuthenticateAPIKey resolves an API key of the form '<verifier ID>.<secret>'.
+func (s *VerifierSvc) AuthenticateAPIKey(ctx context.Context, apiKey string) (Verifier, error) {
+	id, secret, ok := strings.Cut(apiKey, ".")
+	if !ok {
+		return Verifier{}, ErrInvalidAPIKey
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return Verifier{}, ErrInvalidAPIKey
+	}
+	verifiers, err := s.verifierRepo.Query(ctx, specs.NewVerifierSpecificationByID(id))
+	if err != nil {
+		return Verifier{}, err
+	}
+	if len(verifiers) == 0 {
+		return Verifier{}, ErrInvalidAPIKey
+	}
+	verifier := verifiers[0]
+	if subtle.ConstantTimeCompare(verifier.KeyHash, hashAPIKeySecret(secret)) != 1 {
+		return Verifier{}, ErrInvalidAPIKey
+	}
+	if verifier.RevokedAt != nil {
+		slog.WarnContext(ctx, "a revoked verifier key is used", "verifier_id", verifier.ID)
+		return Verifier{}, ErrInvalidAPIKey
+	}
+	return toVerifier(verifier), nil
+}
+
+// AuthenticateCertificate resolves a verifier by an identity of
+// a client certificate which a TLS handshake has already verified.
+func (s *VerifierSvc) AuthenticateCertificate(ctx context.Context, identity string) (Verifier, error) {
+	if identity == "" {
+		return Verifier{}, ErrUnknownCertificate
+	}
+	spec := specs.NewVerifierSpecificationByCertIdentity(identity)
+	verifiers, err := s.verifierRepo.Query(ctx, spec)
+	if err != nil {
+		return Verifier{}, err
+	}
+	if len(verifiers) == 0 {
+		return Verifier{}, ErrUnknownCertificate
+	}
+	verifier := verifiers[0]
+	if verifier.RevokedAt != nil {
+		slog.WarnContext(ctx, "a revoked verifier certificate is used", "verifier_id", verifier.ID)
+		return Verifier{}, ErrUnknownCertificate
+	}
+	return toVerifier(verifier), nil
+}
+
+func hashAPIKeySecret(secret string) []byte {
+	hash := sha256.Sum256([]byte(secret))
+	return hash[:]
+}
+
+func isKnownScope(scope string) bool {
+	for _, knownScope := range KnownScopes {
+		if scope == knownScope {
+			return true
+		}
+	}
+	return false
+}
+
+func toVerifier(verifier r.Verifier) Verifier {
+	result := Verifier{
+		ID:        verifier.ID.String(),
+		Name:      verifier.Name,
+		Scopes:    verifier.Scopes,
+		CreatedAt: verifier.CreatedAt,
+		RevokedAt: verifier.RevokedAt,
+		TenantID:  verifier.TenantID,
+	}
+	if verifier.CertIdentity != nil {
+		result.CertIdentity = *verifier.CertIdentity
+	}
+	return result
+}
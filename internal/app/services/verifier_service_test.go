@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	r "github.com/AndreyAD1/test-signer/internal/app/infrastructure/repositories"
+	"github.com/google/uuid"
+)
+
+// fakeVerifierRepo keeps verifiers with audit records committed together.
+type fakeVerifierRepo struct {
+	mu        sync.Mutex
+	verifiers map[uuid.UUID]r.Verifier
+	records   []r.AuditRecord
+}
+
+func newFakeVerifierRepo() *fakeVerifierRepo {
+	return &fakeVerifierRepo{verifiers: map[uuid.UUID]r.Verifier{}}
+}
+
+func (f *fakeVerifierRepo) Add(ctx context.Context, verifier r.Verifier, record r.AuditRecord) (*r.Verifier, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, stored := range f.verifiers {
+		if stored.Name == verifier.Name {
+			return nil, r.ErrDuplicate
+		}
+	}
+	f.verifiers[verifier.ID] = verifier
+	f.records = append(f.records, record)
+	return &verifier, nil
+}
+
+func (f *fakeVerifierRepo) Query(ctx context.Context, spec r.Specification) ([]r.Verifier, error) {
+	return nil, r.ErrNotExist
+}
+
+func (f *fakeVerifierRepo) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time, record r.AuditRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	verifier, ok := f.verifiers[id]
+	if !ok || verifier.RevokedAt != nil {
+		return r.ErrNotExist
+	}
+	verifier.RevokedAt = &revokedAt
+	f.verifiers[id] = verifier
+	f.records = append(f.records, record)
+	return nil
+}
+
+func TestVerifierChangesAreAuditedWithThem(t *testing.T) {
+	repo := newFakeVerifierRepo()
+	audit := &fakeAudit{}
+	clock := newFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	service := NewVerifierSvc(repo, audit, clock)
+	ctx := ContextWithActor(context.Background(), Actor{ID: "cli:admin", AllTenants: true})
+
+	verifier, _, err := service.CreateVerifier(ctx, "exam-board", []string{ScopeVerify}, "", "")
+	if err != nil {
+		t.Fatalf("can not create a verifier: %v", err)
+	}
+	if !verifier.CreatedAt.Equal(clock.Now()) {
+		t.Errorf("a verifier is created at %s, expected %s", verifier.CreatedAt, clock.Now())
+	}
+	createdAt := clock.Now()
+	clock.Advance(time.Hour)
+	if err := service.RevokeVerifier(ctx, verifier.ID); err != nil {
+		t.Fatalf("can not revoke a verifier: %v", err)
+	}
+	revokedAt := repo.verifiers[uuid.MustParse(verifier.ID)].RevokedAt
+	if revokedAt == nil || !revokedAt.Equal(clock.Now()) {
+		t.Errorf("a verifier is revoked at %v, expected %s", revokedAt, clock.Now())
+	}
+	if len(repo.records) != 2 {
+		t.Fatalf("unexpected committed audit records: %d", len(repo.records))
+	}
+	occurredAt := []time.Time{createdAt, clock.Now()}
+	for i, action := range []string{ActionCreateVerifier, ActionRevokeVerifier} {
+		record := repo.records[i]
+		if record.Action != action || record.Outcome != OutcomeSuccess || record.Actor != "cli:admin" {
+			t.Errorf("unexpected record: %s %s by %s", record.Action, record.Outcome, record.Actor)
+		}
+		if !record.OccurredAt.Equal(occurredAt[i]) {
+			t.Errorf("a record of %s occurred at %s, expected %s", action, record.OccurredAt, occurredAt[i])
+		}
+		if record.Details["verifier_id"] != verifier.ID {
+			t.Errorf("a record of %s has a verifier %v", action, record.Details["verifier_id"])
+		}
+	}
+	if len(audit.events) != 0 {
+		t.Errorf("successful changes are audited outside a transaction: %v", audit.events)
+	}
+}
+
+func TestVerifierFailuresAreAudited(t *testing.T) {
+	repo := newFakeVerifierRepo()
+	audit := &fakeAudit{}
+	service := NewVerifierSvc(repo, audit, newFakeClock(time.Now()))
+	ctx := context.Background()
+	if _, _, err := service.CreateVerifier(ctx, "exam-board", []string{ScopeVerify}, "", ""); err != nil {
+		t.Fatalf("can not create a verifier: %v", err)
+	}
+
+	_, _, err := service.CreateVerifier(ctx, "exam-board", []string{ScopeVerify}, "", "")
+	if !errors.Is(err, ErrDuplicatedVerifier) {
+		t.Errorf("unexpected error of a duplicate: %v", err)
+	}
+	if event := audit.last(); event.Action != ActionCreateVerifier || event.Outcome != OutcomeError {
+		t.Errorf("unexpected audit event: %s %s", event.Action, event.Outcome)
+	}
+	err = service.RevokeVerifier(ctx, uuid.NewString())
+	if !errors.Is(err, ErrVerifierNotFound) {
+		t.Errorf("unexpected error of an unknown verifier: %v", err)
+	}
+	if event := audit.last(); event.Action != ActionRevokeVerifier || event.Outcome != OutcomeError {
+		t.Errorf("unexpected audit event: %s %s", event.Action, event.Outcome)
+	}
+	if len(repo.records) != 1 {
+		t.Errorf("failed changes are committed with audit records: %d", len(repo.records))
+	}
+}
@@ -0,0 +1,77 @@
+package timestamping
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/digitorus/timestamp"
+)
+
+const (
+	queryContentType = "application/timestamp-query"
+	replyContentType = "application/timestamp-reply"
+	maxReplyBytes    = 1 << 20
+)
+
+// Client requests timestamp tokens from a remote TSA over HTTP.
+type Client struct {
+	url    string
+	client *http.Client
+	roots  *x509.CertPool
+}
+
+// NewClient creates a TSA client. Tokens are checked against roots
+// unless they are nil.
+func NewClient(url string, timeout time.Duration, roots *x509.CertPool) *Client {
+	return &Client{url, &http.Client{Timeout: timeout}, roots}
+}
+
+func (c *Client) Timestamp(ctx context.Context, digest []byte) (Token, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return Token{}, err
+	}
+	tsRequest := timestamp.Request{
+		HashAlgorithm: crypto.SHA256,
+		HashedMessage: digest,
+		Certificates:  true,
+		Nonce:         nonce,
+	}
+	body, err := tsRequest.Marshal()
+	if err != nil {
+		return Token{}, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Token{}, err
+	}
+	request.Header.Set("Content-Type", queryContentType)
+	response, err := c.client.Do(request)
+	if err != nil {
+		return Token{}, fmt.Errorf("a TSA request error: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("unexpected TSA response status: %s", response.Status)
+	}
+	reply, err := io.ReadAll(io.LimitReader(response.Body, maxReplyBytes))
+	if err != nil {
+		return Token{}, err
+	}
+	parsed, err := timestamp.ParseResponse(reply)
+	if err != nil {
+		return Token{}, fmt.Errorf("invalid TSA response: %w", err)
+	}
+	if parsed.Nonce == nil || parsed.Nonce.Cmp(nonce) != 0 {
+		return Token{}, fmt.Errorf("a TSA response nonce does not match")
+	}
+	return Verify(parsed.RawToken, digest, c.roots)
+}
@@ -0,0 +1,141 @@
+package timestamping
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/digitorus/timestamp"
+)
+
+// localPolicy is a TSA policy OID of tokens issued by LocalAuthority.
+var localPolicy = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 0, 3161, 1}
+
+// LocalAuthority is a built-in TSA for tests and air-gapped deployments.
+// It signs tokens in-process and can serve RFC 3161 requests over HTTP.
+type LocalAuthority struct {
+	certificate *x509.Certificate
+	signer      crypto.Signer
+}
+
+// NewLocalAuthority loads a TSA certificate and a key from PEM files.
+// The certificate must have the timeStamping extended key usage.
+func NewLocalAuthority(certFile, keyFile string) (*LocalAuthority, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("can not load a TSA key pair: %w", err)
+	}
+	certificate, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("a TSA key can not sign")
+	}
+	return &LocalAuthority{certificate, signer}, nil
+}
+
+// NewEphemeralLocalAuthority generates a self-signed TSA certificate that
+// lives as long as a process.
+func NewEphemeralLocalAuthority() (*LocalAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: "test-signer local TSA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+	}
+	rawCertificate, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := x509.ParseCertificate(rawCertificate)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalAuthority{certificate, key}, nil
+}
+
+func (a *LocalAuthority) Certificate() *x509.Certificate {
+	return a.certificate
+}
+
+func (a *LocalAuthority) Timestamp(ctx context.Context, digest []byte) (Token, error) {
+	reply, err := a.respond(timestamp.Request{
+		HashAlgorithm: crypto.SHA256,
+		HashedMessage: digest,
+		Certificates:  true,
+	})
+	if err != nil {
+		return Token{}, err
+	}
+	parsed, err := timestamp.ParseResponse(reply)
+	if err != nil {
+		return Token{}, err
+	}
+	return Token{Raw: parsed.RawToken, Time: parsed.Time}, nil
+}
+
+func (a *LocalAuthority) respond(request timestamp.Request) ([]byte, error) {
+	tsToken := timestamp.Timestamp{
+		HashAlgorithm:     request.HashAlgorithm,
+		HashedMessage:     request.HashedMessage,
+		Time:              time.Now().UTC(),
+		Accuracy:          time.Second,
+		Policy:            localPolicy,
+		Nonce:             request.Nonce,
+		AddTSACertificate: request.Certificates,
+	}
+	return tsToken.CreateResponseWithOpts(a.certificate, a.signer, crypto.SHA256)
+}
+
+// ServeHTTP answers RFC 3161 time-stamp queries.
+func (a *LocalAuthority) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != queryContentType {
+		http.Error(w, "expect a POST timestamp query", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxReplyBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request, err := timestamp.ParseRequest(body)
+	if err != nil {
+		reply, _ := timestamp.CreateErrorResponse(timestamp.Rejection, timestamp.BadDataFormat)
+		w.Header().Set("Content-Type", replyContentType)
+		w.Write(reply)
+		return
+	}
+	reply, err := a.respond(*request)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "can not create a timestamp response", "error", err)
+		http.Error(w, "An internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", replyContentType)
+	w.Write(reply)
+}
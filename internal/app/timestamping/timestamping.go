@@ -0,0 +1,64 @@
+// Package timestamping obtains and checks RFC 3161 timestamp tokens.
+package timestamping
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/digitorus/timestamp"
+)
+
+var (
+	ErrImprintMismatch = errors.New("a timestamp token covers another digest")
+	ErrUntrustedTSA    = errors.New("a timestamp token is signed by an untrusted TSA")
+)
+
+// Authority issues timestamp tokens over SHA-256 digests.
+type Authority interface {
+	Timestamp(ctx context.Context, digest []byte) (Token, error)
+}
+
+// Token is a DER encoded RFC 3161 TimeStampToken and its generation time.
+type Token struct {
+	Raw  []byte
+	Time time.Time
+}
+
+// Verify parses a token, checks its signature and that it covers a digest.
+// A nil pool skips a TSA certificate chain check.
+func Verify(rawToken []byte, digest []byte, roots *x509.CertPool) (Token, error) {
+	parsed, err := timestamp.Parse(rawToken)
+	if err != nil {
+		return Token{}, fmt.Errorf("invalid timestamp token: %w", err)
+	}
+	if parsed.HashAlgorithm != crypto.SHA256 || !bytes.Equal(parsed.HashedMessage, digest) {
+		return Token{}, ErrImprintMismatch
+	}
+	if roots != nil {
+		if err := verifyChain(parsed.Certificates, roots); err != nil {
+			return Token{}, errors.Join(ErrUntrustedTSA, err)
+		}
+	}
+	return Token{Raw: rawToken, Time: parsed.Time}, nil
+}
+
+func verifyChain(certificates []*x509.Certificate, roots *x509.CertPool) error {
+	if len(certificates) == 0 {
+		return errors.New("a token has no TSA certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err := certificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	return err
+}
@@ -0,0 +1,244 @@
+package timestamping
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitorus/timestamp"
+)
+
+func newTestAuthority(t *testing.T) (*LocalAuthority, *x509.CertPool) {
+	t.Helper()
+	authority, err := NewEphemeralLocalAuthority()
+	if err != nil {
+		t.Fatalf("can not create a local TSA: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(authority.Certificate())
+	return authority, roots
+}
+
+func testDigest(content string) []byte {
+	digest := sha256.Sum256([]byte(content))
+	return digest[:]
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	authority, roots := newTestAuthority(t)
+	server := httptest.NewServer(authority)
+	defer server.Close()
+	client := NewClient(server.URL, time.Second, roots)
+
+	digest := testDigest("a tree head")
+	token, err := client.Timestamp(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("can not get a timestamp: %v", err)
+	}
+	if age := time.Since(token.Time); age < -time.Second || age > time.Minute {
+		t.Errorf("unexpected timestamp time: %s", token.Time)
+	}
+	verified, err := Verify(token.Raw, digest, roots)
+	if err != nil {
+		t.Fatalf("can not verify a token: %v", err)
+	}
+	if !verified.Time.Equal(token.Time) {
+		t.Errorf("a verified time %s differs from %s", verified.Time, token.Time)
+	}
+}
+
+func TestVerifyTamperedImprint(t *testing.T) {
+	authority, roots := newTestAuthority(t)
+	digest := testDigest("a tree head")
+	token, err := authority.Timestamp(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("can not get a timestamp: %v", err)
+	}
+	if _, err := Verify(token.Raw, testDigest("another tree head"), roots); !errors.Is(err, ErrImprintMismatch) {
+		t.Errorf("a token covers another digest: %v", err)
+	}
+
+	position := bytes.Index(token.Raw, digest)
+	if position < 0 {
+		t.Fatal("a token has no imprint")
+	}
+	tampered := bytes.Clone(token.Raw)
+	tampered[position] ^= 0xff
+	tamperedDigest := bytes.Clone(digest)
+	tamperedDigest[0] ^= 0xff
+	_, err = Verify(tampered, tamperedDigest, roots)
+	if err == nil || errors.Is(err, ErrImprintMismatch) {
+		t.Errorf("a signature of a tampered imprint is not checked: %v", err)
+	}
+}
+
+func TestVerifyRoots(t *testing.T) {
+	authority, roots := newTestAuthority(t)
+	_, otherRoots := newTestAuthority(t)
+	digest := testDigest("a tree head")
+	token, err := authority.Timestamp(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("can not get a timestamp: %v", err)
+	}
+	if _, err := Verify(token.Raw, digest, roots); err != nil {
+		t.Errorf("a token of a trusted TSA is refused: %v", err)
+	}
+	if _, err := Verify(token.Raw, digest, otherRoots); !errors.Is(err, ErrUntrustedTSA) {
+		t.Errorf("unexpected error of an untrusted TSA: %v", err)
+	}
+	if _, err := Verify(token.Raw, digest, nil); err != nil {
+		t.Errorf("a token is refused without roots: %v", err)
+	}
+	if _, err := Verify([]byte("not a token"), digest, roots); err == nil {
+		t.Error("an invalid token is verified")
+	}
+}
+
+func TestClientRejectsWrongNonce(t *testing.T) {
+	authority, roots := newTestAuthority(t)
+	tests := map[string]*big.Int{
+		"another nonce": big.NewInt(42),
+		"no nonce":      nil,
+	}
+	for name, nonce := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reply, err := authority.respond(timestamp.Request{
+					HashAlgorithm: crypto.SHA256,
+					HashedMessage: testDigest("a tree head"),
+					Certificates:  true,
+					Nonce:         nonce,
+				})
+				if err != nil {
+					t.Errorf("can not create a reply: %v", err)
+				}
+				w.Header().Set("Content-Type", replyContentType)
+				w.Write(reply)
+			}))
+			defer server.Close()
+			client := NewClient(server.URL, time.Second, roots)
+			_, err := client.Timestamp(context.Background(), testDigest("a tree head"))
+			if err == nil || !strings.Contains(err.Error(), "nonce does not match") {
+				t.Errorf("unexpected error of a wrong nonce: %v", err)
+			}
+		})
+	}
+}
+
+func TestClientRejectsFailedResponses(t *testing.T) {
+	authority, roots := newTestAuthority(t)
+	tests := map[string]http.HandlerFunc{
+		"an error status": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		},
+		"not a reply": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("not a reply"))
+		},
+		"a reply over another digest": func(w http.ResponseWriter, r *http.Request) {
+			request, err := timestamp.ParseRequest(mustReadBody(t, r))
+			if err != nil {
+				t.Errorf("can not parse a request: %v", err)
+				return
+			}
+			request.HashedMessage = testDigest("another tree head")
+			reply, _ := authority.respond(*request)
+			w.Write(reply)
+		},
+	}
+	for name, handler := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(handler)
+			defer server.Close()
+			client := NewClient(server.URL, time.Second, roots)
+			if _, err := client.Timestamp(context.Background(), testDigest("a tree head")); err == nil {
+				t.Error("a failed response is accepted")
+			}
+		})
+	}
+}
+
+func mustReadBody(t *testing.T, r *http.Request) []byte {
+	t.Helper()
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(r.Body); err != nil {
+		t.Errorf("can not read a request: %v", err)
+	}
+	return body.Bytes()
+}
+
+func TestLocalAuthorityServeHTTP(t *testing.T) {
+	authority, _ := newTestAuthority(t)
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		status      int
+		rejected    bool
+	}{
+		{"a GET request", http.MethodGet, queryContentType, "", http.StatusBadRequest, false},
+		{"another content type", http.MethodPost, "application/json", "{}", http.StatusBadRequest, false},
+		{"a malformed query", http.MethodPost, queryContentType, "not a query", http.StatusOK, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, "/", strings.NewReader(test.body))
+			request.Header.Set("Content-Type", test.contentType)
+			response := httptest.NewRecorder()
+			authority.ServeHTTP(response, request)
+			if response.Code != test.status {
+				t.Errorf("unexpected status: %d", response.Code)
+			}
+			if _, err := timestamp.ParseResponse(response.Body.Bytes()); test.rejected && err == nil {
+				t.Error("a malformed query is not rejected")
+			}
+		})
+	}
+}
+
+func TestNewLocalAuthority(t *testing.T) {
+	ephemeral, roots := newTestAuthority(t)
+	key, err := x509.MarshalPKCS8PrivateKey(ephemeral.signer)
+	if err != nil {
+		t.Fatalf("can not encode a key: %v", err)
+	}
+	directory := t.TempDir()
+	certFile, keyFile := filepath.Join(directory, "tsa.crt"), filepath.Join(directory, "tsa.key")
+	files := map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: ephemeral.Certificate().Raw},
+		keyFile:  {Type: "PRIVATE KEY", Bytes: key},
+	}
+	for file, block := range files {
+		if err := os.WriteFile(file, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatalf("can not write a file: %v", err)
+		}
+	}
+
+	authority, err := NewLocalAuthority(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("can not load a local TSA: %v", err)
+	}
+	digest := testDigest("a tree head")
+	token, err := authority.Timestamp(context.Background(), digest)
+	if err != nil {
+		t.Fatalf("can not get a timestamp: %v", err)
+	}
+	if _, err := Verify(token.Raw, digest, roots); err != nil {
+		t.Errorf("a token of a loaded TSA is refused: %v", err)
+	}
+	if _, err := NewLocalAuthority(certFile, filepath.Join(directory, "missing.key")); err == nil {
+		t.Error("a TSA is loaded without a key")
+	}
+}
@@ -0,0 +1,11 @@
+package tracing
+
+import "go.opentelemetry.io/otel/attribute"
+
+// Spans carry no user IDs: a trace backend is not subject to erasure
+// of a user.
+const (
+	requestIDKey   = attribute.Key("request.id")
+	SignatureIDKey = attribute.Key("signature.id")
+	AnswerCountKey = attribute.Key("signature.answer_count")
+)
@@ -0,0 +1,128 @@
+// Package tracing configures OpenTelemetry tracing of the service.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	m "github.com/AndreyAD1/test-signer/internal/app/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ServiceName = "test-signer"
+
+var tracer = otel.Tracer("github.com/AndreyAD1/test-signer/internal/app/tracing")
+
+type Config struct {
+	// OTLPEndpoint is a host:port of an OTLP/HTTP collector.
+	// Spans are not exported if it is empty.
+	OTLPEndpoint string
+	Insecure     bool
+	SampleRatio  float64
+}
+
+// Setup installs W3C trace context propagation and a global tracer provider.
+// The returned function flushes remaining spans.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(
+		propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	)
+	if config.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.OTLPEndpoint)}
+	if config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("can not create an OTLP exporter: %w", err)
+	}
+	return SetupWithExporter(exporter, config.SampleRatio), nil
+}
+
+// SetupWithExporter installs a tracer provider exporting spans to an
+// arbitrary exporter, e.g. tracetest.InMemoryExporter.
+func SetupWithExporter(exporter sdktrace.SpanExporter, sampleRatio float64) func(context.Context) error {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(
+			sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio)),
+		),
+		sdktrace.WithResource(
+			resource.NewSchemaless(semconv.ServiceName(ServiceName)),
+		),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown
+}
+
+// Middleware starts a server span of a route continuing a trace
+// from incoming trace context headers. A panic fails a span.
+func Middleware(route string) m.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(
+				r.Context(),
+				propagation.HeaderCarrier(r.Header),
+			)
+			ctx, span := tracer.Start(
+				ctx,
+				r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.HTTPRoute(route),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+			if requestID := m.RequestIDFromContext(ctx); requestID != "" {
+				span.SetAttributes(requestIDKey.String(requestID))
+			}
+			recorder := m.NewStatusRecorder(w)
+			// a panic is answered with 500 by a recovery middleware,
+			// span.End records it as an exception
+			defer func() {
+				status := recorder.Status()
+				p := recover()
+				if p != nil {
+					status = http.StatusInternalServerError
+				}
+				span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+				if status >= http.StatusInternalServerError {
+					span.SetStatus(codes.Error, http.StatusText(status))
+				}
+				if p != nil {
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+		})
+	}
+}
+
+// RecordError marks a span as failed.
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// End records an error of a span and ends it.
+func End(span trace.Span, err error) {
+	RecordError(span, err)
+	span.End()
+}
@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/AndreyAD1/test-signer/internal/app/middleware"
+	"github.com/AndreyAD1/test-signer/internal/app/tracing/tracingtest"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestEnd(t *testing.T) {
+	exporter := tracingtest.RecordSpans(t)
+	_, failed := tracer.Start(context.Background(), "failed")
+	End(failed, errors.New("a repository error"))
+	_, succeeded := tracer.Start(context.Background(), "succeeded")
+	End(succeeded, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected spans: %d", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error || spans[0].Status.Description != "a repository error" {
+		t.Errorf("unexpected status of a failed span: %v", spans[0].Status)
+	}
+	if len(spans[0].Events) != 1 || spans[0].Events[0].Name != "exception" {
+		t.Errorf("an error is not recorded: %v", spans[0].Events)
+	}
+	if spans[1].Status.Code != codes.Unset || len(spans[1].Events) != 0 {
+		t.Errorf("unexpected status of a successful span: %v", spans[1].Status)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		code   codes.Code
+	}{
+		{name: "success", status: http.StatusCreated, code: codes.Unset},
+		{name: "client error", status: http.StatusBadRequest, code: codes.Unset},
+		{name: "server error", status: http.StatusInternalServerError, code: codes.Error},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exporter := tracingtest.RecordSpans(t)
+			var handlerSpan trace.SpanContext
+			handler := Middleware("/api/v1/sign")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerSpan = trace.SpanContextFromContext(r.Context())
+				w.WriteHeader(test.status)
+			}))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/sign", nil))
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("unexpected spans: %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name != "POST /api/v1/sign" || span.SpanKind != trace.SpanKindServer {
+				t.Errorf("unexpected span: '%s' of kind %s", span.Name, span.SpanKind)
+			}
+			if span.SpanContext.SpanID() != handlerSpan.SpanID() {
+				t.Errorf("a handler context does not carry a server span")
+			}
+			if span.Status.Code != test.code {
+				t.Errorf("unexpected status: %s, expected %s", span.Status.Code, test.code)
+			}
+			expectedStatus := semconv.HTTPResponseStatusCode(test.status)
+			found := false
+			for _, attribute := range span.Attributes {
+				found = found || attribute == expectedStatus
+			}
+			if !found {
+				t.Errorf("no response status attribute %d", test.status)
+			}
+		})
+	}
+}
+
+func TestMiddlewareContinuesTrace(t *testing.T) {
+	exporter := tracingtest.RecordSpans(t)
+	Setup(context.Background(), Config{})
+	handler := Middleware("/api/v1/verify")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	request := httptest.NewRequest("POST", "/api/v1/verify", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected spans: %d", len(spans))
+	}
+	if traceID := spans[0].SpanContext.TraceID().String(); traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected trace ID: %s", traceID)
+	}
+	if parentID := spans[0].Parent.SpanID().String(); parentID != "00f067aa0ba902b7" {
+		t.Errorf("unexpected parent span ID: %s", parentID)
+	}
+}
+
+func TestMiddlewarePanic(t *testing.T) {
+	exporter := tracingtest.RecordSpans(t)
+	handler := m.Recovery(Middleware("/api/v1/sign")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("a handler bug")
+	})))
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("POST", "/api/v1/sign", nil))
+	if response.Code != http.StatusInternalServerError {
+		t.Fatalf("unexpected status: %d", response.Code)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("unexpected spans: %d", len(spans))
+	}
+	span := spans[0]
+	if span.Status.Code != codes.Error {
+		t.Errorf("a panicking span has a status %s", span.Status.Code)
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+		t.Errorf("a panic is not recorded: %v", span.Events)
+	}
+	expectedStatus := semconv.HTTPResponseStatusCode(http.StatusInternalServerError)
+	found := false
+	for _, attribute := range span.Attributes {
+		found = found || attribute == expectedStatus
+	}
+	if !found {
+		t.Error("no response status attribute 500")
+	}
+}
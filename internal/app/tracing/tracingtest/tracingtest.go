@@ -0,0 +1,28 @@
+// Package tracingtest records spans of tests.
+package tracingtest
+
+import (
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanExporter    = tracetest.NewInMemoryExporter()
+	installProvider sync.Once
+)
+
+// RecordSpans installs a global tracer provider once: package tracers
+// delegate to the first installed provider only. It returns an exporter
+// without spans of previous tests.
+func RecordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	installProvider.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanExporter)))
+	})
+	spanExporter.Reset()
+	return spanExporter
+}
@@ -1,9 +1,230 @@
 package configuration
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
 type ServerConfig struct {
-	APISecret     string `env:"API_SECRET,required,notEmpty"`
-	DatabaseURL   string `env:"DATABASE_URL,required,notEmpty"`
-	ServerAddress string `env:"SERVER_ADDRESS" envDefault:"localhost:8080"`
-	SignKey       string `env:"SIGN_KEY,required,notEmpty"`
-	Debug         bool   `env:"DEBUG"`
+	APISecret      string         `env:"API_SECRET"`
+	DatabaseURL    string         `env:"DATABASE_URL,required,notEmpty"`
+	ServerAddress  string         `env:"SERVER_ADDRESS" envDefault:"localhost:8080"`
+	AdminAddress   string         `env:"ADMIN_ADDRESS" envDefault:"localhost:9090"`
+	SignKey        string         `env:"SIGN_KEY,required,notEmpty"`
+	Debug          bool           `env:"DEBUG"`
+	LogLevel       slog.Level     `env:"LOG_LEVEL" envDefault:"INFO"`
+	RequestTimeout time.Duration  `env:"REQUEST_TIMEOUT" envDefault:"5s"`
+	MaxBodyBytes   int64          `env:"MAX_BODY_BYTES" envDefault:"1048576"`
+	JWTIssuer      string         `env:"JWT_ISSUER"`
+	JWTAudience    string         `env:"JWT_AUDIENCE"`
+	JWTLeeway      time.Duration  `env:"JWT_LEEWAY" envDefault:"30s"`
+	JWTAlgorithms  []string       `env:"JWT_ALGORITHMS" envDefault:"HS256"`
+	JWKSURL        string         `env:"JWKS_URL"`
+	JWKSFile       string         `env:"JWKS_FILE"`
+	JWKSRefresh    time.Duration  `env:"JWKS_REFRESH_INTERVAL" envDefault:"15m"`
+	TrustedIssuers TrustedIssuers `env:"TRUSTED_ISSUERS"`
+	// LogSigningKey is a base64 Ed25519 seed signing transparency log tree heads.
+	LogSigningKey       string        `env:"LOG_SIGNING_KEY,required,notEmpty"`
+	LogTreeHeadInterval time.Duration `env:"LOG_TREE_HEAD_INTERVAL" envDefault:"1m"`
+	// TSAURL enables RFC 3161 timestamps from a remote TSA, TSALocal enables
+	// the built-in TSA. A local TSA without a certificate is ephemeral.
+	TSAURL      string        `env:"TSA_URL"`
+	TSACAFile   string        `env:"TSA_CA_FILE"`
+	TSATimeout  time.Duration `env:"TSA_TIMEOUT" envDefault:"10s"`
+	TSALocal    bool          `env:"TSA_LOCAL"`
+	TSACertFile string        `env:"TSA_CERT_FILE"`
+	TSAKeyFile  string        `env:"TSA_KEY_FILE"`
+	// OTLPEndpoint is a host:port of an OTLP/HTTP trace collector.
+	// Traces are not exported if it is empty.
+	OTLPEndpoint       string  `env:"OTLP_ENDPOINT"`
+	OTLPInsecure       bool    `env:"OTLP_INSECURE"`
+	TracingSampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+	// ShutdownDrainDelay is a pause between failing readiness and closing listeners.
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" envDefault:"0s"`
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+	// ShutdownTimeout limits a shutdown of every component.
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"10s"`
+	// TLSCertFile and TLSKeyFile enable HTTPS, TLSClientCAFile also enables
+	// mutual TLS authentication of verifiers.
+	TLSCertFile       string        `env:"TLS_CERT_FILE"`
+	TLSKeyFile        string        `env:"TLS_KEY_FILE"`
+	TLSMinVersion     string        `env:"TLS_MIN_VERSION" envDefault:"1.2"`
+	TLSClientCAFile   string        `env:"TLS_CLIENT_CA_FILE"`
+	TLSReloadInterval time.Duration `env:"TLS_RELOAD_INTERVAL" envDefault:"1m"`
+	// RetiredSignKeys only open signatures issued before a SIGN_KEY rotation.
+	RetiredSignKeys []string `env:"RETIRED_SIGN_KEYS"`
+	// SignRateLimit and VerifyRateLimit are requests per second refilling
+	// token buckets of a burst size. A zero rate disables a limit.
+	SignRateLimit   float64 `env:"SIGN_RATE_LIMIT" envDefault:"0"`
+	SignRateBurst   int     `env:"SIGN_RATE_BURST" envDefault:"10"`
+	VerifyRateLimit float64 `env:"VERIFY_RATE_LIMIT" envDefault:"0"`
+	VerifyRateBurst int     `env:"VERIFY_RATE_BURST" envDefault:"20"`
+	// RateLimitBackend is 'memory' or 'postgres' which shares limits among replicas.
+	RateLimitBackend string `env:"RATE_LIMIT_BACKEND" envDefault:"memory"`
+	// TenantClaim names a JWT claim with a tenant ID of a user. Tokens
+	// without it are rejected; if it is empty, all users are in a default tenant.
+	TenantClaim string `env:"TENANT_CLAIM"`
+	// TenantSignKeys are signing keys of tenants, a default tenant uses SIGN_KEY.
+	TenantSignKeys TenantKeys `env:"TENANT_SIGN_KEYS"`
+	// MasterKey wraps data keys which encrypt stored answers, retired master
+	// keys only unwrap data keys until they are rewrapped.
+	MasterKey         string   `env:"MASTER_KEY,required,notEmpty"`
+	RetiredMasterKeys []string `env:"RETIRED_MASTER_KEYS"`
+	// RetentionPeriod is an age of signatures whose answers are purged,
+	// RetentionRules override it for tenants and tests. Zero keeps answers.
+	RetentionPeriod    Period         `env:"RETENTION_PERIOD" envDefault:"0"`
+	RetentionRules     RetentionRules `env:"RETENTION_RULES"`
+	RetentionAction    string         `env:"RETENTION_ACTION" envDefault:"purge"`
+	RetentionDryRun    bool           `env:"RETENTION_DRY_RUN"`
+	RetentionInterval  time.Duration  `env:"RETENTION_INTERVAL" envDefault:"1h"`
+	RetentionBatchSize int            `env:"RETENTION_BATCH_SIZE" envDefault:"500"`
+	// JWTClientAudience is an audience of client-credential tokens of verifier
+	// clients, it must differ from JWT_AUDIENCE. Empty disables client tokens.
+	JWTClientAudience string `env:"JWT_CLIENT_AUDIENCE"`
+	// LogRateLimit limits public transparency log requests per IP address.
+	LogRateLimit float64 `env:"LOG_RATE_LIMIT" envDefault:"5"`
+	LogRateBurst int     `env:"LOG_RATE_BURST" envDefault:"20"`
+	// VerifyTimeout overrides REQUEST_TIMEOUT of verification which checks
+	// timestamps and inclusion proofs.
+	VerifyTimeout time.Duration `env:"VERIFY_TIMEOUT" envDefault:"15s"`
+	// AuditExportTimeout overrides REQUEST_TIMEOUT of audit log queries
+	// which can export a long period.
+	AuditExportTimeout time.Duration `env:"AUDIT_EXPORT_TIMEOUT" envDefault:"2m"`
+}
+
+// Level is a log level, DEBUG overrides LOG_LEVEL.
+func (c ServerConfig) Level() slog.Level {
+	if c.Debug {
+		return slog.LevelDebug
+	}
+	return c.LogLevel
+}
+
+// Validate checks variables which depend on each other. Keys and
+// certificates are checked when they are loaded.
+func (c ServerConfig) Validate() error {
+	var errs []error
+	if c.JWKSURL != "" && c.JWKSFile != "" {
+		errs = append(errs, errors.New("JWKS_URL and JWKS_FILE are mutually exclusive"))
+	}
+	if c.TSAURL != "" && c.TSALocal {
+		errs = append(errs, errors.New("TSA_URL and TSA_LOCAL are mutually exclusive"))
+	}
+	if c.TLSEnabled() && (c.TLSCertFile == "" || c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE are required for TLS"))
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs = append(errs, errors.New("TRACING_SAMPLE_RATIO must be between 0 and 1"))
+	}
+	if c.SignRateLimit < 0 || c.VerifyRateLimit < 0 || c.LogRateLimit < 0 {
+		errs = append(errs, errors.New("a rate limit can not be negative"))
+	}
+	if c.SignRateBurst < 1 || c.VerifyRateBurst < 1 || c.LogRateBurst < 1 {
+		errs = append(errs, errors.New("a rate limit burst must be at least 1"))
+	}
+	if c.RateLimitBackend != "memory" && c.RateLimitBackend != "postgres" {
+		errs = append(errs, errors.New("RATE_LIMIT_BACKEND must be 'memory' or 'postgres'"))
+	}
+	if _, ok := c.TenantSignKeys[""]; ok {
+		errs = append(errs, errors.New("TENANT_SIGN_KEYS can not have an empty tenant ID"))
+	}
+	for tenantID, keys := range c.TenantSignKeys {
+		if keys.SignKey == "" {
+			errs = append(errs, fmt.Errorf("TENANT_SIGN_KEYS has no sign_key of a tenant '%s'", tenantID))
+		}
+	}
+	if c.JWTClientAudience != "" && c.JWTClientAudience == c.JWTAudience {
+		errs = append(errs, errors.New("JWT_CLIENT_AUDIENCE must differ from JWT_AUDIENCE"))
+	}
+	for _, issuer := range c.TrustedIssuers {
+		if issuer.ClientAudience != "" && issuer.ClientAudience == issuer.Audience {
+			errs = append(errs, fmt.Errorf("a trusted issuer '%s' has the same user and client audience", issuer.Issuer))
+		}
+		if _, ok := c.TenantSignKeys[issuer.Tenant]; issuer.Tenant != "" && !ok {
+			errs = append(errs, fmt.Errorf("a trusted issuer '%s' has a tenant without keys", issuer.Issuer))
+		}
+	}
+	if c.RetentionAction != "purge" && c.RetentionAction != "archive" {
+		errs = append(errs, errors.New("RETENTION_ACTION must be 'purge' or 'archive'"))
+	}
+	if c.RequestTimeout <= 0 || c.VerifyTimeout <= 0 || c.AuditExportTimeout <= 0 {
+		errs = append(errs, errors.New("REQUEST_TIMEOUT, VERIFY_TIMEOUT and AUDIT_EXPORT_TIMEOUT must be positive"))
+	}
+	if c.RetentionInterval <= 0 {
+		errs = append(errs, errors.New("RETENTION_INTERVAL must be positive"))
+	}
+	if c.RetentionBatchSize < 1 {
+		errs = append(errs, errors.New("RETENTION_BATCH_SIZE must be at least 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// TLSEnabled reports whether a server listens with HTTPS.
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" || c.TLSClientCAFile != ""
+}
+
+// DatabaseConfig is a configuration of administrative commands.
+type DatabaseConfig struct {
+	DatabaseURL string `env:"DATABASE_URL,required,notEmpty"`
+}
+
+// MasterKeyConfig is a configuration of administrative commands
+// which read or rewrap encrypted answers.
+type MasterKeyConfig struct {
+	DatabaseURL       string   `env:"DATABASE_URL,required,notEmpty"`
+	MasterKey         string   `env:"MASTER_KEY,required,notEmpty"`
+	RetiredMasterKeys []string `env:"RETIRED_MASTER_KEYS"`
+}
+
+type TrustedIssuer struct {
+	Issuer         string `json:"issuer"`
+	Audience       string `json:"audience"`
+	ClientAudience string `json:"client_audience"`
+	UserClaim      string `json:"user_claim"`
+	// Tenant pins users of an issuer to a tenant regardless of their claims.
+	Tenant string `json:"tenant"`
+}
+
+// TrustedIssuers is a JSON list of OpenID Connect issuers, e.g.
+// [{"issuer": "https://idp.example.com", "audience": "test-signer", "user_claim": "sub"}].
+type TrustedIssuers []TrustedIssuer
+
+func (i *TrustedIssuers) UnmarshalText(text []byte) error {
+	return json.Unmarshal(text, (*[]TrustedIssuer)(i))
+}
+
+// TenantKey holds signing keys of a tenant.
+type TenantKey struct {
+	SignKey         string   `json:"sign_key"`
+	RetiredSignKeys []string `json:"retired_sign_keys"`
+}
+
+// TenantKeys is a JSON map of tenant IDs to signing keys, e.g.
+// {"acme": {"sign_key": "...", "retired_sign_keys": ["..."]}}.
+type TenantKeys map[string]TenantKey
+
+func (k *TenantKeys) UnmarshalText(text []byte) error {
+	return json.Unmarshal(text, (*map[string]TenantKey)(k))
+}
+
+// RetentionRule overrides RETENTION_PERIOD for signatures of a tenant,
+// of a test identified by a request ID pattern or both. A pattern is
+// an SQL LIKE pattern, e.g. 'exam-2020-%'. A rule without a tenant
+// matches all tenants, an empty tenant is a default tenant.
+type RetentionRule struct {
+	TenantID         *string `json:"tenant"`
+	RequestIDPattern string  `json:"request_id_pattern"`
+	Period           Period  `json:"period"`
+}
+
+// RetentionRules is a JSON list of rules, e.g.
+// [{"tenant": "acme", "request_id_pattern": "exam-%", "period": "10y"}].
+type RetentionRules []RetentionRule
+
+func (r *RetentionRules) UnmarshalText(text []byte) error {
+	return json.Unmarshal(text, (*[]RetentionRule)(r))
 }
@@ -0,0 +1,168 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/caarlos0/env/v9"
+	"gopkg.in/yaml.v3"
+)
+
+// FileVariable names a configuration file if a command line does not.
+const FileVariable = "CONFIG_FILE"
+
+// secretFileSuffix marks a variable which names a file with a secret,
+// e.g. a Docker or Kubernetes secret mounted as SIGN_KEY_FILE.
+const secretFileSuffix = "_FILE"
+
+// secretVariables can be read from files and are masked on print.
+var secretVariables = map[string]bool{
+	"API_SECRET":          true,
+	"DATABASE_URL":        true,
+	"SIGN_KEY":            true,
+	"RETIRED_SIGN_KEYS":   true,
+	"LOG_SIGNING_KEY":     true,
+	"TENANT_SIGN_KEYS":    true,
+	"MASTER_KEY":          true,
+	"RETIRED_MASTER_KEYS": true,
+}
+
+// Load fills a configuration from sources in a precedence order: overrides,
+// e.g. command line flags, environment variables, a configuration file
+// and defaults. An empty file falls back to CONFIG_FILE, a configuration
+// without a file is read from an environment only.
+func Load(config any, file string, overrides map[string]string) error {
+	environment, err := mergeSources(file, overrides)
+	if err != nil {
+		return err
+	}
+	return env.ParseWithOptions(config, env.Options{Environment: environment})
+}
+
+func mergeSources(file string, overrides map[string]string) (map[string]string, error) {
+	processEnvironment := map[string]string{}
+	for _, variable := range os.Environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		processEnvironment[name] = value
+	}
+	if file == "" {
+		file = processEnvironment[FileVariable]
+	}
+	fileValues := map[string]string{}
+	if file != "" {
+		var err error
+		if fileValues, err = readFile(file); err != nil {
+			return nil, err
+		}
+	}
+	merged := map[string]string{}
+	for _, source := range []map[string]string{fileValues, processEnvironment, overrides} {
+		values, err := resolveSecretFiles(source)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range values {
+			merged[name] = value
+		}
+	}
+	return merged, nil
+}
+
+// resolveSecretFiles replaces a variable like SIGN_KEY_FILE with SIGN_KEY
+// which has a content of a file. Both variables can not be set in one source.
+func resolveSecretFiles(source map[string]string) (map[string]string, error) {
+	values := map[string]string{}
+	for name, value := range source {
+		secretName, ok := strings.CutSuffix(name, secretFileSuffix)
+		if !ok || !secretVariables[secretName] {
+			values[name] = value
+			continue
+		}
+		if _, ok := source[secretName]; ok {
+			return nil, fmt.Errorf("%s and %s are mutually exclusive", secretName, name)
+		}
+		secret, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("can not read %s: %w", name, err)
+		}
+		values[secretName] = strings.TrimRight(string(secret), "\r\n")
+	}
+	return values, nil
+}
+
+// readFile reads a YAML or a TOML file. Its keys are variable names
+// in any case, e.g. 'sign_key' or 'SIGN_KEY'.
+func readFile(file string) (map[string]string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("can not read a configuration file: %w", err)
+	}
+	rawValues := map[string]any{}
+	switch extension := strings.ToLower(filepath.Ext(file)); extension {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &rawValues)
+	case ".toml":
+		err = toml.Unmarshal(content, &rawValues)
+	default:
+		return nil, fmt.Errorf("an unsupported configuration file '%s': use .yaml, .yml or .toml", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not parse a configuration file '%s': %w", file, err)
+	}
+	known := variableNames(reflect.TypeOf(ServerConfig{}))
+	values := map[string]string{}
+	for key, rawValue := range rawValues {
+		name := strings.ToUpper(key)
+		secretName, _ := strings.CutSuffix(name, secretFileSuffix)
+		if !known[name] && !(known[secretName] && secretVariables[secretName]) {
+			return nil, fmt.Errorf("an unknown variable '%s' in '%s'", key, file)
+		}
+		value, err := fileValue(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("an invalid variable '%s' in '%s': %w", key, file, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// fileValue formats a file value like an environment variable: lists of
+// scalars are comma-separated, other structures are JSON, e.g. TRUSTED_ISSUERS.
+func fileValue(rawValue any) (string, error) {
+	switch value := rawValue.(type) {
+	case string:
+		return value, nil
+	case []any:
+		items := []string{}
+		for _, item := range value {
+			switch item.(type) {
+			case map[string]any, []any:
+				encoded, err := json.Marshal(value)
+				return string(encoded), err
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any, []map[string]any:
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+func variableNames(configType reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < configType.NumField(); i++ {
+		name, _, _ := strings.Cut(configType.Field(i).Tag.Get("env"), ",")
+		if name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
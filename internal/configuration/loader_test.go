@@ -0,0 +1,199 @@
+package configuration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loaderConfig is a part of a server configuration, so a test does not
+// set every required variable.
+type loaderConfig struct {
+	APISecret      string         `env:"API_SECRET"`
+	ServerAddress  string         `env:"SERVER_ADDRESS" envDefault:"localhost:8080"`
+	AdminAddress   string         `env:"ADMIN_ADDRESS" envDefault:"localhost:9090"`
+	SignKey        string         `env:"SIGN_KEY"`
+	RequestTimeout time.Duration  `env:"REQUEST_TIMEOUT" envDefault:"5s"`
+	MaxBodyBytes   int64          `env:"MAX_BODY_BYTES" envDefault:"1048576"`
+	JWTAlgorithms  []string       `env:"JWT_ALGORITHMS" envDefault:"HS256"`
+	TrustedIssuers TrustedIssuers `env:"TRUSTED_ISSUERS"`
+}
+
+// clearEnvironment unsets loaded variables for a test.
+func clearEnvironment(t *testing.T) {
+	t.Helper()
+	known := variableNames(reflect.TypeOf(ServerConfig{}))
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		if name == FileVariable || known[strings.TrimSuffix(name, secretFileSuffix)] {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("can not write a file: %v", err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnvironment(t)
+	file := writeFile(t, "config.yaml", `
+server_address: file:1
+admin_address: file:2
+request_timeout: 7s
+`)
+	t.Setenv("SERVER_ADDRESS", "env:1")
+	t.Setenv("ADMIN_ADDRESS", "env:2")
+	var config loaderConfig
+	err := Load(&config, file, map[string]string{"SERVER_ADDRESS": "flag:1"})
+	if err != nil {
+		t.Fatalf("can not load a configuration: %v", err)
+	}
+	expected := loaderConfig{
+		ServerAddress:  "flag:1",
+		AdminAddress:   "env:2",
+		RequestTimeout: 7 * time.Second,
+		MaxBodyBytes:   1048576,
+		JWTAlgorithms:  []string{"HS256"},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("unexpected configuration: %+v", config)
+	}
+}
+
+func TestLoadFileFromEnvironment(t *testing.T) {
+	clearEnvironment(t)
+	t.Setenv(FileVariable, writeFile(t, "config.toml", `server_address = "file:1"`))
+	var config loaderConfig
+	if err := Load(&config, "", nil); err != nil {
+		t.Fatalf("can not load a configuration: %v", err)
+	}
+	if config.ServerAddress != "file:1" {
+		t.Errorf("CONFIG_FILE is not read: %+v", config)
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	clearEnvironment(t)
+	t.Setenv("SIGN_KEY_FILE", writeFile(t, "sign_key", "sign secret\n"))
+	file := writeFile(t, "config.yaml", "api_secret_file: "+writeFile(t, "api_secret", "api secret\r\n\n"))
+	var config loaderConfig
+	if err := Load(&config, file, nil); err != nil {
+		t.Fatalf("can not load a configuration: %v", err)
+	}
+	if config.SignKey != "sign secret" || config.APISecret != "api secret" {
+		t.Errorf("unexpected secrets: %q, %q", config.SignKey, config.APISecret)
+	}
+
+	t.Setenv("API_SECRET", "env secret")
+	if err := Load(&config, file, nil); err != nil || config.APISecret != "env secret" {
+		t.Errorf("an environment does not override a secret file of a file: %q, %v", config.APISecret, err)
+	}
+}
+
+func TestLoadSecretFileErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		err       string
+	}{
+		{
+			name:      "a variable and its file",
+			variables: map[string]string{"SIGN_KEY": "secret", "SIGN_KEY_FILE": "/dev/null"},
+			err:       "SIGN_KEY and SIGN_KEY_FILE are mutually exclusive",
+		},
+		{
+			name:      "a missing file",
+			variables: map[string]string{"API_SECRET_FILE": "/nonexistent/api_secret"},
+			err:       "can not read API_SECRET_FILE",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clearEnvironment(t)
+			for name, value := range test.variables {
+				t.Setenv(name, value)
+			}
+			var config loaderConfig
+			err := Load(&config, "", nil)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	expected := map[string]string{
+		"SERVER_ADDRESS":  "localhost:8000",
+		"MAX_BODY_BYTES":  "2048",
+		"DEBUG":           "true",
+		"JWT_ALGORITHMS":  "RS256,ES256",
+		"TRUSTED_ISSUERS": `[{"audience":"test-signer","issuer":"https://idp.example.com"}]`,
+	}
+	files := map[string]string{
+		"config.yaml": `
+server_address: localhost:8000
+MAX_BODY_BYTES: 2048
+debug: true
+jwt_algorithms: [RS256, ES256]
+trusted_issuers:
+  - issuer: https://idp.example.com
+    audience: test-signer
+`,
+		"config.toml": `
+server_address = "localhost:8000"
+MAX_BODY_BYTES = 2048
+debug = true
+jwt_algorithms = ["RS256", "ES256"]
+
+[[trusted_issuers]]
+issuer = "https://idp.example.com"
+audience = "test-signer"
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			values, err := readFile(writeFile(t, name, content))
+			if err != nil {
+				t.Fatalf("can not read a file: %v", err)
+			}
+			if len(values) != len(expected) {
+				t.Errorf("unexpected values: %v", values)
+			}
+			for variable, value := range expected {
+				if values[variable] != value {
+					t.Errorf("%s is %q, expected %q", variable, values[variable], value)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFileErrors(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		err     string
+	}{
+		"config.json": {`{}`, "an unsupported configuration file"},
+		"config.yaml": {"sign_key: [", "can not parse a configuration file"},
+		"config.toml": {`unknown_variable = 1`, "an unknown variable 'unknown_variable'"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := readFile(writeFile(t, name, test.content))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
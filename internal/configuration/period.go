@@ -0,0 +1,59 @@
+package configuration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is a calendar period in years, e.g. '7y', or days, e.g. '90d',
+// or a duration, e.g. '36h'. A zero period is '0'.
+type Period struct {
+	Years    int
+	Days     int
+	Duration time.Duration
+}
+
+func (p *Period) UnmarshalText(text []byte) error {
+	value := string(text)
+	for suffix, field := range map[string]*int{"y": &p.Years, "d": &p.Days} {
+		number, ok := strings.CutSuffix(value, suffix)
+		if !ok {
+			continue
+		}
+		count, err := strconv.Atoi(number)
+		if err != nil || count < 0 {
+			return fmt.Errorf("an invalid period '%s'", value)
+		}
+		*p = Period{}
+		*field = count
+		return nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return fmt.Errorf("an invalid period '%s'", value)
+	}
+	*p = Period{Duration: duration}
+	return nil
+}
+
+func (p Period) String() string {
+	switch {
+	case p.Years != 0:
+		return fmt.Sprintf("%dy", p.Years)
+	case p.Days != 0:
+		return fmt.Sprintf("%dd", p.Days)
+	case p.Duration != 0:
+		return p.Duration.String()
+	}
+	return "0"
+}
+
+func (p Period) IsZero() bool {
+	return p == Period{}
+}
+
+func (p Period) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
@@ -0,0 +1,66 @@
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/AndreyAD1/test-signer/internal/app/logging"
+)
+
+const masked = "[REDACTED]"
+
+// Variable is a configuration value named like an environment variable.
+type Variable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Variables lists a configuration in a field order. Secrets are masked,
+// a database URL keeps everything except a password.
+func Variables(config any) []Variable {
+	configValue := reflect.Indirect(reflect.ValueOf(config))
+	configType := configValue.Type()
+	variables := []Variable{}
+	for i := 0; i < configType.NumField(); i++ {
+		name, _, _ := strings.Cut(configType.Field(i).Tag.Get("env"), ",")
+		if name == "" {
+			continue
+		}
+		value := formatValue(configValue.Field(i))
+		switch {
+		case name == "DATABASE_URL":
+			value = logging.RedactURL(value)
+		case secretVariables[name] && value != "":
+			value = masked
+		}
+		variables = append(variables, Variable{name, value})
+	}
+	return variables
+}
+
+func formatValue(value reflect.Value) string {
+	if stringer, ok := value.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	if value.Kind() == reflect.Map {
+		if value.Len() == 0 {
+			return ""
+		}
+		encoded, _ := json.Marshal(value.Interface())
+		return string(encoded)
+	}
+	if value.Kind() != reflect.Slice {
+		return fmt.Sprint(value.Interface())
+	}
+	if value.Type().Elem().Kind() != reflect.String {
+		encoded, _ := json.Marshal(value.Interface())
+		return string(encoded)
+	}
+	items := []string{}
+	for i := 0; i < value.Len(); i++ {
+		items = append(items, value.Index(i).String())
+	}
+	return strings.Join(items, ",")
+}
@@ -0,0 +1,101 @@
+// Package merkle implements the Merkle tree of the test-signer transparency log.
+// It follows the RFC 6962 / RFC 9162 tree structure, so third parties can verify
+// inclusion and consistency proofs with the standard library only.
+package merkle
+
+import (
+	"crypto/sha256"
+	"math/bits"
+)
+
+const (
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// LeafHash is a hash of a log entry: SHA-256(0x00 || data).
+func LeafHash(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{leafPrefix})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// NodeHash is a hash of an interior node: SHA-256(0x01 || left || right).
+func NodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{nodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// EmptyRoot is a root hash of an empty tree.
+func EmptyRoot() []byte {
+	h := sha256.Sum256(nil)
+	return h[:]
+}
+
+// RootHash computes a tree root from leaf hashes.
+func RootHash(leafHashes [][]byte) []byte {
+	switch len(leafHashes) {
+	case 0:
+		return EmptyRoot()
+	case 1:
+		return leafHashes[0]
+	}
+	k := splitPoint(uint64(len(leafHashes)))
+	return NodeHash(RootHash(leafHashes[:k]), RootHash(leafHashes[k:]))
+}
+
+// InclusionProof returns an audit path of a leaf in a tree of given leaf hashes.
+func InclusionProof(leafHashes [][]byte, index uint64) ([][]byte, error) {
+	if index >= uint64(len(leafHashes)) {
+		return nil, ErrIndexOutOfRange
+	}
+	return inclusionPath(leafHashes, index), nil
+}
+
+func inclusionPath(leafHashes [][]byte, index uint64) [][]byte {
+	size := uint64(len(leafHashes))
+	if size <= 1 {
+		return [][]byte{}
+	}
+	k := splitPoint(size)
+	if index < k {
+		return append(inclusionPath(leafHashes[:k], index), RootHash(leafHashes[k:]))
+	}
+	return append(inclusionPath(leafHashes[k:], index-k), RootHash(leafHashes[:k]))
+}
+
+// ConsistencyProof proves that a tree of the first oldSize leaves is a prefix
+// of a tree of all leaf hashes.
+func ConsistencyProof(leafHashes [][]byte, oldSize uint64) ([][]byte, error) {
+	if oldSize > uint64(len(leafHashes)) {
+		return nil, ErrIndexOutOfRange
+	}
+	if oldSize == 0 {
+		return [][]byte{}, nil
+	}
+	return subproof(leafHashes, oldSize, true), nil
+}
+
+func subproof(leafHashes [][]byte, m uint64, complete bool) [][]byte {
+	size := uint64(len(leafHashes))
+	if m == size {
+		if complete {
+			return [][]byte{}
+		}
+		return [][]byte{RootHash(leafHashes)}
+	}
+	k := splitPoint(size)
+	if m <= k {
+		return append(subproof(leafHashes[:k], m, complete), RootHash(leafHashes[k:]))
+	}
+	return append(subproof(leafHashes[k:], m-k, false), RootHash(leafHashes[:k]))
+}
+
+// splitPoint is the largest power of two smaller than n.
+func splitPoint(n uint64) uint64 {
+	return 1 << (bits.Len64(n-1) - 1)
+}
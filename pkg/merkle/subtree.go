@@ -0,0 +1,144 @@
+package merkle
+
+import (
+	"errors"
+	"math/bits"
+)
+
+var ErrMissingSubtree = errors.New("a subtree hash is missing")
+
+// Subtree is a perfect subtree of 2^Level leaves which starts at
+// the leaf Index<<Level. A subtree of a complete range of leaves never
+// changes, so its hash can be stored once the leaves are appended.
+type Subtree struct {
+	Level uint8
+	Index uint64
+}
+
+// Range is leaves [Start, End) whose root hash is an element of a proof.
+type Range struct {
+	Start uint64
+	End   uint64
+}
+
+// Subtrees decomposes a range into perfect subtrees from left to right.
+// Every range of a proof starts at a multiple of a power of two which is
+// not smaller than the range, so subtrees decrease in size.
+func (r Range) Subtrees() []Subtree {
+	subtrees := []Subtree{}
+	for start := r.Start; start < r.End; {
+		level := bits.Len64(r.End-start) - 1
+		if start != 0 {
+			level = min(level, bits.TrailingZeros64(start))
+		}
+		subtrees = append(subtrees, Subtree{uint8(level), start >> level})
+		start += 1 << level
+	}
+	return subtrees
+}
+
+// RangeHash computes a root hash of a range from hashes of its subtrees.
+func RangeHash(r Range, hashes map[Subtree][]byte) ([]byte, error) {
+	subtrees := r.Subtrees()
+	if len(subtrees) == 0 {
+		return EmptyRoot(), nil
+	}
+	var root []byte
+	for i := len(subtrees) - 1; i >= 0; i-- {
+		hash, ok := hashes[subtrees[i]]
+		if !ok {
+			return nil, ErrMissingSubtree
+		}
+		if root == nil {
+			root = hash
+		} else {
+			root = NodeHash(hash, root)
+		}
+	}
+	return root, nil
+}
+
+// InclusionRanges lists ranges whose root hashes are an audit path of
+// a leaf in a tree of a size.
+func InclusionRanges(index, size uint64) ([]Range, error) {
+	if index >= size {
+		return nil, ErrIndexOutOfRange
+	}
+	return inclusionRanges(Range{0, size}, index), nil
+}
+
+func inclusionRanges(r Range, index uint64) []Range {
+	size := r.End - r.Start
+	if size <= 1 {
+		return []Range{}
+	}
+	k := splitPoint(size)
+	if index < k {
+		return append(inclusionRanges(Range{r.Start, r.Start + k}, index), Range{r.Start + k, r.End})
+	}
+	return append(inclusionRanges(Range{r.Start + k, r.End}, index-k), Range{r.Start, r.Start + k})
+}
+
+// ConsistencyRanges lists ranges whose root hashes prove that a tree of
+// the first oldSize leaves is a prefix of a tree of a size.
+func ConsistencyRanges(oldSize, size uint64) ([]Range, error) {
+	if oldSize > size {
+		return nil, ErrIndexOutOfRange
+	}
+	if oldSize == 0 {
+		return []Range{}, nil
+	}
+	return subproofRanges(Range{0, size}, oldSize, true), nil
+}
+
+func subproofRanges(r Range, m uint64, complete bool) []Range {
+	size := r.End - r.Start
+	if m == size {
+		if complete {
+			return []Range{}
+		}
+		return []Range{r}
+	}
+	k := splitPoint(size)
+	if m <= k {
+		return append(subproofRanges(Range{r.Start, r.Start + k}, m, complete), Range{r.Start + k, r.End})
+	}
+	return append(subproofRanges(Range{r.Start + k, r.End}, m-k, false), Range{r.Start, r.Start + k})
+}
+
+// AppendLeaves hashes subtrees completed by leaves appended to a tree of
+// a size. Frontier holds hashes of subtrees of Range{0, size}. It returns
+// hashes of new subtrees including the leaves themselves.
+func AppendLeaves(size uint64, frontier map[Subtree][]byte, leafHashes [][]byte) (map[Subtree][]byte, error) {
+	type node struct {
+		subtree Subtree
+		hash    []byte
+	}
+	stack := []node{}
+	for _, subtree := range (Range{0, size}).Subtrees() {
+		hash, ok := frontier[subtree]
+		if !ok {
+			return nil, ErrMissingSubtree
+		}
+		stack = append(stack, node{subtree, hash})
+	}
+	added := make(map[Subtree][]byte, 2*len(leafHashes))
+	for i, leafHash := range leafHashes {
+		leaf := node{Subtree{0, size + uint64(i)}, leafHash}
+		added[leaf.subtree] = leaf.hash
+		stack = append(stack, leaf)
+		for len(stack) >= 2 {
+			left, right := stack[len(stack)-2], stack[len(stack)-1]
+			if left.subtree.Level != right.subtree.Level {
+				break
+			}
+			parent := node{
+				Subtree{left.subtree.Level + 1, left.subtree.Index / 2},
+				NodeHash(left.hash, right.hash),
+			}
+			added[parent.subtree] = parent.hash
+			stack = append(stack[:len(stack)-2], parent)
+		}
+	}
+	return added, nil
+}
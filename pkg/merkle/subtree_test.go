@@ -0,0 +1,113 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func testLeafHashes(size uint64) [][]byte {
+	leafHashes := make([][]byte, 0, size)
+	for i := uint64(0); i < size; i++ {
+		leafHashes = append(leafHashes, LeafHash(binary.BigEndian.AppendUint64(nil, i)))
+	}
+	return leafHashes
+}
+
+// appendAll builds subtree hashes in batches as a log publisher does.
+func appendAll(t *testing.T, leafHashes [][]byte, batchSize int) map[Subtree][]byte {
+	t.Helper()
+	hashes := map[Subtree][]byte{}
+	for size := 0; size < len(leafHashes); size += batchSize {
+		batch := leafHashes[size:min(size+batchSize, len(leafHashes))]
+		added, err := AppendLeaves(uint64(size), hashes, batch)
+		if err != nil {
+			t.Fatalf("can not append leaves at %d: %v", size, err)
+		}
+		for subtree, hash := range added {
+			hashes[subtree] = hash
+		}
+	}
+	return hashes
+}
+
+func rangeHashes(t *testing.T, ranges []Range, hashes map[Subtree][]byte) [][]byte {
+	t.Helper()
+	proof := [][]byte{}
+	for _, r := range ranges {
+		hash, err := RangeHash(r, hashes)
+		if err != nil {
+			t.Fatalf("can not hash a range %v: %v", r, err)
+		}
+		proof = append(proof, hash)
+	}
+	return proof
+}
+
+func equalProofs(first, second [][]byte) bool {
+	if len(first) != len(second) {
+		return false
+	}
+	for i := range first {
+		if !bytes.Equal(first[i], second[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSubtreeProofsMatchLeafProofs(t *testing.T) {
+	const maxSize = 70
+	leafHashes := testLeafHashes(maxSize)
+	for _, batchSize := range []int{1, 3, 64} {
+		hashes := appendAll(t, leafHashes, batchSize)
+		for size := uint64(1); size <= maxSize; size++ {
+			tree := leafHashes[:size]
+			root, err := RangeHash(Range{0, size}, hashes)
+			if err != nil || !bytes.Equal(root, RootHash(tree)) {
+				t.Fatalf("batch %d: unexpected root of size %d: %v", batchSize, size, err)
+			}
+			for index := uint64(0); index < size; index++ {
+				ranges, err := InclusionRanges(index, size)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				expected, _ := InclusionProof(tree, index)
+				if !equalProofs(rangeHashes(t, ranges, hashes), expected) {
+					t.Errorf("batch %d: an inclusion proof of %d in %d differs", batchSize, index, size)
+				}
+			}
+			for oldSize := uint64(0); oldSize <= size; oldSize++ {
+				ranges, err := ConsistencyRanges(oldSize, size)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				expected, _ := ConsistencyProof(tree, oldSize)
+				if !equalProofs(rangeHashes(t, ranges, hashes), expected) {
+					t.Errorf("batch %d: a consistency proof of %d in %d differs", batchSize, oldSize, size)
+				}
+			}
+		}
+	}
+}
+
+func TestRangeHashReadsLogarithmicSubtrees(t *testing.T) {
+	size := uint64(1<<40 + 12345)
+	ranges, err := InclusionRanges(size-1, size)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	subtrees := 0
+	for _, r := range ranges {
+		subtrees += len(r.Subtrees())
+	}
+	if subtrees > 41*41 {
+		t.Errorf("an inclusion proof reads %d subtrees", subtrees)
+	}
+}
+
+func TestAppendLeavesRequiresFrontier(t *testing.T) {
+	if _, err := AppendLeaves(3, map[Subtree][]byte{}, testLeafHashes(1)); err != ErrMissingSubtree {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
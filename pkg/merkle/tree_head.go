@@ -0,0 +1,41 @@
+package merkle
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const treeHeadDomain = "test-signer/tree-head/v1"
+
+var ErrInvalidTreeHead = errors.New("a tree head signature is invalid")
+
+// TreeHead is a log state signed by the log key.
+type TreeHead struct {
+	Size      uint64    `json:"tree_size"`
+	Timestamp time.Time `json:"timestamp"`
+	RootHash  []byte    `json:"root_hash"`
+	Signature []byte    `json:"signature"`
+}
+
+// SignedData is a byte string covered by a tree head signature.
+func (h TreeHead) SignedData() []byte {
+	data := []byte(treeHeadDomain)
+	data = binary.BigEndian.AppendUint64(data, h.Size)
+	data = binary.BigEndian.AppendUint64(data, uint64(h.Timestamp.UnixMilli()))
+	return append(data, h.RootHash...)
+}
+
+func SignTreeHead(key ed25519.PrivateKey, head TreeHead) TreeHead {
+	head.Timestamp = time.UnixMilli(head.Timestamp.UnixMilli()).UTC()
+	head.Signature = ed25519.Sign(key, head.SignedData())
+	return head
+}
+
+func VerifyTreeHead(key ed25519.PublicKey, head TreeHead) error {
+	if !ed25519.Verify(key, head.SignedData(), head.Signature) {
+		return ErrInvalidTreeHead
+	}
+	return nil
+}
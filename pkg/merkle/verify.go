@@ -0,0 +1,96 @@
+package merkle
+
+import (
+	"bytes"
+	"errors"
+)
+
+var (
+	ErrIndexOutOfRange = errors.New("a leaf index or a tree size is out of range")
+	ErrInvalidProof    = errors.New("a proof is invalid")
+)
+
+// VerifyInclusion checks an audit path of a leaf hash against a tree root
+// as described in RFC 9162, section 2.1.3.2.
+func VerifyInclusion(index, size uint64, leafHash []byte, proof [][]byte, root []byte) error {
+	if index >= size {
+		return ErrIndexOutOfRange
+	}
+	fn, sn := index, size-1
+	result := leafHash
+	for _, node := range proof {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			result = NodeHash(node, result)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			result = NodeHash(result, node)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(result, root) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// VerifyConsistency checks that a tree of oldSize leaves with oldRoot is
+// a prefix of a tree of newSize leaves with newRoot as described
+// in RFC 9162, section 2.1.4.2.
+func VerifyConsistency(oldSize, newSize uint64, oldRoot, newRoot []byte, proof [][]byte) error {
+	if oldSize > newSize {
+		return ErrIndexOutOfRange
+	}
+	if oldSize == newSize {
+		if len(proof) != 0 || !bytes.Equal(oldRoot, newRoot) {
+			return ErrInvalidProof
+		}
+		return nil
+	}
+	if oldSize == 0 {
+		if len(proof) != 0 {
+			return ErrInvalidProof
+		}
+		return nil
+	}
+	if len(proof) == 0 {
+		return ErrInvalidProof
+	}
+	path := proof
+	if oldSize&(oldSize-1) == 0 {
+		path = append([][]byte{oldRoot}, proof...)
+	}
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	oldResult, newResult := path[0], path[0]
+	for _, node := range path[1:] {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			oldResult = NodeHash(node, oldResult)
+			newResult = NodeHash(node, newResult)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			newResult = NodeHash(newResult, node)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(oldResult, oldRoot) || !bytes.Equal(newResult, newRoot) {
+		return ErrInvalidProof
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+const maxVerifySize = 33
+
+// flipped returns a copy of a proof with a flipped bit of a node.
+func flipped(proof [][]byte, node int) [][]byte {
+	tampered := make([][]byte, len(proof))
+	copy(tampered, proof)
+	tampered[node] = bytes.Clone(proof[node])
+	tampered[node][0] ^= 1
+	return tampered
+}
+
+func TestVerifyInclusionRejectsInvalidProofs(t *testing.T) {
+	for size := uint64(1); size <= maxVerifySize; size++ {
+		leafHashes := testLeafHashes(size)
+		root := RootHash(leafHashes)
+		for index := uint64(0); index < size; index++ {
+			proof, err := InclusionProof(leafHashes, index)
+			if err != nil {
+				t.Fatalf("can not prove %d of %d: %v", index, size, err)
+			}
+			leafHash := leafHashes[index]
+			if err := VerifyInclusion(index, size, leafHash, proof, root); err != nil {
+				t.Fatalf("a valid proof of %d of %d is rejected: %v", index, size, err)
+			}
+			invalid := map[string]error{
+				"another leaf":  VerifyInclusion(index, size, LeafHash([]byte("another")), proof, root),
+				"another root":  VerifyInclusion(index, size, leafHash, proof, LeafHash([]byte("another"))),
+				"an extra node": VerifyInclusion(index, size, leafHash, append(proof, root), root),
+			}
+			if index+1 < size {
+				invalid["another index"] = VerifyInclusion(index+1, size, leafHash, proof, root)
+			}
+			if index > 0 {
+				invalid["a previous index"] = VerifyInclusion(index-1, size, leafHash, proof, root)
+			}
+			if len(proof) > 0 {
+				invalid["a truncated proof"] = VerifyInclusion(index, size, leafHash, proof[:len(proof)-1], root)
+			}
+			// a proof binds a size only by a shape of its path, a signed
+			// tree head binds a size to a root
+			for otherSize := index + 1; otherSize <= maxVerifySize; otherSize++ {
+				otherProof, _ := InclusionProof(testLeafHashes(otherSize), index)
+				if len(otherProof) != len(proof) {
+					name := fmt.Sprintf("a size %d", otherSize)
+					invalid[name] = VerifyInclusion(index, otherSize, leafHash, proof, root)
+				}
+			}
+			for node := range proof {
+				tampered := flipped(proof, node)
+				if err := VerifyInclusion(index, size, leafHash, tampered, root); !errors.Is(err, ErrInvalidProof) {
+					t.Errorf("%d of %d: a flipped node %d: %v", index, size, node, err)
+				}
+			}
+			for name, err := range invalid {
+				if err == nil {
+					t.Errorf("%d of %d: %s is verified", index, size, name)
+				}
+			}
+		}
+		if err := VerifyInclusion(size, size, leafHashes[0], nil, root); !errors.Is(err, ErrIndexOutOfRange) {
+			t.Errorf("an index out of %d: %v", size, err)
+		}
+	}
+}
+
+func TestVerifyConsistencyRejectsInvalidProofs(t *testing.T) {
+	for newSize := uint64(2); newSize <= maxVerifySize; newSize++ {
+		leafHashes := testLeafHashes(newSize)
+		newRoot := RootHash(leafHashes)
+		for oldSize := uint64(1); oldSize < newSize; oldSize++ {
+			oldRoot := RootHash(leafHashes[:oldSize])
+			proof, err := ConsistencyProof(leafHashes, oldSize)
+			if err != nil {
+				t.Fatalf("can not prove %d to %d: %v", oldSize, newSize, err)
+			}
+			if err := VerifyConsistency(oldSize, newSize, oldRoot, newRoot, proof); err != nil {
+				t.Fatalf("a valid proof of %d to %d is rejected: %v", oldSize, newSize, err)
+			}
+			invalid := map[string]error{
+				"swapped roots":    VerifyConsistency(oldSize, newSize, newRoot, oldRoot, proof),
+				"another old root": VerifyConsistency(oldSize, newSize, LeafHash([]byte("another")), newRoot, proof),
+				"no proof":         VerifyConsistency(oldSize, newSize, oldRoot, newRoot, nil),
+				"a truncated proof": VerifyConsistency(
+					oldSize, newSize, oldRoot, newRoot, proof[:len(proof)-1],
+				),
+				"an extra node": VerifyConsistency(oldSize, newSize, oldRoot, newRoot, append(proof, newRoot)),
+			}
+			for otherSize := oldSize + 1; otherSize <= maxVerifySize; otherSize++ {
+				otherProof, _ := ConsistencyProof(testLeafHashes(otherSize), oldSize)
+				if len(otherProof) != len(proof) {
+					name := fmt.Sprintf("a new size %d", otherSize)
+					invalid[name] = VerifyConsistency(oldSize, otherSize, oldRoot, newRoot, proof)
+				}
+			}
+			for otherSize := uint64(1); otherSize < newSize; otherSize++ {
+				otherProof, _ := ConsistencyProof(leafHashes, otherSize)
+				if len(otherProof) != len(proof) {
+					name := fmt.Sprintf("an old size %d", otherSize)
+					invalid[name] = VerifyConsistency(otherSize, newSize, oldRoot, newRoot, proof)
+				}
+			}
+			for node := range proof {
+				tampered := flipped(proof, node)
+				if err := VerifyConsistency(oldSize, newSize, oldRoot, newRoot, tampered); !errors.Is(err, ErrInvalidProof) {
+					t.Errorf("%d to %d: a flipped node %d: %v", oldSize, newSize, node, err)
+				}
+			}
+			for name, err := range invalid {
+				if err == nil {
+					t.Errorf("%d to %d: %s is verified", oldSize, newSize, name)
+				}
+			}
+		}
+	}
+	root := RootHash(testLeafHashes(2))
+	if err := VerifyConsistency(3, 2, root, root, nil); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("a shrinking tree: %v", err)
+	}
+	if err := VerifyConsistency(2, 2, root, root, [][]byte{root}); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("a proof of an unchanged tree: %v", err)
+	}
+	if err := VerifyConsistency(2, 2, root, EmptyRoot(), nil); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("another root of an unchanged tree: %v", err)
+	}
+}
+
+func TestVerifyTreeHeadRejectsTampering(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("can not generate a key: %v", err)
+	}
+	otherKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("can not generate a key: %v", err)
+	}
+	head := SignTreeHead(privateKey, TreeHead{
+		Size:      5,
+		Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		RootHash:  RootHash(testLeafHashes(5)),
+	})
+	if err := VerifyTreeHead(publicKey, head); err != nil {
+		t.Fatalf("a valid tree head is rejected: %v", err)
+	}
+	tests := map[string]func(TreeHead) TreeHead{
+		"a size": func(h TreeHead) TreeHead {
+			h.Size++
+			return h
+		},
+		"a timestamp": func(h TreeHead) TreeHead {
+			h.Timestamp = h.Timestamp.Add(time.Millisecond)
+			return h
+		},
+		"a root hash": func(h TreeHead) TreeHead {
+			h.RootHash = flipped([][]byte{h.RootHash}, 0)[0]
+			return h
+		},
+		"a signature": func(h TreeHead) TreeHead {
+			h.Signature = flipped([][]byte{h.Signature}, 0)[0]
+			return h
+		},
+		"a truncated signature": func(h TreeHead) TreeHead {
+			h.Signature = h.Signature[:len(h.Signature)-1]
+			return h
+		},
+	}
+	for name, tamper := range tests {
+		if err := VerifyTreeHead(publicKey, tamper(head)); !errors.Is(err, ErrInvalidTreeHead) {
+			t.Errorf("a tampered %s: %v", name, err)
+		}
+	}
+	if err := VerifyTreeHead(otherKey, head); !errors.Is(err, ErrInvalidTreeHead) {
+		t.Errorf("a tree head of another key: %v", err)
+	}
+}